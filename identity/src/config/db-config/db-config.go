@@ -0,0 +1,29 @@
+// Package dbconfig holds the database connection of the service the identity packages run in
+package dbconfig
+
+import (
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+var (
+	db *gorm.DB
+	mu sync.RWMutex
+)
+
+// SetDB hands the identity packages the service's connection; call it once the schema is migrated
+func SetDB(conn *gorm.DB) {
+	mu.Lock()
+	defer mu.Unlock()
+	db = conn
+}
+
+func GetDB() *gorm.DB {
+	mu.RLock()
+	defer mu.RUnlock()
+	if db == nil {
+		panic("Identity database not set. Call SetDB first.")
+	}
+	return db
+}
@@ -0,0 +1,346 @@
+package authcontroller
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	dbconfig "github.com/yatender-pareek/identity/src/config/db-config"
+	authdto "github.com/yatender-pareek/identity/src/dtos/auth-dto"
+	userentity "github.com/yatender-pareek/identity/src/models/user-model"
+	auditservice "github.com/yatender-pareek/identity/src/services/audit-service"
+	lockoutservice "github.com/yatender-pareek/identity/src/services/lockout-service"
+	mfaservice "github.com/yatender-pareek/identity/src/services/mfa-service"
+	oidcservice "github.com/yatender-pareek/identity/src/services/oidc-service"
+	sessionservice "github.com/yatender-pareek/identity/src/services/session-service"
+	tenantservice "github.com/yatender-pareek/identity/src/services/tenant-service"
+	tokenservice "github.com/yatender-pareek/identity/src/services/token-service"
+	"github.com/yatender-pareek/identity/src/tenancy"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var (
+	validate = validator.New()
+
+	// startMFA and completeSSO are replaced in tests, which have no database or identity provider
+	startMFA = func(user userentity.User) (*mfaservice.LoginChallenge, error) {
+		return mfaservice.NewMFAService().StartLogin(user)
+	}
+	completeSSO = func(state, code string) (userentity.User, error) {
+		return oidcservice.Default().CompleteLogin(state, code)
+	}
+
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+// Register handles user registration
+// @Summary Register a new user
+// @Description Creates a new user account with username, password, and email. Without a tenant the user joins the default tenant;
+// @Description naming a tenant creates it with the user as its first member and admin, so it must not exist yet.
+// @Description Users joining the default tenant get the viewer role until an admin assigns another.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param user body authdto.RegisterRequestDTO true "User registration details"
+// @Success 200 {object} authdto.RegisterResponseDTO "Registration success"
+// @Failure 400 {object} map[string]string "error: Invalid input"
+// @Failure 409 {object} map[string]string "error: Username, email or tenant already exists"
+// @Failure 500 {object} map[string]string "error: Server error"
+// @Router /api/register [post]
+func Register(c *gin.Context) {
+	var registerDTO authdto.RegisterRequestDTO
+	if err := c.ShouldBindJSON(&registerDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if err := validate.Struct(&registerDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(registerDTO.Password), bcrypt.DefaultCost)
+	if err != nil {
+		log.Printf("Password hashing error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		return
+	}
+	user := userentity.User{
+		Username: registerDTO.Username,
+		Password: string(hashedPassword),
+		Email:    registerDTO.Email,
+		Tenant:   registerDTO.Tenant,
+		Role:     tenancy.RoleAdmin,
+	}
+	// Whoever opens a tenant administers it; users joining the shared default tenant can only read
+	if user.Tenant == "" {
+		user.Tenant = tenancy.DefaultTenant
+		user.Role = tenancy.RoleViewer
+	}
+
+	err = dbconfig.GetDB().Transaction(func(tx *gorm.DB) error {
+		if registerDTO.Tenant != "" {
+			if _, err := tenantservice.NewTenantService().CreateTenant(tx, registerDTO.Tenant); err != nil {
+				return err
+			}
+		}
+		return tx.Create(&user).Error
+	})
+	if err != nil {
+		switch err {
+		case tenantservice.ErrTenantExists:
+			c.JSON(http.StatusConflict, gin.H{"error": "Tenant already exists; ask a platform admin to add you to it"})
+			return
+		case tenantservice.ErrInvalidTenant:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+		if strings.Contains(err.Error(), "Duplicate entry") || err == gorm.ErrDuplicatedKey {
+			c.JSON(http.StatusConflict, gin.H{"error": "Username or email already exists"})
+			return
+		}
+		log.Printf("Database error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		return
+	}
+
+	response := authdto.RegisterResponseDTO{
+		Message: "User registered successfully",
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// Login handles user login and JWT generation
+// @Summary User login
+// @Description Authenticates a user using query parameters and opens a session: returns a short-lived JWT access token
+// @Description and a refresh token for POST /token/refresh. Users with MFA, or whose role requires it, instead get 202 with
+// @Description a short-lived challenge token to exchange for the tokens at POST /login/mfa.
+// @Description Repeated failures lock out the username and the client address for a growing time (429 with Retry-After).
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param username query string true "User username"
+// @Param password query string true "User password"
+// @Success 200 {object} authdto.LoginResponseDTO "Login success with JWT token"
+// @Success 202 {object} authdto.MFAChallengeResponseDTO "Password accepted; a second factor is required"
+// @Failure 400 {object} map[string]string "error: Invalid input"
+// @Failure 401 {object} map[string]string "error: Invalid credentials"
+// @Failure 429 {object} map[string]string "error: Too many failed logins for the username or from the address"
+// @Failure 500 {object} map[string]string "error: Server error"
+// @Router /api/login [post]
+func Login(c *gin.Context) {
+	var loginDTO authdto.LoginRequestDTO
+	if err := c.ShouldBindQuery(&loginDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := validate.Struct(&loginDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	lockout := lockoutservice.NewLockoutService()
+	if locked, err := lockout.LockedFor(loginDTO.Username, c.ClientIP()); err != nil {
+		log.Printf("Lockout error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		return
+	} else if locked > 0 {
+		auditservice.RecordUser(c, auditservice.ActionLoginLocked, loginDTO.Username, "")
+		respondLocked(c, locked)
+		return
+	}
+
+	var storedUser userentity.User
+	passwordHash := dummyPasswordHash()
+	if err := dbconfig.GetDB().Where("username = ?", loginDTO.Username).First(&storedUser).Error; err == nil {
+		passwordHash = []byte(storedUser.Password)
+	} else if err != gorm.ErrRecordNotFound {
+		log.Printf("Database error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		return
+	}
+
+	// Unknown usernames are checked against a dummy hash and counted like wrong passwords,
+	// so neither the response time nor a lockout tells which accounts exist
+	if err := bcrypt.CompareHashAndPassword(passwordHash, []byte(loginDTO.Password)); err != nil || storedUser.ID == 0 {
+		auditservice.RecordUser(c, auditservice.ActionLoginFailed, loginDTO.Username, storedUser.Tenant, "authMethod", "password")
+		recordLoginFailure(c, loginDTO.Username)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		return
+	}
+	if err := lockout.RecordSuccess(storedUser.Username); err != nil {
+		log.Printf("Lockout error: %v", err)
+	}
+	if challenged(c, storedUser) {
+		return
+	}
+	session, refreshToken, err := sessionservice.NewSessionService().StartSession(storedUser, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		log.Printf("Session error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		return
+	}
+	response, err := issueTokens(storedUser, session.ID, refreshToken)
+	if err != nil {
+		log.Printf("Token generation error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		return
+	}
+
+	auditservice.RecordUser(c, auditservice.ActionLoginSuccess, storedUser.Username, storedUser.Tenant, "authMethod", "password")
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RefreshToken exchanges a refresh token for a new token pair
+// @Summary Refresh an access token
+// @Description Exchanges a refresh token for a new access token and refresh token; the presented refresh token stops working.
+// @Description The new access token carries the user's current tenant and role. Reusing a refresh token revokes its session.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body authdto.RefreshRequestDTO true "Refresh token"
+// @Success 200 {object} authdto.LoginResponseDTO "New token pair"
+// @Failure 400 {object} map[string]string "error: Invalid input"
+// @Failure 401 {object} map[string]string "error: Invalid, expired, revoked or reused refresh token"
+// @Failure 500 {object} map[string]string "error: Server error"
+// @Router /api/token/refresh [post]
+func RefreshToken(c *gin.Context) {
+	var refreshDTO authdto.RefreshRequestDTO
+	if err := c.ShouldBindJSON(&refreshDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := validate.Struct(&refreshDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	session, user, refreshToken, err := sessionservice.NewSessionService().Refresh(refreshDTO.RefreshToken)
+	if err != nil {
+		if err == sessionservice.ErrInvalidRefreshToken || err == sessionservice.ErrRefreshTokenReused {
+			auditservice.RecordRequest(c, auditservice.ActionTokenRefreshFailed, "reason", err.Error())
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Session error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		return
+	}
+	response, err := issueTokens(user, session.ID, refreshToken)
+	if err != nil {
+		log.Printf("Token generation error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// Logout ends the caller's session
+// @Summary Log out
+// @Description Revokes the presented access token and its session, so neither it nor the session's refresh token works any more
+// @Tags Auth
+// @Security BearerAuth
+// @Success 204 "Logged out"
+// @Failure 400 {object} map[string]string "error: Not a login session"
+// @Failure 401 {object} map[string]string "error: Invalid token"
+// @Failure 500 {object} map[string]string "error: Server error"
+// @Router /api/logout [post]
+func Logout(c *gin.Context) {
+	sessionID := c.GetString("session")
+	if sessionID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only login sessions can log out"})
+		return
+	}
+	if err := sessionservice.NewSessionService().EndSession(sessionID, c.GetString("jti"), c.GetTime("tokenExpiresAt")); err != nil {
+		log.Printf("Logout error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		return
+	}
+	auditservice.RecordRequest(c, auditservice.ActionLogout)
+	c.Status(http.StatusNoContent)
+}
+
+// JWKS publishes the public keys access tokens are signed with
+// @Summary JSON Web Key Set
+// @Description Public keys of the token issuer (RFC 7517). Tokens name their key in the kid header; during a key rotation
+// @Description both the new and the retiring keys are listed. Services verifying tokens fetch and cache this set.
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} tokenservice.JWKS "Signing keys"
+// @Router /.well-known/jwks.json [get]
+func JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, tokenservice.PublicJWKS())
+}
+
+// recordLoginFailure counts a failed login against the username and the client address
+func recordLoginFailure(c *gin.Context, username string) {
+	locked, err := lockoutservice.NewLockoutService().RecordFailure(username, c.ClientIP())
+	if err != nil {
+		log.Printf("Lockout error: %v", err)
+		return
+	}
+	if locked > 0 {
+		log.Printf("Logins for %s or from %s locked out for %s after failed attempts", username, c.ClientIP(), locked)
+	}
+}
+
+// challenged answers 202 with an MFA challenge when the user has MFA or their role requires it,
+// or 500 when that cannot be decided; it reports whether it answered
+func challenged(c *gin.Context, user userentity.User) bool {
+	challenge, err := startMFA(user)
+	if err != nil {
+		log.Printf("MFA error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		return true
+	}
+	if challenge == nil {
+		return false
+	}
+	c.JSON(http.StatusAccepted, authdto.MFAChallengeResponseDTO{
+		ChallengeToken: challenge.Token,
+		Purpose:        challenge.Purpose,
+		ExpiresIn:      int64(time.Until(challenge.ExpiresAt) / time.Second),
+	})
+	return true
+}
+
+func respondLocked(c *gin.Context, locked time.Duration) {
+	seconds := int64((locked + time.Second - 1) / time.Second)
+	c.Header("Retry-After", strconv.FormatInt(seconds, 10))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": fmt.Sprintf("Too many failed logins; try again in %d seconds", seconds)})
+}
+
+// dummyPasswordHash is a hash at the cost of real ones, to check passwords of unknown users against
+func dummyPasswordHash() []byte {
+	dummyHashOnce.Do(func() {
+		var err error
+		if dummyHash, err = bcrypt.GenerateFromPassword([]byte("not a password of any user"), bcrypt.DefaultCost); err != nil {
+			log.Fatalf("Failed to hash dummy password: %v", err)
+		}
+	})
+	return dummyHash
+}
+
+// issueTokens signs an access token for the session and pairs it with the session's refresh token
+func issueTokens(user userentity.User, sessionID, refreshToken string) (authdto.LoginResponseDTO, error) {
+	tokenString, ttl, err := tokenservice.IssueAccessToken(user, sessionID)
+	if err != nil {
+		return authdto.LoginResponseDTO{}, err
+	}
+	return authdto.LoginResponseDTO{
+		Token:        tokenString,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(ttl / time.Second),
+		TokenType:    "Bearer",
+	}, nil
+}
@@ -0,0 +1,114 @@
+package authcontroller
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	authdto "github.com/yatender-pareek/identity/src/dtos/auth-dto"
+	mfadto "github.com/yatender-pareek/identity/src/dtos/mfa-dto"
+	auditservice "github.com/yatender-pareek/identity/src/services/audit-service"
+	mfaservice "github.com/yatender-pareek/identity/src/services/mfa-service"
+	sessionservice "github.com/yatender-pareek/identity/src/services/session-service"
+)
+
+// MFALogin completes a login that needs a second factor
+// @Summary Second login step
+// @Description Exchanges the challenge token of a password login and a code for the access and refresh tokens.
+// @Description A verify challenge accepts a TOTP code or an unused recovery code. An enroll challenge takes a TOTP code
+// @Description of the secret from POST /login/mfa/enroll, enables MFA and also returns the user's recovery codes.
+// @Description A challenge works once and fails after too many wrong codes; wrong codes also count towards the login lockout.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body authdto.MFALoginRequestDTO true "Challenge token and code"
+// @Success 200 {object} authdto.LoginResponseDTO "Login success with JWT token"
+// @Failure 400 {object} map[string]string "error: Invalid input, or enrollment not started"
+// @Failure 401 {object} map[string]string "error: Invalid code, or challenge expired, used or failed too often"
+// @Failure 500 {object} map[string]string "error: Server error"
+// @Router /api/login/mfa [post]
+func MFALogin(c *gin.Context) {
+	var mfaDTO authdto.MFALoginRequestDTO
+	if err := c.ShouldBindJSON(&mfaDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := validate.Struct(&mfaDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	user, recoveryCodes, err := mfaservice.NewMFAService().CompleteLogin(mfaDTO.ChallengeToken, mfaDTO.Code)
+	if err != nil {
+		// Wrong codes count towards the user's lockout, so new challenges cannot be used to keep guessing
+		if err == mfaservice.ErrInvalidCode {
+			auditservice.RecordUser(c, auditservice.ActionMFAFailed, user.Username, user.Tenant)
+			recordLoginFailure(c, user.Username)
+		}
+		respondMFAError(c, err)
+		return
+	}
+	session, refreshToken, err := sessionservice.NewSessionService().StartSession(user, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		log.Printf("Session error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		return
+	}
+	response, err := issueTokens(user, session.ID, refreshToken)
+	if err != nil {
+		log.Printf("Token generation error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		return
+	}
+	response.RecoveryCodes = recoveryCodes
+	log.Printf("MFA login for user %s", user.Username)
+	auditservice.RecordUser(c, auditservice.ActionLoginSuccess, user.Username, user.Tenant, "authMethod", "mfa")
+	c.JSON(http.StatusOK, response)
+}
+
+// MFALoginEnroll sets up MFA during a login that requires it
+// @Summary Enroll in MFA during login
+// @Description For an enroll challenge: returns a new TOTP secret to add to an authenticator app. Complete the login with
+// @Description one of the app's codes at POST /login/mfa. Calling it again replaces the secret.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body authdto.MFAEnrollChallengeRequestDTO true "Challenge token"
+// @Success 200 {object} mfadto.EnrollmentResponseDTO "TOTP secret"
+// @Failure 400 {object} map[string]string "error: Invalid input"
+// @Failure 401 {object} map[string]string "error: Challenge expired, used or failed too often"
+// @Failure 409 {object} map[string]string "error: MFA is already enabled"
+// @Failure 500 {object} map[string]string "error: Server error"
+// @Router /api/login/mfa/enroll [post]
+func MFALoginEnroll(c *gin.Context) {
+	var enrollDTO authdto.MFAEnrollChallengeRequestDTO
+	if err := c.ShouldBindJSON(&enrollDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := validate.Struct(&enrollDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	enrollment, err := mfaservice.NewMFAService().BeginLoginEnrollment(enrollDTO.ChallengeToken)
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, mfadto.EnrollmentResponseDTO{Secret: enrollment.Secret, OtpauthURI: enrollment.OtpauthURI})
+}
+
+func respondMFAError(c *gin.Context, err error) {
+	switch err {
+	case mfaservice.ErrInvalidCode, mfaservice.ErrInvalidChallenge:
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case mfaservice.ErrNotEnrolling:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case mfaservice.ErrAlreadyEnabled:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		log.Printf("MFA error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+	}
+}
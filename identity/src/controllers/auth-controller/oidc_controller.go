@@ -0,0 +1,115 @@
+package authcontroller
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	auditservice "github.com/yatender-pareek/identity/src/services/audit-service"
+	oidcservice "github.com/yatender-pareek/identity/src/services/oidc-service"
+	sessionservice "github.com/yatender-pareek/identity/src/services/session-service"
+)
+
+// oidcStateCookie binds a login to the browser that started it, so a callback cannot be replayed into another browser
+const oidcStateCookie = "oidc_state"
+
+// OIDCLogin starts a single sign-on login
+// @Summary Single sign-on login
+// @Description Redirects the browser to the OpenID Connect provider (authorization code flow with PKCE).
+// @Description After signing in there, the provider sends the browser back to GET /oidc/callback.
+// @Tags Auth
+// @Success 302 "Redirect to the identity provider"
+// @Failure 502 {object} map[string]string "error: Identity provider unreachable or misconfigured"
+// @Router /api/oidc/login [get]
+func OIDCLogin(c *gin.Context) {
+	authURL, state, err := oidcservice.Default().StartLogin()
+	if err != nil {
+		respondOIDCError(c, err)
+		return
+	}
+	secure := c.Request.TLS != nil || strings.HasPrefix(strings.ToLower(c.GetHeader("X-Forwarded-Proto")), "https")
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, state, 600, "/", "", secure, true)
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCCallback completes a single sign-on login
+// @Summary Single sign-on callback
+// @Description Redirect target of the identity provider. Validates the ID token, links the provider account to a local user
+// @Description (provisioning one on first login), sets the user's role from their provider groups and opens a session.
+// @Description Users with MFA, or whose role requires it, instead get 202 with a challenge token for POST /login/mfa, as with Login.
+// @Tags Auth
+// @Produce json
+// @Param code query string true "Authorization code"
+// @Param state query string true "State of the login"
+// @Success 200 {object} authdto.LoginResponseDTO "Login success with JWT token"
+// @Success 202 {object} authdto.MFAChallengeResponseDTO "Provider login accepted; a second factor is required"
+// @Failure 400 {object} map[string]string "error: Login expired, already completed or started in another browser"
+// @Failure 401 {object} map[string]string "error: Provider refused the login or sent an invalid ID token"
+// @Failure 403 {object} map[string]string "error: No group grants a role, or the linked account was removed"
+// @Failure 502 {object} map[string]string "error: Identity provider unreachable or misconfigured"
+// @Failure 500 {object} map[string]string "error: Server error"
+// @Router /api/oidc/callback [get]
+func OIDCCallback(c *gin.Context) {
+	if providerError := c.Query("error"); providerError != "" {
+		auditservice.RecordRequest(c, auditservice.ActionLoginFailed, "authMethod", "oidc", "reason", providerError)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Identity provider refused the login: " + providerError + " " + c.Query("error_description")})
+		return
+	}
+	state, code := c.Query("state"), c.Query("code")
+	cookie, _ := c.Cookie(oidcStateCookie)
+	if state == "" || code == "" || cookie != state {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Login expired, already completed or started in another browser; start again"})
+		return
+	}
+	c.SetCookie(oidcStateCookie, "", -1, "/", "", false, true)
+
+	user, err := completeSSO(state, code)
+	if err != nil {
+		respondOIDCError(c, err)
+		return
+	}
+	// The provider's sign-in does not replace the second factor of users who have or need one
+	if challenged(c, user) {
+		return
+	}
+	session, refreshToken, err := sessionservice.NewSessionService().StartSession(user, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		log.Printf("Session error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		return
+	}
+	response, err := issueTokens(user, session.ID, refreshToken)
+	if err != nil {
+		log.Printf("Token generation error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		return
+	}
+	log.Printf("Single sign-on login for user %s", user.Username)
+	auditservice.RecordUser(c, auditservice.ActionLoginSuccess, user.Username, user.Tenant, "authMethod", "oidc")
+	c.JSON(http.StatusOK, response)
+}
+
+func respondOIDCError(c *gin.Context, err error) {
+	if errors.Is(err, oidcservice.ErrInvalidState) || errors.Is(err, oidcservice.ErrInvalidIDToken) ||
+		errors.Is(err, oidcservice.ErrNoRole) || errors.Is(err, oidcservice.ErrAccountRemoved) {
+		auditservice.RecordRequest(c, auditservice.ActionLoginFailed, "authMethod", "oidc", "reason", err.Error())
+	}
+	switch {
+	case errors.Is(err, oidcservice.ErrInvalidState):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, oidcservice.ErrInvalidIDToken):
+		log.Printf("OIDC login error: %v", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": oidcservice.ErrInvalidIDToken.Error()})
+	case errors.Is(err, oidcservice.ErrNoRole), errors.Is(err, oidcservice.ErrAccountRemoved):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, oidcservice.ErrProvider):
+		log.Printf("OIDC provider error: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Identity provider error"})
+	default:
+		log.Printf("OIDC login error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+	}
+}
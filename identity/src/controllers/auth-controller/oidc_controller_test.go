@@ -0,0 +1,116 @@
+package authcontroller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	authdto "github.com/yatender-pareek/identity/src/dtos/auth-dto"
+	mfaentity "github.com/yatender-pareek/identity/src/models/mfa-model"
+	userentity "github.com/yatender-pareek/identity/src/models/user-model"
+	mfaservice "github.com/yatender-pareek/identity/src/services/mfa-service"
+	oidcservice "github.com/yatender-pareek/identity/src/services/oidc-service"
+)
+
+func TestOIDCCallback(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	start, complete := startMFA, completeSSO
+	t.Cleanup(func() { startMFA, completeSSO = start, complete })
+	user := userentity.User{ID: 7, Username: "alice", Tenant: "acme", Role: "admin"}
+	challenge := func(purpose string) func(userentity.User) (*mfaservice.LoginChallenge, error) {
+		return func(userentity.User) (*mfaservice.LoginChallenge, error) {
+			return &mfaservice.LoginChallenge{Token: "challenge-token", Purpose: purpose, ExpiresAt: time.Now().Add(5 * time.Minute)}, nil
+		}
+	}
+	tests := []struct {
+		name        string
+		cookie      string
+		ssoErr      error
+		startMFA    func(userentity.User) (*mfaservice.LoginChallenge, error)
+		wantStatus  int
+		wantPurpose string
+		wantMFA     bool
+	}{
+		{
+			name:        "user with MFA gets a verify challenge",
+			cookie:      "state-1",
+			startMFA:    challenge(mfaentity.PurposeVerify),
+			wantStatus:  http.StatusAccepted,
+			wantPurpose: mfaentity.PurposeVerify,
+			wantMFA:     true,
+		},
+		{
+			name:        "role requiring MFA gets an enroll challenge",
+			cookie:      "state-1",
+			startMFA:    challenge(mfaentity.PurposeEnroll),
+			wantStatus:  http.StatusAccepted,
+			wantPurpose: mfaentity.PurposeEnroll,
+			wantMFA:     true,
+		},
+		{
+			name:   "MFA lookup failure refuses the login",
+			cookie: "state-1",
+			startMFA: func(userentity.User) (*mfaservice.LoginChallenge, error) {
+				return nil, errors.New("database down")
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantMFA:    true,
+		},
+		{
+			name:       "provider login without a role is refused before MFA",
+			cookie:     "state-1",
+			ssoErr:     oidcservice.ErrNoRole,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "state from another browser is refused",
+			cookie:     "state-2",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calledMFA := false
+			completeSSO = func(state, code string) (userentity.User, error) {
+				if tt.ssoErr != nil {
+					return userentity.User{}, tt.ssoErr
+				}
+				return user, nil
+			}
+			startMFA = func(got userentity.User) (*mfaservice.LoginChallenge, error) {
+				calledMFA = true
+				if got.ID != user.ID {
+					t.Errorf("MFA started for user %d, want %d", got.ID, user.ID)
+				}
+				return tt.startMFA(got)
+			}
+
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/oidc/callback?state=state-1&code=abc", nil)
+			c.Request.AddCookie(&http.Cookie{Name: oidcStateCookie, Value: tt.cookie})
+			OIDCCallback(c)
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body)
+			}
+			if calledMFA != tt.wantMFA {
+				t.Fatalf("MFA started: %v, want %v", calledMFA, tt.wantMFA)
+			}
+			if tt.wantStatus != http.StatusAccepted {
+				return
+			}
+			var response authdto.MFAChallengeResponseDTO
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+			if response.ChallengeToken != "challenge-token" || response.Purpose != tt.wantPurpose || response.ExpiresIn <= 0 {
+				t.Fatalf("response %+v, want challenge-token with purpose %s", response, tt.wantPurpose)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package lockoutcontroller
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	auditservice "github.com/yatender-pareek/identity/src/services/audit-service"
+	lockoutservice "github.com/yatender-pareek/identity/src/services/lockout-service"
+	tenantservice "github.com/yatender-pareek/identity/src/services/tenant-service"
+	"github.com/yatender-pareek/identity/src/tenancy"
+)
+
+// GetLoginLockouts godoc
+// @Summary List failed logins and lockouts
+// @Description Lists the usernames with recent failed logins, with their failure count, lockout end and latest client address.
+// @Description Tenant admins see their tenant's users; platform admins also see unknown usernames and client addresses.
+// @Description Requires the manage_users permission.
+// @Tags Tenants
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} lockoutentity.LoginThrottle
+// @Failure 403 {object} map[string]string "error: Role lacks the manage_users permission"
+// @Failure 500 {object} map[string]string "error: Server error"
+// @Router /api/login-lockouts [get]
+func GetLoginLockouts(c *gin.Context) {
+	throttles, err := lockoutServiceFor(c).List()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, throttles)
+}
+
+// UnlockUser godoc
+// @Summary Unlock a user
+// @Description Lifts the login lockout of a user and forgets their failed logins. Their client address stays locked
+// @Description if it is locked itself. Tenant admins may unlock their tenant's users except platform admins.
+// @Tags Tenants
+// @Security BearerAuth
+// @Param username path string true "Username"
+// @Success 204 "Unlocked"
+// @Failure 403 {object} map[string]string "error: Role lacks the manage_users permission, or the user is a platform admin"
+// @Failure 404 {object} map[string]string "error: User not found, or no failed logins recorded"
+// @Failure 500 {object} map[string]string "error: Server error"
+// @Router /api/users/{username}/lockout [delete]
+func UnlockUser(c *gin.Context) {
+	if err := lockoutServiceFor(c).Unlock(c.Param("username")); err != nil {
+		respondError(c, err)
+		return
+	}
+	log.Printf("Logins of user %s unlocked by %s", c.Param("username"), tenancy.CallerOf(c).Username)
+	auditservice.RecordRequest(c, auditservice.ActionLoginUnlocked, "target", c.Param("username"))
+	c.Status(http.StatusNoContent)
+}
+
+// UnlockIP godoc
+// @Summary Unlock a client address
+// @Description Lifts the login lockout of a client address and forgets its failed logins. Platform admins only.
+// @Tags Tenants
+// @Security BearerAuth
+// @Param ip path string true "Client address"
+// @Success 204 "Unlocked"
+// @Failure 403 {object} map[string]string "error: Platform admin role required"
+// @Failure 404 {object} map[string]string "error: No failed logins recorded"
+// @Failure 500 {object} map[string]string "error: Server error"
+// @Router /api/login-lockouts/ips/{ip} [delete]
+func UnlockIP(c *gin.Context) {
+	if err := lockoutServiceFor(c).UnlockIP(c.Param("ip")); err != nil {
+		respondError(c, err)
+		return
+	}
+	log.Printf("Logins from %s unlocked by %s", c.Param("ip"), tenancy.CallerOf(c).Username)
+	auditservice.RecordRequest(c, auditservice.ActionLoginUnlocked, "target", c.Param("ip"))
+	c.Status(http.StatusNoContent)
+}
+
+// lockoutServiceFor limits lockout management to the caller's tenant. The service is built per request,
+// like the login's, so it reads LOGIN_LOCKOUT_* after main has loaded .env.
+func lockoutServiceFor(c *gin.Context) *lockoutservice.LockoutService {
+	return lockoutservice.NewLockoutService().ForTenant(tenancy.CallerOf(c).ScopeTenant())
+}
+
+func respondError(c *gin.Context, err error) {
+	switch err {
+	case tenantservice.ErrUnknownUser, lockoutservice.ErrNotLocked:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case tenantservice.ErrPlatformAdminOnly:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Lockout error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+	}
+}
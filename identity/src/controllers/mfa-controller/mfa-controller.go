@@ -0,0 +1,277 @@
+package mfacontroller
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	mfadto "github.com/yatender-pareek/identity/src/dtos/mfa-dto"
+	auditservice "github.com/yatender-pareek/identity/src/services/audit-service"
+	mfaservice "github.com/yatender-pareek/identity/src/services/mfa-service"
+	tenantservice "github.com/yatender-pareek/identity/src/services/tenant-service"
+	"github.com/yatender-pareek/identity/src/tenancy"
+)
+
+var (
+	validate   = validator.New()
+	mfaService = mfaservice.NewMFAService()
+)
+
+// GetMFAStatus godoc
+// @Summary MFA status
+// @Description Reports whether the caller has MFA enabled, whether their role requires it, and how many recovery codes remain
+// @Tags MFA
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} mfadto.StatusResponseDTO
+// @Failure 403 {object} map[string]string "error: Not a user account"
+// @Failure 500 {object} map[string]string "error: Server error"
+// @Router /api/mfa [get]
+func GetMFAStatus(c *gin.Context) {
+	status, err := mfaService.Status(tenancy.CallerOf(c).Username)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, mfadto.StatusResponseDTO{
+		Enabled:           status.Enabled,
+		Required:          status.Required,
+		RecoveryCodesLeft: status.RecoveryCodesLeft,
+	})
+}
+
+// EnrollTOTP godoc
+// @Summary Start TOTP enrollment
+// @Description Returns a new TOTP secret for the caller to add to an authenticator app; MFA is enabled once
+// @Description POST /mfa/totp/confirm accepts one of the app's codes. Calling it again replaces an unconfirmed secret.
+// @Tags MFA
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} mfadto.EnrollmentResponseDTO
+// @Failure 403 {object} map[string]string "error: Not a user account"
+// @Failure 409 {object} map[string]string "error: MFA is already enabled"
+// @Failure 500 {object} map[string]string "error: Server error"
+// @Router /api/mfa/totp [post]
+func EnrollTOTP(c *gin.Context) {
+	enrollment, err := mfaService.BeginEnrollment(tenancy.CallerOf(c).Username)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, mfadto.EnrollmentResponseDTO{Secret: enrollment.Secret, OtpauthURI: enrollment.OtpauthURI})
+}
+
+// ConfirmTOTP godoc
+// @Summary Confirm TOTP enrollment
+// @Description Enables MFA once the code shows the authenticator app works, and returns the caller's recovery codes.
+// @Description They are shown only once; each replaces a TOTP code one time.
+// @Tags MFA
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body mfadto.CodeRequestDTO true "TOTP code"
+// @Success 200 {object} mfadto.RecoveryCodesResponseDTO
+// @Failure 400 {object} map[string]string "error: Invalid input, or enrollment not started"
+// @Failure 401 {object} map[string]string "error: Invalid code"
+// @Failure 409 {object} map[string]string "error: MFA is already enabled"
+// @Failure 500 {object} map[string]string "error: Server error"
+// @Router /api/mfa/totp/confirm [post]
+func ConfirmTOTP(c *gin.Context) {
+	code, ok := bindCode(c)
+	if !ok {
+		return
+	}
+	codes, err := mfaService.ConfirmEnrollment(tenancy.CallerOf(c).Username, code)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, mfadto.RecoveryCodesResponseDTO{RecoveryCodes: codes})
+}
+
+// DisableTOTP godoc
+// @Summary Disable MFA
+// @Description Removes the caller's authenticator and recovery codes after checking a TOTP or recovery code.
+// @Description Refused when the caller's role requires MFA.
+// @Tags MFA
+// @Accept json
+// @Security BearerAuth
+// @Param request body mfadto.CodeRequestDTO true "TOTP or recovery code"
+// @Success 204 "MFA disabled"
+// @Failure 400 {object} map[string]string "error: Invalid input, or MFA not enabled"
+// @Failure 401 {object} map[string]string "error: Invalid code"
+// @Failure 403 {object} map[string]string "error: Role requires MFA"
+// @Failure 500 {object} map[string]string "error: Server error"
+// @Router /api/mfa/totp [delete]
+func DisableTOTP(c *gin.Context) {
+	code, ok := bindCode(c)
+	if !ok {
+		return
+	}
+	if err := mfaService.Disable(tenancy.CallerOf(c).Username, code); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Replace recovery codes
+// @Description Invalidates the caller's recovery codes and returns new ones after checking a TOTP or recovery code
+// @Tags MFA
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body mfadto.CodeRequestDTO true "TOTP or recovery code"
+// @Success 200 {object} mfadto.RecoveryCodesResponseDTO
+// @Failure 400 {object} map[string]string "error: Invalid input, or MFA not enabled"
+// @Failure 401 {object} map[string]string "error: Invalid code"
+// @Failure 500 {object} map[string]string "error: Server error"
+// @Router /api/mfa/recovery-codes [post]
+func RegenerateRecoveryCodes(c *gin.Context) {
+	code, ok := bindCode(c)
+	if !ok {
+		return
+	}
+	codes, err := mfaService.RegenerateRecoveryCodes(tenancy.CallerOf(c).Username, code)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, mfadto.RecoveryCodesResponseDTO{RecoveryCodes: codes})
+}
+
+// GetMFAPolicy godoc
+// @Summary MFA policy
+// @Description Lists the roles of the caller's tenant that must use MFA. Platform admins may name another tenant.
+// @Description Requires the manage_users permission.
+// @Tags MFA
+// @Produce json
+// @Security BearerAuth
+// @Param tenant query string false "Tenant (platform admins only)"
+// @Success 200 {object} mfadto.PolicyResponseDTO
+// @Failure 403 {object} map[string]string "error: Role lacks the manage_users permission"
+// @Failure 500 {object} map[string]string "error: Server error"
+// @Router /api/mfa/policy [get]
+func GetMFAPolicy(c *gin.Context) {
+	tenant := policyTenant(c)
+	roles, err := mfaServiceFor(c).Policy(tenant)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, mfadto.PolicyResponseDTO{Tenant: tenant, Roles: roles})
+}
+
+// SetMFAPolicy godoc
+// @Summary Require MFA per role
+// @Description Sets the roles of the caller's tenant that must use MFA, replacing the previous list. Their users without MFA
+// @Description enroll at their next password login. Platform admins may name another tenant; only they may require MFA
+// @Description for platform_admin. Requires the manage_users permission.
+// @Tags MFA
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param tenant query string false "Tenant (platform admins only)"
+// @Param request body mfadto.PolicyRequestDTO true "Roles requiring MFA"
+// @Success 200 {object} mfadto.PolicyResponseDTO
+// @Failure 400 {object} map[string]string "error: Invalid input or role"
+// @Failure 403 {object} map[string]string "error: Role lacks the manage_users permission, or change reserved to platform admins"
+// @Failure 404 {object} map[string]string "error: Tenant not found"
+// @Failure 500 {object} map[string]string "error: Server error"
+// @Router /api/mfa/policy [put]
+func SetMFAPolicy(c *gin.Context) {
+	var req mfadto.PolicyRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := validate.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+	tenant := policyTenant(c)
+	roles, err := mfaServiceFor(c).SetPolicy(tenant, req.Roles, tenancy.CallerOf(c).Username)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	log.Printf("MFA policy of tenant %s set to %v by %s", tenant, roles, tenancy.CallerOf(c).Username)
+	c.JSON(http.StatusOK, mfadto.PolicyResponseDTO{Tenant: tenant, Roles: roles})
+}
+
+// ResetUserMFA godoc
+// @Summary Reset a user's MFA
+// @Description Removes the authenticator and recovery codes of a user who lost them, so they can log in with their password
+// @Description and enroll again. Tenant admins may reset their tenant's users except platform admins.
+// @Tags MFA
+// @Security BearerAuth
+// @Param username path string true "Username"
+// @Success 204 "MFA reset"
+// @Failure 400 {object} map[string]string "error: MFA not enabled"
+// @Failure 403 {object} map[string]string "error: Role lacks the manage_users permission, or the user is a platform admin"
+// @Failure 404 {object} map[string]string "error: User not found"
+// @Failure 500 {object} map[string]string "error: Server error"
+// @Router /api/users/{username}/mfa [delete]
+func ResetUserMFA(c *gin.Context) {
+	if err := mfaServiceFor(c).Reset(c.Param("username")); err != nil {
+		if err == tenantservice.ErrUnknownUser {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		respondError(c, err)
+		return
+	}
+	log.Printf("MFA of user %s reset by %s", c.Param("username"), tenancy.CallerOf(c).Username)
+	auditservice.RecordRequest(c, auditservice.ActionMFAReset, "target", c.Param("username"))
+	c.Status(http.StatusNoContent)
+}
+
+// mfaServiceFor limits policy and user management to the caller's tenant
+func mfaServiceFor(c *gin.Context) *mfaservice.MFAService {
+	return mfaService.ForTenant(tenancy.CallerOf(c).ScopeTenant())
+}
+
+// policyTenant is the tenant a policy request is about: the caller's, or the one a platform admin names
+func policyTenant(c *gin.Context) string {
+	caller := tenancy.CallerOf(c)
+	if tenant := c.Query("tenant"); tenant != "" && caller.IsPlatformAdmin() {
+		return tenant
+	}
+	return caller.Tenant
+}
+
+func bindCode(c *gin.Context) (string, bool) {
+	var req mfadto.CodeRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return "", false
+	}
+	if err := validate.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return "", false
+	}
+	return req.Code, true
+}
+
+func respondError(c *gin.Context, err error) {
+	switch err {
+	case tenantservice.ErrUnknownUser:
+		// Self-service callers that are no user, such as API keys
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only user accounts have MFA"})
+	case mfaservice.ErrInvalidCode:
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case mfaservice.ErrRequired, tenantservice.ErrPlatformAdminOnly:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case tenantservice.ErrUnknownTenant:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case mfaservice.ErrAlreadyEnabled:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case mfaservice.ErrNotEnrolling, mfaservice.ErrNotEnabled, mfaservice.ErrInvalidPolicyRoles:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		log.Printf("MFA error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+	}
+}
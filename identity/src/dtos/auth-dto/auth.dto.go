@@ -0,0 +1,61 @@
+package authdto
+
+// RegisterRequestDTO defines the request payload for user registration
+type RegisterRequestDTO struct {
+	Username string `json:"username" validate:"required,min=3,max=50,alphanum"`
+	Password string `json:"password" validate:"required,min=8,max=100"`
+	Email    string `json:"email" validate:"required,min=8,max=100"`
+	// Tenant is a new tenant to create with the user as its first member; empty joins "default"
+	Tenant string `json:"tenant" validate:"omitempty,max=64"`
+}
+
+// RegisterResponseDTO defines the response payload for user registration
+type RegisterResponseDTO struct {
+	Message string `json:"message"`
+}
+
+// LoginRequestDTO defines the query parameters for user login
+type LoginRequestDTO struct {
+	Username string `form:"username" validate:"required,min=3,max=50,alphanum"`
+	Password string `form:"password" validate:"required,min=8,max=100"`
+}
+
+// LoginResponseDTO defines the response payload for user login and token refresh
+type LoginResponseDTO struct {
+	Token string `json:"token"`
+	// RefreshToken obtains a new pair from POST /token/refresh; each refresh token works once
+	RefreshToken string `json:"refreshToken"`
+	// ExpiresIn is the lifetime of Token in seconds
+	ExpiresIn int64  `json:"expiresIn" example:"900"`
+	TokenType string `json:"tokenType" example:"Bearer"`
+	// RecoveryCodes are returned once, when a login enrolled the user in MFA
+	RecoveryCodes []string `json:"recoveryCodes,omitempty"`
+}
+
+// RefreshRequestDTO defines the request payload for token refresh
+type RefreshRequestDTO struct {
+	RefreshToken string `json:"refreshToken" validate:"required"`
+}
+
+// MFAChallengeResponseDTO answers a password login that still needs a second factor
+type MFAChallengeResponseDTO struct {
+	// ChallengeToken is exchanged with a code at POST /login/mfa
+	ChallengeToken string `json:"challengeToken"`
+	// Purpose is "verify" to enter a code of the user's authenticator, or "enroll" when their role
+	// requires MFA they have not set up: POST /login/mfa/enroll first returns the secret to add
+	Purpose string `json:"purpose" example:"verify"`
+	// ExpiresIn is the lifetime of ChallengeToken in seconds
+	ExpiresIn int64 `json:"expiresIn" example:"300"`
+}
+
+// MFALoginRequestDTO defines the request payload for the second login step
+type MFALoginRequestDTO struct {
+	ChallengeToken string `json:"challengeToken" validate:"required"`
+	// Code is a TOTP code or, for a verify challenge, a recovery code
+	Code string `json:"code" validate:"required,max=32"`
+}
+
+// MFAEnrollChallengeRequestDTO defines the request payload for enrolling during login
+type MFAEnrollChallengeRequestDTO struct {
+	ChallengeToken string `json:"challengeToken" validate:"required"`
+}
@@ -0,0 +1,38 @@
+package mfadto
+
+// EnrollmentResponseDTO is a new TOTP secret to add to an authenticator app
+type EnrollmentResponseDTO struct {
+	Secret string `json:"secret" example:"JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+	// OtpauthURI is the secret as a key URI, usually shown as a QR code
+	OtpauthURI string `json:"otpauthUri"`
+}
+
+// CodeRequestDTO carries a code proving the caller holds their authenticator
+type CodeRequestDTO struct {
+	// Code is a TOTP code or, where a recovery code is accepted, a recovery code
+	Code string `json:"code" validate:"required,max=32"`
+}
+
+// RecoveryCodesResponseDTO lists new recovery codes; they are shown only once
+type RecoveryCodesResponseDTO struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// StatusResponseDTO describes the caller's MFA
+type StatusResponseDTO struct {
+	Enabled bool `json:"enabled"`
+	// Required is set when the caller's role must use MFA
+	Required          bool  `json:"required"`
+	RecoveryCodesLeft int64 `json:"recoveryCodesLeft"`
+}
+
+// PolicyRequestDTO sets the roles of a tenant that must use MFA
+type PolicyRequestDTO struct {
+	Roles []string `json:"roles" validate:"max=8,dive,required"`
+}
+
+// PolicyResponseDTO lists the roles of a tenant that must use MFA
+type PolicyResponseDTO struct {
+	Tenant string   `json:"tenant"`
+	Roles  []string `json:"roles"`
+}
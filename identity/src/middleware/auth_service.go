@@ -0,0 +1,95 @@
+// Package middleware authenticates requests with the access tokens the identity module issues
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	auditservice "github.com/yatender-pareek/identity/src/services/audit-service"
+	sessionservice "github.com/yatender-pareek/identity/src/services/session-service"
+	tokenservice "github.com/yatender-pareek/identity/src/services/token-service"
+	"github.com/yatender-pareek/identity/src/tenancy"
+)
+
+// AuthMiddleware validates JWT access tokens against the JWKS
+func AuthMiddleware() gin.HandlerFunc {
+	authenticate := TokenAuthenticator()
+	return func(c *gin.Context) {
+		tokenString, ok := BearerToken(c)
+		if !ok {
+			return
+		}
+		authenticate(c, tokenString)
+	}
+}
+
+// BearerToken returns the credential of the Authorization header, answering 401 when there is none
+func BearerToken(c *gin.Context) (string, bool) {
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
+		c.Abort()
+		return "", false
+	}
+
+	// Expect "Bearer <token>"
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		auditservice.RecordRequest(c, auditservice.ActionTokenInvalid, "reason", "malformed authorization header")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header"})
+		c.Abort()
+		return "", false
+	}
+	return parts[1], true
+}
+
+// TokenAuthenticator returns a handler step that verifies an access token, refuses revoked ones
+// and stores the caller, for services that accept other credentials as well
+func TokenAuthenticator() func(c *gin.Context, tokenString string) {
+	verifier := tokenservice.NewVerifier()
+	sessions := sessionservice.NewSessionService()
+
+	return func(c *gin.Context, tokenString string) {
+		claims, err := verifier.Parse(tokenString)
+		if err == tokenservice.ErrNoSession {
+			// Tokens without a session predate revocation and cannot be revoked, so they are refused
+			auditservice.RecordRequest(c, auditservice.ActionTokenInvalid, "reason", err.Error())
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token has no session; log in again"})
+			c.Abort()
+			return
+		}
+		if err != nil {
+			log.Printf("Token parsing error: %v", err)
+			auditservice.RecordRequest(c, auditservice.ActionTokenInvalid, "reason", err.Error())
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token: " + err.Error()})
+			c.Abort()
+			return
+		}
+
+		revoked, err := sessions.IsRevoked(claims.JTI, claims.SessionID)
+		if err != nil {
+			log.Printf("Token revocation lookup error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+			c.Abort()
+			return
+		}
+		if revoked {
+			auditservice.RecordUser(c, auditservice.ActionTokenInvalid, claims.Username, claims.Tenant, "reason", "token revoked")
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked"})
+			c.Abort()
+			return
+		}
+		c.Set("jti", claims.JTI)
+		c.Set("session", claims.SessionID)
+		c.Set("tokenExpiresAt", claims.ExpiresAt)
+
+		// Tokens issued before tenants existed belong to the default tenant
+		if claims.Tenant == "" {
+			claims.Tenant = tenancy.DefaultTenant
+		}
+		tenancy.SetCaller(c, tenancy.Caller{Username: claims.Username, Tenant: claims.Tenant, Role: claims.Role})
+		c.Next()
+	}
+}
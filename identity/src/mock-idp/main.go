@@ -0,0 +1,205 @@
+// Command mock-idp is a minimal OpenID Connect provider for trying single sign-on locally.
+// It signs every authorization request in as one configured user without asking for a password,
+// so it must never be exposed beyond a developer machine or a test network.
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	tokenservice "github.com/yatender-pareek/identity/src/services/token-service"
+)
+
+// authorization is an issued code waiting to be redeemed
+type authorization struct {
+	clientID      string
+	redirectURI   string
+	codeChallenge string
+	nonce         string
+	username      string
+	expiresAt     time.Time
+}
+
+type provider struct {
+	issuer   string
+	clientID string
+	email    string
+	username string
+	groups   []string
+	key      *ecdsa.PrivateKey
+	jwk      tokenservice.JWK
+
+	mu    sync.Mutex
+	codes map[string]authorization
+}
+
+func main() {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		log.Fatalf("Failed to generate signing key: %v", err)
+	}
+	jwk, err := tokenservice.PublicJWK(&key.PublicKey)
+	if err != nil {
+		log.Fatalf("Failed to describe signing key: %v", err)
+	}
+	p := &provider{
+		issuer:   env("MOCK_IDP_ISSUER", "http://localhost:9000"),
+		clientID: os.Getenv("MOCK_IDP_CLIENT_ID"),
+		username: env("MOCK_IDP_USERNAME", "analyst1"),
+		email:    os.Getenv("MOCK_IDP_EMAIL"),
+		groups:   strings.Split(env("MOCK_IDP_GROUPS", "soc-analysts"), ","),
+		key:      key,
+		jwk:      jwk,
+		codes:    make(map[string]authorization),
+	}
+
+	http.HandleFunc("/.well-known/openid-configuration", p.discovery)
+	http.HandleFunc("/authorize", p.authorize)
+	http.HandleFunc("/token", p.token)
+	http.HandleFunc("/jwks", p.jwks)
+	addr := env("MOCK_IDP_ADDR", ":9000")
+	log.Printf("Mock OpenID Connect provider %s listening on %s; it signs everyone in as %s", p.issuer, addr, p.username)
+	log.Fatal(http.ListenAndServe(addr, nil))
+}
+
+func (p *provider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                p.issuer,
+		"authorization_endpoint":                p.issuer + "/authorize",
+		"token_endpoint":                        p.issuer + "/token",
+		"jwks_uri":                              p.issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"ES256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+// authorize signs the configured user in at once, or the user named by login_hint, and redirects back with a code
+func (p *provider) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	redirectURI := query.Get("redirect_uri")
+	target, err := url.Parse(redirectURI)
+	if err != nil || redirectURI == "" {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	if query.Get("response_type") != "code" || query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		http.Error(w, "only the code flow with S256 PKCE is supported", http.StatusBadRequest)
+		return
+	}
+	if p.clientID != "" && query.Get("client_id") != p.clientID {
+		http.Error(w, "unknown client_id", http.StatusBadRequest)
+		return
+	}
+
+	username := p.username
+	if hint := query.Get("login_hint"); hint != "" {
+		username = hint
+	}
+	code := randomString()
+	p.mu.Lock()
+	p.codes[code] = authorization{
+		clientID:      query.Get("client_id"),
+		redirectURI:   redirectURI,
+		codeChallenge: query.Get("code_challenge"),
+		nonce:         query.Get("nonce"),
+		username:      username,
+		expiresAt:     time.Now().Add(time.Minute),
+	}
+	p.mu.Unlock()
+
+	params := target.Query()
+	params.Set("code", code)
+	params.Set("state", query.Get("state"))
+	target.RawQuery = params.Encode()
+	http.Redirect(w, r, target.String(), http.StatusFound)
+}
+
+// token redeems a code once, checking the redirect URI and PKCE verifier, and returns a signed ID token
+func (p *provider) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.ParseForm() != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+	code := r.PostForm.Get("code")
+	p.mu.Lock()
+	auth, ok := p.codes[code]
+	delete(p.codes, code)
+	p.mu.Unlock()
+
+	verifier := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || time.Now().After(auth.expiresAt) || auth.redirectURI != r.PostForm.Get("redirect_uri") ||
+		base64.RawURLEncoding.EncodeToString(verifier[:]) != auth.codeChallenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	email := p.email
+	if email == "" {
+		email = auth.username + "@example.com"
+	}
+	now := time.Now()
+	idToken := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss":                p.issuer,
+		"sub":                "mock|" + auth.username,
+		"aud":                auth.clientID,
+		"iat":                now.Unix(),
+		"exp":                now.Add(5 * time.Minute).Unix(),
+		"nonce":              auth.nonce,
+		"preferred_username": auth.username,
+		"email":              email,
+		"email_verified":     true,
+		"groups":             p.groups,
+	})
+	idToken.Header["kid"] = p.jwk.Kid
+	signed, err := idToken.SignedString(p.key)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     signed,
+	})
+}
+
+func (p *provider) jwks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, tokenservice.JWKS{Keys: []tokenservice.JWK{p.jwk}})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func randomString() string {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		log.Fatalf("Failed to generate random value: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+func env(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,29 @@
+// models/models.go
+package models
+
+import (
+	lockoutentity "github.com/yatender-pareek/identity/src/models/lockout-model"
+	mfaentity "github.com/yatender-pareek/identity/src/models/mfa-model"
+	oidcentity "github.com/yatender-pareek/identity/src/models/oidc-model"
+	sessionentity "github.com/yatender-pareek/identity/src/models/session-model"
+	tenantentity "github.com/yatender-pareek/identity/src/models/tenant-model"
+	userentity "github.com/yatender-pareek/identity/src/models/user-model"
+)
+
+// GetAllModels returns the identity tables every service migrates alongside its own
+func GetAllModels() []interface{} {
+	return []interface{}{
+		&userentity.User{},
+		&tenantentity.Tenant{},
+		&tenantentity.Migration{},
+		&sessionentity.Session{},
+		&sessionentity.TokenRevocation{},
+		&oidcentity.ExternalIdentity{},
+		&oidcentity.LoginState{},
+		&mfaentity.TOTPFactor{},
+		&mfaentity.RecoveryCode{},
+		&mfaentity.Challenge{},
+		&mfaentity.Policy{},
+		&lockoutentity.LoginThrottle{},
+	}
+}
@@ -0,0 +1,31 @@
+package lockoutentity
+
+import (
+	"time"
+)
+
+// Kinds of login throttles
+const (
+	// KindUsername counts failed logins for one username, whether or not such a user exists
+	KindUsername = "username"
+	// KindIP counts failed logins from one client address
+	KindIP = "ip"
+)
+
+// LoginThrottle counts the recent failed logins of a username or client address and locks it out
+// for a growing time once they pass the configured threshold
+type LoginThrottle struct {
+	ID      uint   `json:"id" gorm:"primaryKey;autoIncrement"`
+	Kind    string `json:"kind" gorm:"type:varchar(16);not null;uniqueIndex:idx_login_throttle"`
+	Subject string `json:"subject" gorm:"type:varchar(255);not null;uniqueIndex:idx_login_throttle"`
+	// Failures counts failed logins since the last success, forgetting them after a quiet period
+	Failures      int        `json:"failures" gorm:"not null;default:0"`
+	LockedUntil   *time.Time `json:"lockedUntil"`
+	LastFailureAt time.Time  `json:"lastFailureAt" gorm:"not null;index"`
+	// LastFailureIP is the client address of the latest failure
+	LastFailureIP string `json:"lastFailureIp" gorm:"type:varchar(45)"`
+}
+
+func (LoginThrottle) TableName() string {
+	return "login_throttles"
+}
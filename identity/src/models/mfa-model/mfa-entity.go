@@ -0,0 +1,71 @@
+package mfaentity
+
+import (
+	"time"
+)
+
+// TOTPFactor is a user's authenticator app; it protects their logins once confirmed
+type TOTPFactor struct {
+	ID     uint   `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID uint   `json:"userId" gorm:"not null;uniqueIndex"`
+	Secret string `json:"-" gorm:"type:varchar(64);not null"`
+	// ConfirmedAt is set once the user proved the app produces valid codes
+	ConfirmedAt *time.Time `json:"confirmedAt"`
+	// LastUsedStep is the time step of the last accepted code, which cannot be used again
+	LastUsedStep int64     `json:"-" gorm:"not null;default:0"`
+	CreatedAt    time.Time `json:"createdAt" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time `json:"-" gorm:"autoUpdateTime"`
+}
+
+func (TOTPFactor) TableName() string {
+	return "mfa_totp_factors"
+}
+
+// RecoveryCode is a one-time code standing in for the authenticator app; only its hash is stored
+type RecoveryCode struct {
+	ID        uint   `gorm:"primaryKey;autoIncrement"`
+	UserID    uint   `gorm:"not null;index"`
+	CodeHash  string `gorm:"type:char(64);not null;uniqueIndex"`
+	UsedAt    *time.Time
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+func (RecoveryCode) TableName() string {
+	return "mfa_recovery_codes"
+}
+
+// Challenge purposes
+const (
+	// PurposeVerify asks for a code of the user's confirmed factor
+	PurposeVerify = "verify"
+	// PurposeEnroll makes a user whose role requires MFA set up a factor before the login completes
+	PurposeEnroll = "enroll"
+)
+
+// Challenge is a login whose password was accepted and that waits for the second factor.
+// Only a hash of its token is stored.
+type Challenge struct {
+	TokenHash string    `gorm:"type:char(64);primaryKey"`
+	UserID    uint      `gorm:"not null;index"`
+	Purpose   string    `gorm:"type:varchar(16);not null"`
+	Attempts  int       `gorm:"not null;default:0"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+func (Challenge) TableName() string {
+	return "mfa_challenges"
+}
+
+// Policy requires the users of a tenant holding Role to use MFA
+type Policy struct {
+	ID        uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	Tenant    string    `json:"tenant" gorm:"type:varchar(64);not null;uniqueIndex:idx_mfa_policy"`
+	Role      string    `json:"role" gorm:"type:varchar(32);not null;uniqueIndex:idx_mfa_policy"`
+	CreatedBy string    `json:"createdBy" gorm:"type:varchar(255)"`
+	CreatedAt time.Time `json:"createdAt" gorm:"autoCreateTime"`
+}
+
+func (Policy) TableName() string {
+	return "mfa_policies"
+}
@@ -0,0 +1,34 @@
+package oidcentity
+
+import (
+	"time"
+)
+
+// ExternalIdentity links a local user to their account at an OpenID Connect provider
+type ExternalIdentity struct {
+	ID          uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID      uint       `json:"userId" gorm:"not null;index"`
+	Issuer      string     `json:"issuer" gorm:"type:varchar(255);not null;uniqueIndex:idx_external_identity"`
+	Subject     string     `json:"subject" gorm:"type:varchar(255);not null;uniqueIndex:idx_external_identity"`
+	Email       string     `json:"email" gorm:"type:varchar(255)"`
+	LastLoginAt *time.Time `json:"lastLoginAt"`
+	CreatedAt   time.Time  `json:"createdAt" gorm:"autoCreateTime"`
+}
+
+func (ExternalIdentity) TableName() string {
+	return "external_identities"
+}
+
+// LoginState is an authorization request waiting for the provider to redirect back.
+// Only a hash of the state parameter is stored; each state completes one login.
+type LoginState struct {
+	StateHash    string    `gorm:"type:char(64);primaryKey"`
+	Nonce        string    `gorm:"type:varchar(64);not null"`
+	CodeVerifier string    `gorm:"type:varchar(128);not null"`
+	ExpiresAt    time.Time `gorm:"not null;index"`
+	CreatedAt    time.Time `gorm:"autoCreateTime"`
+}
+
+func (LoginState) TableName() string {
+	return "oidc_login_states"
+}
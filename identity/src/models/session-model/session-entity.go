@@ -0,0 +1,31 @@
+package sessionentity
+
+import (
+	"time"
+)
+
+// Session is one login. Its refresh token is replaced on every refresh and only stored hashed;
+// the hash it replaced is kept so a stolen, already used refresh token is recognised.
+type Session struct {
+	ID                string     `json:"id" gorm:"type:char(32);primaryKey"`
+	Username          string     `json:"username" gorm:"type:varchar(255);not null;index"`
+	Tenant            string     `json:"tenant" gorm:"type:varchar(64);not null;index"`
+	RefreshTokenHash  string     `json:"-" gorm:"type:char(64);uniqueIndex;not null"`
+	PreviousTokenHash string     `json:"-" gorm:"type:char(64);index"`
+	IPAddress         string     `json:"ipAddress" gorm:"type:varchar(45)"`
+	UserAgent         string     `json:"userAgent" gorm:"type:varchar(255)"`
+	ExpiresAt         time.Time  `json:"expiresAt" gorm:"not null"`
+	RefreshedAt       *time.Time `json:"refreshedAt"`
+	RevokedAt         *time.Time `json:"revokedAt"`
+	CreatedAt         time.Time  `json:"createdAt" gorm:"autoCreateTime"`
+}
+
+// TokenRevocation revokes one access token (JTI) or every access token of a session (SessionID)
+// until ExpiresAt, after which none of them would be accepted anyway
+type TokenRevocation struct {
+	ID        uint64    `json:"id" gorm:"primaryKey;autoIncrement"`
+	JTI       string    `json:"jti" gorm:"type:varchar(64);index"`
+	SessionID string    `json:"sessionId" gorm:"type:char(32);index"`
+	ExpiresAt time.Time `json:"expiresAt" gorm:"not null;index"`
+	CreatedAt time.Time `json:"createdAt" gorm:"autoCreateTime"`
+}
@@ -0,0 +1,12 @@
+package tenantentity
+
+import (
+	"time"
+)
+
+// Migration records a one-time data migration that has been applied, so services
+// starting later do not apply it again
+type Migration struct {
+	Name      string    `json:"name" gorm:"primaryKey;type:varchar(128)"`
+	AppliedAt time.Time `json:"appliedAt" gorm:"autoCreateTime"`
+}
@@ -0,0 +1,14 @@
+package tenantentity
+
+import (
+	"time"
+)
+
+// Tenant is a customer or business unit; its users only see its own logs and threats.
+// Logs, threats and users refer to it by Name.
+type Tenant struct {
+	ID        uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name      string    `json:"name" gorm:"type:varchar(64);uniqueIndex;not null"`
+	CreatedAt time.Time `json:"createdAt" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"-" gorm:"autoUpdateTime"`
+}
@@ -12,6 +12,8 @@ type User struct {
 	Username  string         `gorm:"type:varchar(255);unique;not null" json:"username"`
 	Password  string         `gorm:"type:varchar(255);not null" json:"password"`
 	Email     string         `gorm:"type:varchar(255);not null" json:"email"`
+	Tenant    string         `gorm:"type:varchar(64);not null;default:default;index" json:"tenant"`
+	Role      string         `gorm:"type:varchar(32);not null;default:viewer" json:"role"`
 	CreatedAt time.Time      `json:"-" gorm:"autoCreateTime"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
 	UpdatedAt time.Time      `json:"-" gorm:"autoUpdateTime,omitempty"`
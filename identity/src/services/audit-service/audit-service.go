@@ -0,0 +1,188 @@
+// Package auditservice records the services' own security events, such as logins, token failures
+// and deletions, and hands them to the log pipeline so detection rules cover the platform itself
+package auditservice
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yatender-pareek/identity/src/tenancy"
+)
+
+// Actions of audit events, stored as the log's action
+const (
+	ActionLoginSuccess       = "login_success"
+	ActionLoginFailed        = "login_failed"
+	ActionLoginLocked        = "login_locked"
+	ActionMFAFailed          = "mfa_failed"
+	ActionTokenRefreshFailed = "token_refresh_failed"
+	ActionLogout             = "logout"
+	ActionTokenInvalid       = "token_invalid"
+	ActionAPIKeyInvalid      = "api_key_invalid"
+	ActionLogDeleted         = "log_deleted"
+	ActionThreatDeleted      = "threat_deleted"
+	ActionIncidentDeleted    = "incident_deleted"
+	ActionUserUpdated        = "user_updated"
+	ActionSessionsRevoked    = "sessions_revoked"
+	ActionMFAReset           = "mfa_reset"
+	ActionLoginUnlocked      = "login_unlocked"
+	ActionAPIKeyCreated      = "api_key_created"
+	ActionAPIKeyRotated      = "api_key_rotated"
+	ActionAPIKeyRevoked      = "api_key_revoked"
+)
+
+// SourcePrefix starts the source of every audit event, e.g. "audit:log-ingestor-service". The log
+// pipeline only lets credentials with the audit:write scope use it, so detection rules can trust it.
+const SourcePrefix = "audit:"
+
+// IsAuditSource reports whether a log source is reserved for audit events. Rules compare
+// lower-cased values, so the check ignores case and surrounding space.
+func IsAuditSource(source string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(source)), SourcePrefix)
+}
+
+// anonymous is the user of events whose caller is not known, such as requests with invalid tokens
+const anonymous = "anonymous"
+
+// Event is one security event of a service
+type Event struct {
+	Timestamp time.Time
+	Action    string
+	// UserID is the acting user, or the username tried for failed logins
+	UserID    string
+	IPAddress string
+	Tenant    string
+	// Source is SourcePrefix followed by the service the event happened in
+	Source     string
+	Attributes map[string]string
+}
+
+// Sink stores a batch of events; each service passes one that feeds its log pipeline
+type Sink func(events []Event) error
+
+type recorder struct {
+	source    string
+	tenant    string
+	sink      Sink
+	queue     chan Event
+	batchSize int
+	dropped   atomic.Uint64
+}
+
+var active *recorder
+
+// Start makes Record queue events for sink, which a background worker calls with batches of them.
+// Events are attributed to source and, when AUDIT_TENANT is set, stored under that tenant instead of
+// the user's. A full queue (AUDIT_QUEUE_SIZE, default 10000) drops events rather than slow down requests.
+// AUDIT_ENABLED=false turns recording off.
+func Start(source string, sink Sink) error {
+	if os.Getenv("AUDIT_ENABLED") == "false" {
+		log.Println("Audit events are not recorded (AUDIT_ENABLED=false)")
+		return nil
+	}
+	queueSize := 10000
+	if raw := os.Getenv("AUDIT_QUEUE_SIZE"); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value <= 0 {
+			return fmt.Errorf("invalid AUDIT_QUEUE_SIZE %q", raw)
+		}
+		queueSize = value
+	}
+	r := &recorder{
+		source:    source,
+		tenant:    os.Getenv("AUDIT_TENANT"),
+		sink:      sink,
+		queue:     make(chan Event, queueSize),
+		batchSize: 500,
+	}
+	go r.work()
+	active = r
+	return nil
+}
+
+// Record queues an event. It never blocks; without Start, or with a full queue, the event is dropped.
+func Record(event Event) {
+	r := active
+	if r == nil {
+		return
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	if event.UserID == "" {
+		event.UserID = anonymous
+	}
+	// Log ingestion requires an IP address; requests always have one, background events may not
+	if net.ParseIP(event.IPAddress) == nil {
+		event.IPAddress = "0.0.0.0"
+	}
+	if r.tenant != "" {
+		event.Tenant = r.tenant
+	} else if event.Tenant == "" {
+		event.Tenant = tenancy.DefaultTenant
+	}
+	event.Source = SourcePrefix + r.source
+	select {
+	case r.queue <- event:
+	default:
+		if r.dropped.Add(1)%1000 == 1 {
+			log.Printf("Audit queue full, %d events dropped so far", r.dropped.Load())
+		}
+	}
+}
+
+// RecordRequest records an event of the request's caller, from their client address, with the
+// request's method, path and user agent among its attributes. attributes alternate keys and values.
+func RecordRequest(c *gin.Context, action string, attributes ...string) {
+	caller := tenancy.CallerOf(c)
+	RecordUser(c, action, caller.Username, caller.Tenant, attributes...)
+}
+
+// RecordUser records an event of a user other than the request's caller, such as one logging in
+func RecordUser(c *gin.Context, action, username, tenant string, attributes ...string) {
+	event := Event{
+		Action:    action,
+		UserID:    username,
+		IPAddress: c.ClientIP(),
+		Tenant:    tenant,
+		Attributes: map[string]string{
+			"method": c.Request.Method,
+			"path":   c.Request.URL.Path,
+		},
+	}
+	if userAgent := c.Request.UserAgent(); userAgent != "" {
+		event.Attributes["userAgent"] = userAgent
+	}
+	for i := 0; i+1 < len(attributes); i += 2 {
+		if attributes[i+1] != "" {
+			event.Attributes[attributes[i]] = attributes[i+1]
+		}
+	}
+	Record(event)
+}
+
+// work hands queued events to the sink in batches of up to batchSize
+func (r *recorder) work() {
+	for first := range r.queue {
+		batch := []Event{first}
+	drain:
+		for len(batch) < r.batchSize {
+			select {
+			case next := <-r.queue:
+				batch = append(batch, next)
+			default:
+				break drain
+			}
+		}
+		if err := r.sink(batch); err != nil {
+			log.Printf("Failed to store %d audit events: %v", len(batch), err)
+		}
+	}
+}
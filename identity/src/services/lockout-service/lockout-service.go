@@ -0,0 +1,245 @@
+// Package lockoutservice slows down password guessing by locking out usernames and client addresses
+// after repeated failed logins
+package lockoutservice
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	dbconfig "github.com/yatender-pareek/identity/src/config/db-config"
+	lockoutentity "github.com/yatender-pareek/identity/src/models/lockout-model"
+	userentity "github.com/yatender-pareek/identity/src/models/user-model"
+	tenantservice "github.com/yatender-pareek/identity/src/services/tenant-service"
+	"github.com/yatender-pareek/identity/src/tenancy"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrNotLocked = errors.New("no failed logins are recorded for it")
+
+// Config sets when and for how long failed logins lock out, from LOGIN_LOCKOUT_* environment variables
+type Config struct {
+	// UserThreshold and IPThreshold are the failed logins a username or client address may have
+	// before it is locked out; 0 never locks it out
+	UserThreshold int
+	IPThreshold   int
+	// BaseDelay is the first lockout; each further failure doubles it, up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Window is how long failures are remembered after the latest one
+	Window time.Duration
+}
+
+// ConfigFromEnv reads the lockout configuration, using the defaults for unset or invalid values
+func ConfigFromEnv() Config {
+	return Config{
+		UserThreshold: intEnv("LOGIN_LOCKOUT_USER_THRESHOLD", 5),
+		IPThreshold:   intEnv("LOGIN_LOCKOUT_IP_THRESHOLD", 20),
+		BaseDelay:     durationEnv("LOGIN_LOCKOUT_BASE_DELAY", time.Minute),
+		MaxDelay:      durationEnv("LOGIN_LOCKOUT_MAX_DELAY", time.Hour),
+		Window:        durationEnv("LOGIN_LOCKOUT_WINDOW", 24*time.Hour),
+	}
+}
+
+// delay is the lockout after failures failed logins against threshold
+func (c Config) delay(failures, threshold int) time.Duration {
+	if threshold <= 0 || failures < threshold {
+		return 0
+	}
+	delay := c.BaseDelay
+	for i := threshold; i < failures && delay < c.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > c.MaxDelay {
+		delay = c.MaxDelay
+	}
+	return delay
+}
+
+type LockoutService struct {
+	config Config
+	tenant string
+}
+
+func NewLockoutService() *LockoutService {
+	return &LockoutService{config: ConfigFromEnv()}
+}
+
+// ForTenant returns a service that only lists and unlocks the users of tenant, as for tenant admins.
+// An empty tenant, as for platform admins, covers all usernames and client addresses.
+func (s *LockoutService) ForTenant(tenant string) *LockoutService {
+	return &LockoutService{config: s.config, tenant: tenant}
+}
+
+// LockedFor returns how much longer logins for username or from ip are locked out; 0 when they are not
+func (s *LockoutService) LockedFor(username, ip string) (time.Duration, error) {
+	var throttles []lockoutentity.LoginThrottle
+	err := dbconfig.GetDB().
+		Where("((kind = ? AND subject = ?) OR (kind = ? AND subject = ?)) AND locked_until > ?",
+			lockoutentity.KindUsername, username, lockoutentity.KindIP, ip, time.Now()).
+		Find(&throttles).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to check lockout: %v", err)
+	}
+	var remaining time.Duration
+	for _, throttle := range throttles {
+		if left := time.Until(*throttle.LockedUntil); left > remaining {
+			remaining = left
+		}
+	}
+	return remaining, nil
+}
+
+// RecordFailure counts a failed login for username and ip and returns the lockout it starts, if any
+func (s *LockoutService) RecordFailure(username, ip string) (time.Duration, error) {
+	db := dbconfig.GetDB()
+	userDelay, err := s.recordFailure(db, lockoutentity.KindUsername, username, ip, s.config.UserThreshold)
+	if err != nil {
+		return 0, err
+	}
+	ipDelay, err := s.recordFailure(db, lockoutentity.KindIP, ip, ip, s.config.IPThreshold)
+	if err != nil {
+		return 0, err
+	}
+	if ipDelay > userDelay {
+		return ipDelay, nil
+	}
+	return userDelay, nil
+}
+
+// StartPruner deletes the throttles whose failures are forgotten and whose lockout has ended, once an hour
+// in the background, so that failed logins never wait for it. Until then such rows are ignored: a new
+// failure starts counting afresh and List leaves them out.
+func StartPruner() {
+	window := ConfigFromEnv().Window
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			now := time.Now()
+			result := dbconfig.GetDB().
+				Where("last_failure_at < ? AND (locked_until IS NULL OR locked_until < ?)", now.Add(-window), now).
+				Delete(&lockoutentity.LoginThrottle{})
+			if result.Error != nil {
+				log.Printf("Failed to prune login failures: %v", result.Error)
+			} else if result.RowsAffected > 0 {
+				log.Printf("Pruned %d expired login failures", result.RowsAffected)
+			}
+		}
+	}()
+}
+
+// RecordSuccess forgets the failed logins of username. Those of the client address are kept,
+// so that logging in to one account does not allow guessing the passwords of others.
+func (s *LockoutService) RecordSuccess(username string) error {
+	err := dbconfig.GetDB().Where("kind = ? AND subject = ?", lockoutentity.KindUsername, username).
+		Delete(&lockoutentity.LoginThrottle{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to reset login failures: %v", err)
+	}
+	return nil
+}
+
+// List returns the usernames and client addresses with recent failed logins, locked out ones first.
+// A service limited to one tenant lists only the tenant's users.
+func (s *LockoutService) List() ([]lockoutentity.LoginThrottle, error) {
+	now := time.Now()
+	query := dbconfig.GetDB().Where("last_failure_at > ? OR locked_until > ?", now.Add(-s.config.Window), now)
+	if s.tenant != "" {
+		users := dbconfig.GetDB().Model(&userentity.User{}).Select("username").Where("tenant = ?", s.tenant)
+		query = query.Where("kind = ? AND subject IN (?)", lockoutentity.KindUsername, users)
+	}
+	throttles := []lockoutentity.LoginThrottle{}
+	if err := query.Order("locked_until DESC, last_failure_at DESC").Find(&throttles).Error; err != nil {
+		return nil, fmt.Errorf("failed to list login failures: %v", err)
+	}
+	return throttles, nil
+}
+
+// Unlock lifts the lockout of a user and forgets their failed logins.
+// A service limited to one tenant cannot unlock platform admins.
+func (s *LockoutService) Unlock(username string) error {
+	if s.tenant != "" {
+		var user userentity.User
+		if err := tenancy.Scoped(dbconfig.GetDB(), s.tenant).Where("username = ?", username).First(&user).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return tenantservice.ErrUnknownUser
+			}
+			return err
+		}
+		if user.Role == tenancy.RolePlatformAdmin {
+			return tenantservice.ErrPlatformAdminOnly
+		}
+	}
+	return s.unlock(lockoutentity.KindUsername, username)
+}
+
+// UnlockIP lifts the lockout of a client address and forgets its failed logins
+func (s *LockoutService) UnlockIP(ip string) error {
+	return s.unlock(lockoutentity.KindIP, ip)
+}
+
+func (s *LockoutService) unlock(kind, subject string) error {
+	result := dbconfig.GetDB().Where("kind = ? AND subject = ?", kind, subject).Delete(&lockoutentity.LoginThrottle{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to unlock: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotLocked
+	}
+	return nil
+}
+
+// recordFailure counts one failure of a throttle and locks it out once it passes threshold
+func (s *LockoutService) recordFailure(db *gorm.DB, kind, subject, ip string, threshold int) (time.Duration, error) {
+	if threshold <= 0 || subject == "" {
+		return 0, nil
+	}
+	var delay time.Duration
+	err := db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		// Creating the row first lets the locking read below serialize concurrent failures
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&lockoutentity.LoginThrottle{Kind: kind, Subject: subject, LastFailureAt: now}).Error; err != nil {
+			return err
+		}
+		var throttle lockoutentity.LoginThrottle
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("kind = ? AND subject = ?", kind, subject).First(&throttle).Error; err != nil {
+			return err
+		}
+		if throttle.LastFailureAt.Before(now.Add(-s.config.Window)) {
+			throttle.Failures = 0
+		}
+		throttle.Failures++
+		throttle.LastFailureAt = now
+		throttle.LastFailureIP = ip
+		if delay = s.config.delay(throttle.Failures, threshold); delay > 0 {
+			lockedUntil := now.Add(delay)
+			throttle.LockedUntil = &lockedUntil
+		}
+		return tx.Save(&throttle).Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to record login failure: %v", err)
+	}
+	return delay, nil
+}
+
+func intEnv(name string, fallback int) int {
+	if value, err := strconv.Atoi(os.Getenv(name)); err == nil && value >= 0 {
+		return value
+	}
+	return fallback
+}
+
+func durationEnv(name string, fallback time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(name)); err == nil && value > 0 {
+		return value
+	}
+	return fallback
+}
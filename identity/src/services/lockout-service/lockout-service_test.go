@@ -0,0 +1,77 @@
+package lockoutservice
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDelay(t *testing.T) {
+	config := Config{BaseDelay: time.Minute, MaxDelay: time.Hour}
+	tests := []struct {
+		name      string
+		config    Config
+		failures  int
+		threshold int
+		want      time.Duration
+	}{
+		{name: "below the threshold", config: config, failures: 4, threshold: 5},
+		{name: "at the threshold", config: config, failures: 5, threshold: 5, want: time.Minute},
+		{name: "one past the threshold doubles", config: config, failures: 6, threshold: 5, want: 2 * time.Minute},
+		{name: "two past the threshold doubles twice", config: config, failures: 7, threshold: 5, want: 4 * time.Minute},
+		{name: "capped at the maximum", config: config, failures: 11, threshold: 5, want: time.Hour},
+		{name: "many failures stay capped", config: config, failures: 100000, threshold: 5, want: time.Hour},
+		{name: "zero threshold never locks", config: config, failures: 100, threshold: 0},
+		{name: "no failures", config: config, threshold: 1},
+		{name: "base above the maximum", config: Config{BaseDelay: 2 * time.Hour, MaxDelay: time.Hour}, failures: 1, threshold: 1, want: time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.delay(tt.failures, tt.threshold); got != tt.want {
+				t.Fatalf("delay(%d, %d) = %v, want %v", tt.failures, tt.threshold, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	defaults := Config{UserThreshold: 5, IPThreshold: 20, BaseDelay: time.Minute, MaxDelay: time.Hour, Window: 24 * time.Hour}
+	tests := []struct {
+		name string
+		env  map[string]string
+		want Config
+	}{
+		{name: "defaults", want: defaults},
+		{
+			name: "overrides",
+			env: map[string]string{
+				"LOGIN_LOCKOUT_USER_THRESHOLD": "3",
+				"LOGIN_LOCKOUT_IP_THRESHOLD":   "0",
+				"LOGIN_LOCKOUT_BASE_DELAY":     "30s",
+				"LOGIN_LOCKOUT_MAX_DELAY":      "15m",
+				"LOGIN_LOCKOUT_WINDOW":         "1h",
+			},
+			want: Config{UserThreshold: 3, IPThreshold: 0, BaseDelay: 30 * time.Second, MaxDelay: 15 * time.Minute, Window: time.Hour},
+		},
+		{
+			name: "invalid values fall back to the defaults",
+			env: map[string]string{
+				"LOGIN_LOCKOUT_USER_THRESHOLD": "-1",
+				"LOGIN_LOCKOUT_IP_THRESHOLD":   "many",
+				"LOGIN_LOCKOUT_BASE_DELAY":     "0s",
+				"LOGIN_LOCKOUT_MAX_DELAY":      "-5m",
+				"LOGIN_LOCKOUT_WINDOW":         "a day",
+			},
+			want: defaults,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"LOGIN_LOCKOUT_USER_THRESHOLD", "LOGIN_LOCKOUT_IP_THRESHOLD", "LOGIN_LOCKOUT_BASE_DELAY", "LOGIN_LOCKOUT_MAX_DELAY", "LOGIN_LOCKOUT_WINDOW"} {
+				t.Setenv(name, tt.env[name])
+			}
+			if got := ConfigFromEnv(); got != tt.want {
+				t.Fatalf("ConfigFromEnv() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,520 @@
+// Package mfaservice adds TOTP as a second login factor, with recovery codes and per-role requirements
+package mfaservice
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	dbconfig "github.com/yatender-pareek/identity/src/config/db-config"
+	mfaentity "github.com/yatender-pareek/identity/src/models/mfa-model"
+	tenantentity "github.com/yatender-pareek/identity/src/models/tenant-model"
+	userentity "github.com/yatender-pareek/identity/src/models/user-model"
+	tenantservice "github.com/yatender-pareek/identity/src/services/tenant-service"
+	"github.com/yatender-pareek/identity/src/tenancy"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultChallengeTTL = 5 * time.Minute
+	// maxChallengeAttempts is how many wrong codes a challenge survives
+	maxChallengeAttempts = 5
+	recoveryCodeCount    = 10
+)
+
+var (
+	ErrAlreadyEnabled     = errors.New("MFA is already enabled; disable it first to enroll a new authenticator")
+	ErrNotEnrolling       = errors.New("start the enrollment first")
+	ErrNotEnabled         = errors.New("MFA is not enabled")
+	ErrInvalidCode        = errors.New("invalid code")
+	ErrRequired           = errors.New("your role requires MFA, so it cannot be disabled")
+	ErrInvalidChallenge   = errors.New("login challenge expired, failed too often or was already used; log in again")
+	ErrInvalidPolicyRoles = fmt.Errorf("roles must be among %s", strings.Join(tenancy.Roles, ", "))
+)
+
+// Enrollment is a new TOTP secret for the user to add to their authenticator app
+type Enrollment struct {
+	Secret     string
+	OtpauthURI string
+}
+
+// Status describes a user's MFA
+type Status struct {
+	Enabled           bool
+	Required          bool
+	RecoveryCodesLeft int64
+}
+
+// LoginChallenge is what a password login with MFA answers instead of tokens
+type LoginChallenge struct {
+	Token     string
+	Purpose   string
+	ExpiresAt time.Time
+}
+
+// ChallengeTTL is how long a login challenge may be answered, from MFA_CHALLENGE_TTL
+func ChallengeTTL() time.Duration {
+	if ttl, err := time.ParseDuration(os.Getenv("MFA_CHALLENGE_TTL")); err == nil && ttl > 0 {
+		return ttl
+	}
+	return defaultChallengeTTL
+}
+
+type MFAService struct {
+	tenant string
+}
+
+func NewMFAService() *MFAService {
+	return &MFAService{}
+}
+
+// ForTenant returns a service that only manages the policy and users of tenant, as for tenant admins.
+// An empty tenant, as for platform admins, covers all tenants.
+func (s *MFAService) ForTenant(tenant string) *MFAService {
+	return &MFAService{tenant: tenant}
+}
+
+func (s *MFAService) db() *gorm.DB {
+	return tenancy.Scoped(dbconfig.GetDB(), s.tenant)
+}
+
+// Status reports whether the user has MFA, must have it, and how many recovery codes remain
+func (s *MFAService) Status(username string) (Status, error) {
+	user, err := s.user(s.db(), username)
+	if err != nil {
+		return Status{}, err
+	}
+	var status Status
+	if status.Required, err = s.requiredFor(user); err != nil {
+		return Status{}, err
+	}
+	factor, err := confirmedFactor(s.db(), user.ID)
+	if err != nil && err != ErrNotEnabled {
+		return Status{}, err
+	}
+	if factor == nil {
+		return status, nil
+	}
+	status.Enabled = true
+	err = s.db().Model(&mfaentity.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", user.ID).Count(&status.RecoveryCodesLeft).Error
+	return status, err
+}
+
+// BeginEnrollment gives the user a new TOTP secret, replacing one they never confirmed
+func (s *MFAService) BeginEnrollment(username string) (Enrollment, error) {
+	user, err := s.user(s.db(), username)
+	if err != nil {
+		return Enrollment{}, err
+	}
+	return beginEnrollment(s.db(), user)
+}
+
+// ConfirmEnrollment enables MFA once code shows the authenticator app works, and returns new recovery codes
+func (s *MFAService) ConfirmEnrollment(username, code string) ([]string, error) {
+	user, err := s.user(s.db(), username)
+	if err != nil {
+		return nil, err
+	}
+	var codes []string
+	err = s.db().Transaction(func(tx *gorm.DB) error {
+		codes, err = confirmEnrollment(tx, user, code)
+		return err
+	})
+	return codes, err
+}
+
+// Disable removes the user's factor and recovery codes after checking a current code,
+// unless their role requires MFA
+func (s *MFAService) Disable(username, code string) error {
+	user, err := s.user(s.db(), username)
+	if err != nil {
+		return err
+	}
+	required, err := s.requiredFor(user)
+	if err != nil {
+		return err
+	}
+	if required {
+		return ErrRequired
+	}
+	return s.db().Transaction(func(tx *gorm.DB) error {
+		if err := verifyCode(tx, user, code); err != nil {
+			return err
+		}
+		return removeFactor(tx, user.ID)
+	})
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes after checking a current code
+func (s *MFAService) RegenerateRecoveryCodes(username, code string) ([]string, error) {
+	user, err := s.user(s.db(), username)
+	if err != nil {
+		return nil, err
+	}
+	var codes []string
+	err = s.db().Transaction(func(tx *gorm.DB) error {
+		if err := verifyCode(tx, user, code); err != nil {
+			return err
+		}
+		codes, err = newRecoveryCodes(tx, user.ID)
+		return err
+	})
+	return codes, err
+}
+
+// Reset removes the MFA of a user who lost their authenticator, so they can enroll again.
+// A service limited to one tenant cannot reset platform admins.
+func (s *MFAService) Reset(username string) error {
+	user, err := s.user(s.db(), username)
+	if err != nil {
+		return err
+	}
+	if s.tenant != "" && user.Role == tenancy.RolePlatformAdmin {
+		return tenantservice.ErrPlatformAdminOnly
+	}
+	return s.db().Transaction(func(tx *gorm.DB) error {
+		return removeFactor(tx, user.ID)
+	})
+}
+
+// Policy returns the roles of tenant that must use MFA
+func (s *MFAService) Policy(tenant string) ([]string, error) {
+	roles := []string{}
+	err := s.db().Model(&mfaentity.Policy{}).Where("tenant = ?", tenant).Order("role").Pluck("role", &roles).Error
+	return roles, err
+}
+
+// SetPolicy makes exactly roles of tenant require MFA. A service limited to one tenant
+// cannot change the requirement for platform admins.
+func (s *MFAService) SetPolicy(tenant string, roles []string, by string) ([]string, error) {
+	for _, role := range roles {
+		if !tenancy.ValidRole(role) {
+			return nil, ErrInvalidPolicyRoles
+		}
+	}
+	if s.tenant != "" {
+		current, err := s.Policy(tenant)
+		if err != nil {
+			return nil, err
+		}
+		if contains(current, tenancy.RolePlatformAdmin) != contains(roles, tenancy.RolePlatformAdmin) {
+			return nil, tenantservice.ErrPlatformAdminOnly
+		}
+	}
+	err := s.db().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("name = ?", tenant).First(&tenantentity.Tenant{}).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return tenantservice.ErrUnknownTenant
+			}
+			return err
+		}
+		if err := tx.Where("tenant = ?", tenant).Delete(&mfaentity.Policy{}).Error; err != nil {
+			return err
+		}
+		if len(roles) == 0 {
+			return nil
+		}
+		policies := make([]mfaentity.Policy, len(roles))
+		for i, role := range roles {
+			policies[i] = mfaentity.Policy{Tenant: tenant, Role: role, CreatedBy: by}
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&policies).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.Policy(tenant)
+}
+
+// StartLogin decides whether a password or single sign-on login needs a second factor. It returns a challenge
+// for users with MFA, and for users whose role requires MFA but who have not enrolled yet.
+func (s *MFAService) StartLogin(user userentity.User) (*LoginChallenge, error) {
+	db := dbconfig.GetDB()
+	purpose := mfaentity.PurposeVerify
+	if _, err := confirmedFactor(db, user.ID); err == ErrNotEnabled {
+		required, err := s.requiredFor(user)
+		if err != nil || !required {
+			return nil, err
+		}
+		purpose = mfaentity.PurposeEnroll
+	} else if err != nil {
+		return nil, err
+	}
+
+	token, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	challenge := mfaentity.Challenge{
+		TokenHash: hashValue(token),
+		UserID:    user.ID,
+		Purpose:   purpose,
+		ExpiresAt: time.Now().Add(ChallengeTTL()),
+	}
+	// Abandoned challenges are cleared as new ones start
+	if err := db.Where("expires_at < ?", time.Now()).Delete(&mfaentity.Challenge{}).Error; err != nil {
+		return nil, fmt.Errorf("failed to clear expired challenges: %v", err)
+	}
+	if err := db.Create(&challenge).Error; err != nil {
+		return nil, fmt.Errorf("failed to save challenge: %v", err)
+	}
+	return &LoginChallenge{Token: token, Purpose: purpose, ExpiresAt: challenge.ExpiresAt}, nil
+}
+
+// BeginLoginEnrollment gives the user of an enroll challenge a TOTP secret to set up
+func (s *MFAService) BeginLoginEnrollment(token string) (Enrollment, error) {
+	challenge, user, err := openChallenge(dbconfig.GetDB(), token)
+	if err != nil {
+		return Enrollment{}, err
+	}
+	if challenge.Purpose != mfaentity.PurposeEnroll {
+		return Enrollment{}, ErrAlreadyEnabled
+	}
+	return beginEnrollment(dbconfig.GetDB(), user)
+}
+
+// CompleteLogin checks the code answering a challenge and returns the user to issue tokens for.
+// A verify challenge accepts TOTP or recovery codes; an enroll challenge confirms the new factor
+// with a TOTP code and also returns the user's recovery codes. With ErrInvalidCode the user is
+// returned too, so the failure can be counted against them.
+func (s *MFAService) CompleteLogin(token, code string) (userentity.User, []string, error) {
+	db := dbconfig.GetDB()
+	challenge, user, err := openChallenge(db, token)
+	if err != nil {
+		return userentity.User{}, nil, err
+	}
+	// Counting the attempt first stops parallel guesses from exceeding the limit
+	result := db.Model(&mfaentity.Challenge{}).
+		Where("token_hash = ? AND attempts < ?", challenge.TokenHash, maxChallengeAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if result.Error != nil {
+		return userentity.User{}, nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		db.Delete(&challenge)
+		return userentity.User{}, nil, ErrInvalidChallenge
+	}
+
+	var codes []string
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if challenge.Purpose == mfaentity.PurposeEnroll {
+			codes, err = confirmEnrollment(tx, user, code)
+		} else {
+			err = verifyCode(tx, user, code)
+		}
+		if err != nil {
+			return err
+		}
+		// Deleting the challenge makes it single-use even when two answers race
+		deleted := tx.Delete(&challenge)
+		if deleted.Error != nil {
+			return deleted.Error
+		}
+		if deleted.RowsAffected == 0 {
+			return ErrInvalidChallenge
+		}
+		return nil
+	})
+	if err == ErrInvalidCode {
+		return user, nil, err
+	}
+	if err != nil {
+		return userentity.User{}, nil, err
+	}
+	return user, codes, nil
+}
+
+// requiredFor reports whether the user's tenant requires MFA for their role
+func (s *MFAService) requiredFor(user userentity.User) (bool, error) {
+	var count int64
+	err := dbconfig.GetDB().Model(&mfaentity.Policy{}).
+		Where("tenant = ? AND role = ?", user.Tenant, user.Role).Count(&count).Error
+	return count > 0, err
+}
+
+func (s *MFAService) user(db *gorm.DB, username string) (userentity.User, error) {
+	var user userentity.User
+	if err := db.Where("username = ?", username).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return userentity.User{}, tenantservice.ErrUnknownUser
+		}
+		return userentity.User{}, err
+	}
+	return user, nil
+}
+
+// openChallenge returns an unexpired challenge and its user
+func openChallenge(db *gorm.DB, token string) (mfaentity.Challenge, userentity.User, error) {
+	var challenge mfaentity.Challenge
+	if err := db.Where("token_hash = ?", hashValue(token)).First(&challenge).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return mfaentity.Challenge{}, userentity.User{}, ErrInvalidChallenge
+		}
+		return mfaentity.Challenge{}, userentity.User{}, err
+	}
+	if time.Now().After(challenge.ExpiresAt) {
+		db.Delete(&challenge)
+		return mfaentity.Challenge{}, userentity.User{}, ErrInvalidChallenge
+	}
+	var user userentity.User
+	if err := db.First(&user, challenge.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return mfaentity.Challenge{}, userentity.User{}, ErrInvalidChallenge
+		}
+		return mfaentity.Challenge{}, userentity.User{}, err
+	}
+	return challenge, user, nil
+}
+
+func beginEnrollment(db *gorm.DB, user userentity.User) (Enrollment, error) {
+	if _, err := confirmedFactor(db, user.ID); err == nil {
+		return Enrollment{}, ErrAlreadyEnabled
+	} else if err != ErrNotEnabled {
+		return Enrollment{}, err
+	}
+	secret, err := newTOTPSecret()
+	if err != nil {
+		return Enrollment{}, err
+	}
+	factor := mfaentity.TOTPFactor{UserID: user.ID, Secret: secret}
+	if err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"secret", "last_used_step", "updated_at"}),
+	}).Create(&factor).Error; err != nil {
+		return Enrollment{}, fmt.Errorf("failed to save TOTP secret: %v", err)
+	}
+	return Enrollment{Secret: secret, OtpauthURI: otpauthURI(user.Username, secret)}, nil
+}
+
+func confirmEnrollment(tx *gorm.DB, user userentity.User, code string) ([]string, error) {
+	var factor mfaentity.TOTPFactor
+	if err := tx.Where("user_id = ?", user.ID).First(&factor).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotEnrolling
+		}
+		return nil, err
+	}
+	if factor.ConfirmedAt != nil {
+		return nil, ErrAlreadyEnabled
+	}
+	step, ok := matchTOTP(factor.Secret, normalizeCode(code), time.Now(), factor.LastUsedStep)
+	if !ok {
+		return nil, ErrInvalidCode
+	}
+	now := time.Now()
+	if err := tx.Model(&factor).Updates(map[string]interface{}{"confirmed_at": now, "last_used_step": step}).Error; err != nil {
+		return nil, fmt.Errorf("failed to enable MFA: %v", err)
+	}
+	return newRecoveryCodes(tx, user.ID)
+}
+
+func confirmedFactor(db *gorm.DB, userID uint) (*mfaentity.TOTPFactor, error) {
+	var factor mfaentity.TOTPFactor
+	if err := db.Where("user_id = ? AND confirmed_at IS NOT NULL", userID).First(&factor).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotEnabled
+		}
+		return nil, err
+	}
+	return &factor, nil
+}
+
+// verifyCode accepts a current TOTP code of the user's confirmed factor, or one of their unused recovery codes
+func verifyCode(tx *gorm.DB, user userentity.User, code string) error {
+	factor, err := confirmedFactor(tx, user.ID)
+	if err != nil {
+		return err
+	}
+	code = normalizeCode(code)
+	if step, ok := matchTOTP(factor.Secret, code, time.Now(), factor.LastUsedStep); ok {
+		// The condition keeps a code from being accepted twice by concurrent logins
+		result := tx.Model(&mfaentity.TOTPFactor{}).Where("id = ? AND last_used_step < ?", factor.ID, step).
+			Update("last_used_step", step)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvalidCode
+		}
+		return nil
+	}
+
+	result := tx.Model(&mfaentity.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, hashValue(code)).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidCode
+	}
+	return nil
+}
+
+// newRecoveryCodes replaces the user's recovery codes and returns the new ones, which are shown only once
+func newRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&mfaentity.RecoveryCode{}).Error; err != nil {
+		return nil, fmt.Errorf("failed to remove recovery codes: %v", err)
+	}
+	codes := make([]string, recoveryCodeCount)
+	rows := make([]mfaentity.RecoveryCode, recoveryCodeCount)
+	for i := range codes {
+		value, err := randomHex(5)
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = value[:5] + "-" + value[5:]
+		rows[i] = mfaentity.RecoveryCode{UserID: userID, CodeHash: hashValue(codes[i])}
+	}
+	if err := tx.Create(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to save recovery codes: %v", err)
+	}
+	return codes, nil
+}
+
+func removeFactor(tx *gorm.DB, userID uint) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&mfaentity.RecoveryCode{}).Error; err != nil {
+		return fmt.Errorf("failed to remove recovery codes: %v", err)
+	}
+	result := tx.Where("user_id = ?", userID).Delete(&mfaentity.TOTPFactor{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to remove TOTP factor: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotEnabled
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeCode drops the spaces apps show within codes; recovery codes are matched in lower case
+func normalizeCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), " ", ""))
+}
+
+func randomHex(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate random value: %v", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func hashValue(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,128 @@
+package mfaservice
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 key of the RFC 6238 test vectors
+const rfcSecret = "12345678901234567890"
+
+func TestTOTPCode(t *testing.T) {
+	// RFC 6238 appendix B, SHA1; the codes are the last six of the RFC's eight digits
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+		{unix: 20000000000, want: "353130"},
+	}
+	for _, tt := range tests {
+		if got := totpCode([]byte(rfcSecret), tt.unix/totpPeriod); got != tt.want {
+			t.Errorf("code at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestMatchTOTP(t *testing.T) {
+	secret := secretEncoding.EncodeToString([]byte(rfcSecret))
+	now := time.Unix(1234567890, 0)
+	current := now.Unix() / totpPeriod
+	code := func(step int64) string { return totpCode([]byte(rfcSecret), step) }
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		lastStep int64
+		wantStep int64
+		wantOK   bool
+	}{
+		{name: "current step", secret: secret, code: code(current), wantStep: current, wantOK: true},
+		{name: "previous step within the skew", secret: secret, code: code(current - 1), wantStep: current - 1, wantOK: true},
+		{name: "next step within the skew", secret: secret, code: code(current + 1), wantStep: current + 1, wantOK: true},
+		{name: "two steps old", secret: secret, code: code(current - 2)},
+		{name: "two steps ahead", secret: secret, code: code(current + 2)},
+		{name: "replay of the last used step", secret: secret, code: code(current), lastStep: current},
+		{name: "step before the last used one", secret: secret, code: code(current - 1), lastStep: current},
+		{name: "later step after a used one", secret: secret, code: code(current + 1), lastStep: current, wantStep: current + 1, wantOK: true},
+		{name: "wrong code", secret: secret, code: "000000"},
+		{name: "too short", secret: secret, code: code(current)[:5]},
+		{name: "too long", secret: secret, code: code(current) + "0"},
+		{name: "secret not base32", secret: "not base32!", code: code(current)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := matchTOTP(tt.secret, tt.code, now, tt.lastStep)
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Fatalf("matchTOTP = %d, %v, want %d, %v", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestNewTOTPSecret(t *testing.T) {
+	secret, err := newTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := secretEncoding.DecodeString(secret)
+	if err != nil {
+		t.Fatalf("secret %q is not base32: %v", secret, err)
+	}
+	if len(key) != 20 {
+		t.Fatalf("secret holds %d bytes, want 20", len(key))
+	}
+}
+
+func TestOtpauthURI(t *testing.T) {
+	tests := []struct {
+		name      string
+		issuer    string
+		account   string
+		wantLabel string
+	}{
+		{name: "default issuer", account: "alice", wantLabel: "/Security%20Logs:alice"},
+		{name: "configured issuer", issuer: "Acme", account: "bob@acme.test", wantLabel: "/Acme:bob@acme.test"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MFA_TOTP_ISSUER", tt.issuer)
+			uri := otpauthURI(tt.account, "JBSWY3DPEHPK3PXP")
+			if !strings.HasPrefix(uri, "otpauth://totp"+tt.wantLabel+"?") {
+				t.Fatalf("uri %s, want label %s", uri, tt.wantLabel)
+			}
+			parsed, err := url.Parse(uri)
+			if err != nil {
+				t.Fatal(err)
+			}
+			query := parsed.Query()
+			for key, want := range map[string]string{"secret": "JBSWY3DPEHPK3PXP", "algorithm": "SHA1", "digits": "6", "period": "30"} {
+				if got := query.Get(key); got != want {
+					t.Errorf("%s = %q, want %q", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNormalizeCode(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{code: "123456", want: "123456"},
+		{code: " 123 456 ", want: "123456"},
+		{code: "AB12-CD34", want: "ab12-cd34"},
+	}
+	for _, tt := range tests {
+		if got := normalizeCode(tt.code); got != tt.want {
+			t.Errorf("normalizeCode(%q) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package oidcservice
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/yatender-pareek/identity/src/tenancy"
+)
+
+// Config is the deployment's OpenID Connect client, read from OIDC_* environment variables
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	GroupsClaim  string
+	// RoleMapping maps IdP groups to roles; the first entry whose group the user is in wins
+	RoleMapping []GroupRole
+	// DefaultRole is given to users in none of the mapped groups; empty refuses them
+	DefaultRole string
+	// Tenant is the tenant new users join
+	Tenant string
+	// LinkByEmail links a first login to the tenant's existing user with the same verified email
+	LinkByEmail bool
+}
+
+// GroupRole maps one IdP group to a role
+type GroupRole struct {
+	Group string
+	Role  string
+}
+
+// ConfigFromEnv reads the client configuration; it is disabled when OIDC_ISSUER is unset
+func ConfigFromEnv() (Config, bool, error) {
+	config := Config{
+		Issuer:       strings.TrimSpace(os.Getenv("OIDC_ISSUER")),
+		ClientID:     strings.TrimSpace(os.Getenv("OIDC_CLIENT_ID")),
+		ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+		RedirectURL:  strings.TrimSpace(os.Getenv("OIDC_REDIRECT_URL")),
+		Scopes:       strings.Fields(os.Getenv("OIDC_SCOPES")),
+		GroupsClaim:  strings.TrimSpace(os.Getenv("OIDC_GROUPS_CLAIM")),
+		DefaultRole:  strings.TrimSpace(os.Getenv("OIDC_DEFAULT_ROLE")),
+		Tenant:       strings.TrimSpace(os.Getenv("OIDC_TENANT")),
+		LinkByEmail:  os.Getenv("OIDC_LINK_BY_EMAIL") != "false",
+	}
+	if config.Issuer == "" {
+		return Config{}, false, nil
+	}
+	if config.ClientID == "" || config.RedirectURL == "" {
+		return Config{}, true, fmt.Errorf("OIDC_CLIENT_ID and OIDC_REDIRECT_URL are required with OIDC_ISSUER")
+	}
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "profile", "email"}
+	}
+	if config.GroupsClaim == "" {
+		config.GroupsClaim = "groups"
+	}
+	if config.Tenant == "" {
+		config.Tenant = tenancy.DefaultTenant
+	}
+	if config.DefaultRole != "" && !ssoRole(config.DefaultRole) {
+		return Config{}, true, fmt.Errorf("OIDC_DEFAULT_ROLE %q is not a tenant role", config.DefaultRole)
+	}
+	for _, entry := range strings.Split(os.Getenv("OIDC_ROLE_MAPPING"), ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		group, role, ok := strings.Cut(entry, "=")
+		group, role = strings.TrimSpace(group), strings.TrimSpace(role)
+		if !ok || group == "" || !ssoRole(role) {
+			return Config{}, true, fmt.Errorf("OIDC_ROLE_MAPPING entry %q must be group=role with a role other than %s", entry, tenancy.RolePlatformAdmin)
+		}
+		config.RoleMapping = append(config.RoleMapping, GroupRole{Group: group, Role: role})
+	}
+	return config, true, nil
+}
+
+// ssoRole reports whether the provider's groups may grant role; platform_admin is never one
+func ssoRole(role string) bool {
+	return tenancy.ValidRole(role) && role != tenancy.RolePlatformAdmin
+}
+
+var (
+	defaultOnce    sync.Once
+	defaultService *OIDCService
+)
+
+// Default returns the service for the environment's configuration, or nil when single sign-on
+// is not configured. An invalid configuration stops the service.
+func Default() *OIDCService {
+	defaultOnce.Do(func() {
+		config, enabled, err := ConfigFromEnv()
+		if err != nil {
+			log.Fatalf("Invalid OIDC configuration: %v", err)
+		}
+		if enabled {
+			defaultService = NewOIDCService(config)
+		}
+	})
+	return defaultService
+}
+
+// Enabled reports whether single sign-on is configured
+func Enabled() bool {
+	return Default() != nil
+}
+
+// roleFor returns the role the user's groups map to, or DefaultRole
+func (c Config) roleFor(groups []string) string {
+	member := make(map[string]bool, len(groups))
+	for _, group := range groups {
+		member[group] = true
+	}
+	for _, mapping := range c.RoleMapping {
+		if member[mapping.Group] {
+			return mapping.Role
+		}
+	}
+	return c.DefaultRole
+}
@@ -0,0 +1,469 @@
+// Package oidcservice signs users in through an OpenID Connect provider with the authorization code flow and PKCE
+package oidcservice
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	dbconfig "github.com/yatender-pareek/identity/src/config/db-config"
+	oidcentity "github.com/yatender-pareek/identity/src/models/oidc-model"
+	userentity "github.com/yatender-pareek/identity/src/models/user-model"
+	tenantservice "github.com/yatender-pareek/identity/src/services/tenant-service"
+	tokenservice "github.com/yatender-pareek/identity/src/services/token-service"
+	"github.com/yatender-pareek/identity/src/tenancy"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	loginStateTTL   = 10 * time.Minute
+	maxResponseSize = 1 << 20
+	// idTokenLeeway tolerates clock skew between this service and the provider
+	idTokenLeeway = time.Minute
+)
+
+var (
+	// ErrInvalidState is returned for callbacks whose state is unknown, expired or already used
+	ErrInvalidState = errors.New("login expired or was already completed; start again")
+	// ErrProvider is returned when the provider cannot be reached or answers unexpectedly
+	ErrProvider = errors.New("identity provider error")
+	// ErrInvalidIDToken is returned when the provider's ID token does not validate
+	ErrInvalidIDToken = errors.New("invalid ID token")
+	// ErrAccountRemoved is returned when the provider account is linked to a deleted user
+	ErrAccountRemoved = errors.New("the account linked to this identity was removed")
+	// ErrNoRole is returned when none of the user's groups grants a role and there is no default role
+	ErrNoRole = errors.New("none of your identity provider groups grants access")
+)
+
+// providerMetadata is the part of the provider's discovery document this client uses
+type providerMetadata struct {
+	Issuer                string   `json:"issuer"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	JWKSURI               string   `json:"jwks_uri"`
+	CodeChallengeMethods  []string `json:"code_challenge_methods_supported"`
+}
+
+// idTokenClaims are the ID token claims used to find or provision the local user
+type idTokenClaims struct {
+	Subject           string
+	Nonce             string
+	Email             string
+	EmailVerified     bool
+	PreferredUsername string
+	Groups            []string
+}
+
+type OIDCService struct {
+	config Config
+	client *http.Client
+
+	mu       sync.Mutex
+	provider *providerMetadata
+	keys     *tokenservice.Verifier
+}
+
+func NewOIDCService(config Config) *OIDCService {
+	return &OIDCService{config: config, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// discover fetches the provider's discovery document once it is reachable and keeps it
+func (s *OIDCService) discover() (*providerMetadata, *tokenservice.Verifier, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.provider != nil {
+		return s.provider, s.keys, nil
+	}
+
+	var metadata providerMetadata
+	if err := s.getJSON(strings.TrimSuffix(s.config.Issuer, "/")+"/.well-known/openid-configuration", &metadata); err != nil {
+		return nil, nil, fmt.Errorf("%w: discovery failed: %v", ErrProvider, err)
+	}
+	if metadata.Issuer != s.config.Issuer {
+		return nil, nil, fmt.Errorf("%w: discovery names issuer %q, expected %q", ErrProvider, metadata.Issuer, s.config.Issuer)
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, nil, fmt.Errorf("%w: discovery lacks authorization, token or JWKS endpoint", ErrProvider)
+	}
+	if len(metadata.CodeChallengeMethods) > 0 && !contains(metadata.CodeChallengeMethods, "S256") {
+		return nil, nil, fmt.Errorf("%w: provider does not support PKCE with S256", ErrProvider)
+	}
+	s.provider, s.keys = &metadata, tokenservice.NewJWKSVerifier(metadata.JWKSURI)
+	return s.provider, s.keys, nil
+}
+
+// StartLogin records a new authorization request and returns the provider URL to send the
+// browser to, and the state the callback must present
+func (s *OIDCService) StartLogin() (string, string, error) {
+	provider, _, err := s.discover()
+	if err != nil {
+		return "", "", err
+	}
+	state, errState := randomString(32)
+	nonce, errNonce := randomString(32)
+	verifier, errVerifier := randomString(48)
+	if err := errors.Join(errState, errNonce, errVerifier); err != nil {
+		return "", "", err
+	}
+
+	db := dbconfig.GetDB()
+	// Abandoned logins are cleared as new ones start
+	if err := db.Where("expires_at < ?", time.Now()).Delete(&oidcentity.LoginState{}).Error; err != nil {
+		return "", "", fmt.Errorf("failed to clear expired logins: %v", err)
+	}
+	if err := db.Create(&oidcentity.LoginState{
+		StateHash:    hashValue(state),
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(loginStateTTL),
+	}).Error; err != nil {
+		return "", "", fmt.Errorf("failed to save login: %v", err)
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {s.config.ClientID},
+		"redirect_uri":          {s.config.RedirectURL},
+		"scope":                 {strings.Join(s.config.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	separator := "?"
+	if strings.Contains(provider.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return provider.AuthorizationEndpoint + separator + query.Encode(), state, nil
+}
+
+// CompleteLogin redeems the authorization code of a callback, validates the ID token and
+// returns the local user it belongs to, provisioning or linking one on first login
+func (s *OIDCService) CompleteLogin(state, code string) (userentity.User, error) {
+	provider, keys, err := s.discover()
+	if err != nil {
+		return userentity.User{}, err
+	}
+
+	// Deleting the state makes it single-use even when two callbacks race
+	db := dbconfig.GetDB()
+	var login oidcentity.LoginState
+	if err := db.Where("state_hash = ?", hashValue(state)).First(&login).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return userentity.User{}, ErrInvalidState
+		}
+		return userentity.User{}, err
+	}
+	result := db.Where("state_hash = ?", login.StateHash).Delete(&oidcentity.LoginState{})
+	if result.Error != nil {
+		return userentity.User{}, result.Error
+	}
+	if result.RowsAffected == 0 || time.Now().After(login.ExpiresAt) {
+		return userentity.User{}, ErrInvalidState
+	}
+
+	idToken, err := s.redeemCode(provider, code, login.CodeVerifier)
+	if err != nil {
+		return userentity.User{}, err
+	}
+	claims, err := s.validateIDToken(keys, idToken, login.Nonce)
+	if err != nil {
+		return userentity.User{}, err
+	}
+	return s.signIn(claims)
+}
+
+// redeemCode exchanges the authorization code for the provider's tokens and returns the ID token
+func (s *OIDCService) redeemCode(provider *providerMetadata, code, verifier string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {s.config.RedirectURL},
+		"client_id":     {s.config.ClientID},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequest(http.MethodPost, provider.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if s.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(s.config.ClientID), url.QueryEscape(s.config.ClientSecret))
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%w: token request failed: %v", ErrProvider, err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&body); err != nil {
+		return "", fmt.Errorf("%w: invalid token response: %v", ErrProvider, err)
+	}
+	if body.Error == "invalid_grant" {
+		return "", ErrInvalidState
+	}
+	if resp.StatusCode != http.StatusOK || body.Error != "" {
+		return "", fmt.Errorf("%w: token endpoint answered %s: %s %s", ErrProvider, resp.Status, body.Error, body.ErrorDescription)
+	}
+	if body.IDToken == "" {
+		return "", fmt.Errorf("%w: token response has no id_token; is the openid scope requested?", ErrProvider)
+	}
+	return body.IDToken, nil
+}
+
+// validateIDToken checks the ID token's signature against the provider's JWKS, its issuer,
+// audience, expiry and nonce
+func (s *OIDCService) validateIDToken(keys *tokenservice.Verifier, idToken, nonce string) (idTokenClaims, error) {
+	token, err := jwt.Parse(idToken, keys.Keyfunc,
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg()}),
+		jwt.WithIssuer(s.config.Issuer),
+		jwt.WithAudience(s.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(idTokenLeeway),
+	)
+	if err != nil {
+		return idTokenClaims{}, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	mapClaims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return idTokenClaims{}, ErrInvalidIDToken
+	}
+	// A token for several audiences must name this client as the party it was issued to
+	if audience, _ := mapClaims.GetAudience(); len(audience) > 1 {
+		if azp, _ := mapClaims["azp"].(string); azp != s.config.ClientID {
+			return idTokenClaims{}, fmt.Errorf("%w: azp does not name this client", ErrInvalidIDToken)
+		}
+	}
+
+	var claims idTokenClaims
+	claims.Subject, _ = mapClaims.GetSubject()
+	claims.Nonce, _ = mapClaims["nonce"].(string)
+	claims.Email, _ = mapClaims["email"].(string)
+	claims.PreferredUsername, _ = mapClaims["preferred_username"].(string)
+	switch verified := mapClaims["email_verified"].(type) {
+	case bool:
+		claims.EmailVerified = verified
+	case string:
+		claims.EmailVerified = verified == "true"
+	}
+	switch groups := mapClaims[s.config.GroupsClaim].(type) {
+	case []interface{}:
+		for _, group := range groups {
+			if name, ok := group.(string); ok {
+				claims.Groups = append(claims.Groups, name)
+			}
+		}
+	case string:
+		claims.Groups = strings.FieldsFunc(groups, func(r rune) bool { return r == ',' || r == ' ' })
+	}
+
+	if claims.Subject == "" {
+		return idTokenClaims{}, fmt.Errorf("%w: no sub claim", ErrInvalidIDToken)
+	}
+	if claims.Nonce != nonce {
+		return idTokenClaims{}, fmt.Errorf("%w: nonce does not match the login", ErrInvalidIDToken)
+	}
+	return claims, nil
+}
+
+// signIn finds the user linked to the provider account, else links the tenant's user with the
+// same verified email, else provisions a new user, and gives them the role their groups map to
+func (s *OIDCService) signIn(claims idTokenClaims) (userentity.User, error) {
+	role := s.config.roleFor(claims.Groups)
+	if role == "" {
+		return userentity.User{}, ErrNoRole
+	}
+
+	var user userentity.User
+	err := dbconfig.GetDB().Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		var identity oidcentity.ExternalIdentity
+		err := tx.Where("issuer = ? AND subject = ?", s.config.Issuer, claims.Subject).First(&identity).Error
+		switch {
+		case err == nil:
+			if err := tx.First(&user, identity.UserID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ErrAccountRemoved
+				}
+				return err
+			}
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			found, err := s.linkableUser(tx, claims)
+			if err != nil {
+				return err
+			}
+			if found == nil {
+				if found, err = s.provisionUser(tx, claims); err != nil {
+					return err
+				}
+			}
+			user = *found
+			identity = oidcentity.ExternalIdentity{UserID: user.ID, Issuer: s.config.Issuer, Subject: claims.Subject}
+		default:
+			return err
+		}
+
+		identity.Email, identity.LastLoginAt = claims.Email, &now
+		if err := tx.Save(&identity).Error; err != nil {
+			return fmt.Errorf("failed to link identity: %v", err)
+		}
+
+		// The provider's groups decide the role on every login. platform_admin is only granted by
+		// Bootstrap or another platform admin and is never derived from what the provider sends.
+		if user.Role == tenancy.RolePlatformAdmin {
+			role = tenancy.RolePlatformAdmin
+		}
+		if user.Role != role {
+			user.Role = role
+			if err := tx.Model(&user).Update("role", role).Error; err != nil {
+				return fmt.Errorf("failed to update role: %v", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return userentity.User{}, err
+	}
+	return user, nil
+}
+
+// linkableUser returns the configured tenant's only user with the token's email, if the
+// provider verified it and linking by email is enabled
+func (s *OIDCService) linkableUser(tx *gorm.DB, claims idTokenClaims) (*userentity.User, error) {
+	if !s.config.LinkByEmail || !claims.EmailVerified || claims.Email == "" {
+		return nil, nil
+	}
+	var users []userentity.User
+	if err := tx.Where("tenant = ? AND email = ?", s.config.Tenant, claims.Email).Limit(2).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	if len(users) != 1 {
+		return nil, nil
+	}
+	return &users[0], nil
+}
+
+// provisionUser creates a user in the configured tenant. Its random password cannot be used,
+// so the user can only sign in through the provider.
+func (s *OIDCService) provisionUser(tx *gorm.DB, claims idTokenClaims) (*userentity.User, error) {
+	if _, err := tenantservice.NewTenantService().CreateTenant(tx, s.config.Tenant); err != nil && err != tenantservice.ErrTenantExists {
+		return nil, err
+	}
+	username, err := availableUsername(tx, claims)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := randomString(32)
+	if err != nil {
+		return nil, err
+	}
+	password, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	user := userentity.User{
+		Username: username,
+		Password: string(password),
+		Email:    claims.Email,
+		Tenant:   s.config.Tenant,
+		Role:     tenancy.RoleViewer,
+	}
+	if err := tx.Create(&user).Error; err != nil {
+		return nil, fmt.Errorf("failed to provision user: %v", err)
+	}
+	return &user, nil
+}
+
+var usernameUnsafe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// availableUsername derives a free username from preferred_username or the email's local part.
+// Names listed in PLATFORM_ADMINS count as taken, so the next start cannot promote an account
+// the provider chose the name of.
+func availableUsername(tx *gorm.DB, claims idTokenClaims) (string, error) {
+	base := claims.PreferredUsername
+	if base == "" {
+		base, _, _ = strings.Cut(claims.Email, "@")
+	}
+	base = usernameUnsafe.ReplaceAllString(base, "")
+	if len(base) > 40 {
+		base = base[:40]
+	}
+	if base == "" {
+		base = "sso"
+	}
+	for attempt := 0; attempt < 5; attempt++ {
+		username := base
+		if attempt > 0 {
+			suffix, err := randomString(4)
+			if err != nil {
+				return "", err
+			}
+			username = base + "-" + strings.ToLower(suffix)
+		}
+		var taken int64
+		// Deleted users keep their username in the unique index
+		if err := tx.Unscoped().Model(&userentity.User{}).Where("username = ?", username).Count(&taken).Error; err != nil {
+			return "", err
+		}
+		if taken == 0 && !tenantservice.IsBootstrapAdmin(username) {
+			return username, nil
+		}
+	}
+	return "", errors.New("no free username for the new user")
+}
+
+func (s *OIDCService) getJSON(target string, into interface{}) error {
+	resp, err := s.client.Get(target)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s answered %s", target, resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(into)
+}
+
+// randomString returns size random bytes, base64url encoded
+func randomString(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate random value: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashValue(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,241 @@
+package sessionservice
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	dbconfig "github.com/yatender-pareek/identity/src/config/db-config"
+	sessionentity "github.com/yatender-pareek/identity/src/models/session-model"
+	userentity "github.com/yatender-pareek/identity/src/models/user-model"
+	tenantservice "github.com/yatender-pareek/identity/src/services/tenant-service"
+	"github.com/yatender-pareek/identity/src/tenancy"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token was already used; the session has been revoked")
+)
+
+// AccessTokenTTL is how long an access token is valid, from ACCESS_TOKEN_TTL (default 15m)
+func AccessTokenTTL() time.Duration {
+	return durationEnv("ACCESS_TOKEN_TTL", defaultAccessTokenTTL)
+}
+
+// RefreshTokenTTL is how long a session lasts without logging in again, from REFRESH_TOKEN_TTL (default 720h)
+func RefreshTokenTTL() time.Duration {
+	return durationEnv("REFRESH_TOKEN_TTL", defaultRefreshTokenTTL)
+}
+
+func durationEnv(name string, fallback time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(name)); err == nil && value > 0 {
+		return value
+	}
+	return fallback
+}
+
+// NewTokenID returns a random identifier for an access token's jti claim
+func NewTokenID() (string, error) {
+	return randomHex(16)
+}
+
+type SessionService struct {
+	tenant string
+}
+
+func NewSessionService() *SessionService {
+	return &SessionService{}
+}
+
+// ForTenant returns a service limited to the sessions of tenant; an empty tenant covers all tenants
+func (s *SessionService) ForTenant(tenant string) *SessionService {
+	return &SessionService{tenant: tenant}
+}
+
+func (s *SessionService) db() *gorm.DB {
+	return tenancy.Scoped(dbconfig.GetDB(), s.tenant)
+}
+
+// StartSession opens a session for user and returns it with its first refresh token
+func (s *SessionService) StartSession(user userentity.User, ipAddress, userAgent string) (sessionentity.Session, string, error) {
+	refreshToken, err := newRefreshToken()
+	if err != nil {
+		return sessionentity.Session{}, "", err
+	}
+	id, err := randomHex(16)
+	if err != nil {
+		return sessionentity.Session{}, "", err
+	}
+	session := sessionentity.Session{
+		ID:               id,
+		Username:         user.Username,
+		Tenant:           user.Tenant,
+		RefreshTokenHash: hashToken(refreshToken),
+		IPAddress:        ipAddress,
+		UserAgent:        truncate(userAgent, 255),
+		ExpiresAt:        time.Now().Add(RefreshTokenTTL()),
+	}
+	if err := dbconfig.GetDB().Create(&session).Error; err != nil {
+		return sessionentity.Session{}, "", fmt.Errorf("failed to create session: %v", err)
+	}
+	return session, refreshToken, nil
+}
+
+// Refresh exchanges a refresh token for a new one and returns the session's user as currently stored,
+// so role and tenant changes apply from the next refresh. Presenting a refresh token that was already
+// exchanged revokes the session, since either it or its replacement has been stolen.
+func (s *SessionService) Refresh(refreshToken string) (sessionentity.Session, userentity.User, string, error) {
+	db := dbconfig.GetDB()
+	hash := hashToken(refreshToken)
+	var session sessionentity.Session
+	if err := db.Where("refresh_token_hash = ?", hash).First(&session).Error; err != nil {
+		if err != gorm.ErrRecordNotFound {
+			return sessionentity.Session{}, userentity.User{}, "", err
+		}
+		if err := db.Where("previous_token_hash = ? AND revoked_at IS NULL", hash).First(&session).Error; err == nil {
+			log.Printf("Refresh token of session %s for %s was reused; revoking the session", session.ID, session.Username)
+			if err := revokeSessions(db, []string{session.ID}); err != nil {
+				return sessionentity.Session{}, userentity.User{}, "", err
+			}
+			return sessionentity.Session{}, userentity.User{}, "", ErrRefreshTokenReused
+		}
+		return sessionentity.Session{}, userentity.User{}, "", ErrInvalidRefreshToken
+	}
+	now := time.Now()
+	if session.RevokedAt != nil || !now.Before(session.ExpiresAt) {
+		return sessionentity.Session{}, userentity.User{}, "", ErrInvalidRefreshToken
+	}
+
+	var user userentity.User
+	if err := db.Where("username = ?", session.Username).First(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return sessionentity.Session{}, userentity.User{}, "", ErrInvalidRefreshToken
+		}
+		return sessionentity.Session{}, userentity.User{}, "", err
+	}
+
+	next, err := newRefreshToken()
+	if err != nil {
+		return sessionentity.Session{}, userentity.User{}, "", err
+	}
+	// Matching on the old hash makes concurrent refreshes with the same token succeed only once
+	result := db.Model(&sessionentity.Session{}).Where("id = ? AND refresh_token_hash = ?", session.ID, hash).Updates(map[string]interface{}{
+		"refresh_token_hash":  hashToken(next),
+		"previous_token_hash": hash,
+		"tenant":              user.Tenant,
+		"refreshed_at":        now,
+	})
+	if result.Error != nil {
+		return sessionentity.Session{}, userentity.User{}, "", fmt.Errorf("failed to rotate refresh token: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return sessionentity.Session{}, userentity.User{}, "", ErrInvalidRefreshToken
+	}
+	session.Tenant = user.Tenant
+	session.RefreshedAt = &now
+	return session, user, next, nil
+}
+
+// EndSession logs out: the session can no longer be refreshed and its access tokens are rejected
+func (s *SessionService) EndSession(sessionID, jti string, expiresAt time.Time) error {
+	return dbconfig.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&sessionentity.TokenRevocation{JTI: jti, ExpiresAt: expiresAt}).Error; err != nil {
+			return fmt.Errorf("failed to revoke token: %v", err)
+		}
+		return revokeSessions(tx, []string{sessionID})
+	})
+}
+
+// RevokeUserSessions ends every open session of a user the service can see and returns how many there were.
+// A service limited to one tenant cannot revoke the sessions of platform admins.
+func (s *SessionService) RevokeUserSessions(username string) (int64, error) {
+	var revoked int64
+	err := s.db().Transaction(func(tx *gorm.DB) error {
+		var user userentity.User
+		if err := tx.Where("username = ?", username).First(&user).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return tenantservice.ErrUnknownUser
+			}
+			return err
+		}
+		if s.tenant != "" && user.Role == tenancy.RolePlatformAdmin {
+			return tenantservice.ErrPlatformAdminOnly
+		}
+		var ids []string
+		if err := tx.Model(&sessionentity.Session{}).Where("username = ? AND revoked_at IS NULL AND expires_at > ?", username, time.Now()).
+			Pluck("id", &ids).Error; err != nil {
+			return fmt.Errorf("failed to find sessions: %v", err)
+		}
+		revoked = int64(len(ids))
+		return revokeSessions(tx, ids)
+	})
+	return revoked, err
+}
+
+// IsRevoked reports whether an access token was revoked by itself or with its session
+func (s *SessionService) IsRevoked(jti, sessionID string) (bool, error) {
+	var count int64
+	err := dbconfig.GetDB().Model(&sessionentity.TokenRevocation{}).
+		Where("(jti = ? OR session_id = ?) AND expires_at > ?", jti, sessionID, time.Now()).Count(&count).Error
+	return count > 0, err
+}
+
+// revokeSessions marks the sessions revoked and revokes the access tokens already issued for them,
+// dropping revocations that have outlived the tokens they cover
+func revokeSessions(tx *gorm.DB, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	now := time.Now()
+	if err := tx.Model(&sessionentity.Session{}).Where("id IN ? AND revoked_at IS NULL", ids).Update("revoked_at", now).Error; err != nil {
+		return fmt.Errorf("failed to revoke sessions: %v", err)
+	}
+	revocations := make([]sessionentity.TokenRevocation, len(ids))
+	for i, id := range ids {
+		revocations[i] = sessionentity.TokenRevocation{SessionID: id, ExpiresAt: now.Add(AccessTokenTTL())}
+	}
+	if err := tx.Create(&revocations).Error; err != nil {
+		return fmt.Errorf("failed to revoke session tokens: %v", err)
+	}
+	return tx.Where("expires_at <= ?", now).Delete(&sessionentity.TokenRevocation{}).Error
+}
+
+func newRefreshToken() (string, error) {
+	buffer := make([]byte, 32)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", fmt.Errorf("failed to generate refresh token: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buffer), nil
+}
+
+func randomHex(size int) (string, error) {
+	buffer := make([]byte, size)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", fmt.Errorf("failed to generate identifier: %v", err)
+	}
+	return hex.EncodeToString(buffer), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func truncate(value string, max int) string {
+	if len(value) > max {
+		return value[:max]
+	}
+	return value
+}
@@ -0,0 +1,187 @@
+package tenantservice
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	dbconfig "github.com/yatender-pareek/identity/src/config/db-config"
+	tenantentity "github.com/yatender-pareek/identity/src/models/tenant-model"
+	userentity "github.com/yatender-pareek/identity/src/models/user-model"
+	"github.com/yatender-pareek/identity/src/tenancy"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrTenantExists      = errors.New("tenant already exists")
+	ErrUnknownTenant     = errors.New("tenant does not exist")
+	ErrUnknownUser       = errors.New("user does not exist")
+	ErrInvalidTenant     = errors.New("tenant names are 1 to 64 lowercase letters, digits, '-' or '_'")
+	ErrInvalidUserRole   = fmt.Errorf("role must be one of %s", strings.Join(tenancy.Roles, ", "))
+	ErrPlatformAdminOnly = errors.New("only platform admins can move users between tenants or grant or revoke the platform_admin role")
+)
+
+var tenantNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// ValidTenantName reports whether name may be used for a new tenant
+func ValidTenantName(name string) bool {
+	return tenantNamePattern.MatchString(name)
+}
+
+// IsBootstrapAdmin reports whether PLATFORM_ADMINS lists the username
+func IsBootstrapAdmin(username string) bool {
+	for _, admin := range strings.Split(os.Getenv("PLATFORM_ADMINS"), ",") {
+		if strings.TrimSpace(admin) == username && username != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// legacyRolesMigration names the one-time promotion of users from before roles existed
+const legacyRolesMigration = "legacy-users-to-tenant-admins"
+
+// Bootstrap creates the default tenant and a tenant for every name users or the service's
+// tenantModels already refer to, makes users from before roles existed admins of their tenant
+// once, then promotes the existing users listed in PLATFORM_ADMINS to platform admins.
+// Accounts created later, by registration or single sign-on, are never promoted on their own.
+func Bootstrap(db *gorm.DB, tenantModels ...interface{}) error {
+	names := []string{tenancy.DefaultTenant}
+	for _, model := range append([]interface{}{&userentity.User{}}, tenantModels...) {
+		var used []string
+		if err := db.Model(model).Distinct("tenant").Where("tenant <> ''").Pluck("tenant", &used).Error; err != nil {
+			return fmt.Errorf("failed to read existing tenants: %v", err)
+		}
+		names = append(names, used...)
+	}
+	tenants := make([]tenantentity.Tenant, len(names))
+	for i, name := range names {
+		tenants[i].Name = name
+	}
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&tenants).Error; err != nil {
+		return fmt.Errorf("failed to create tenants: %v", err)
+	}
+	// Every user could delete data before roles existed; keep them able to until an admin decides otherwise
+	err := db.Transaction(func(tx *gorm.DB) error {
+		marker := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&tenantentity.Migration{Name: legacyRolesMigration})
+		if marker.Error != nil || marker.RowsAffected == 0 {
+			return marker.Error
+		}
+		return tx.Model(&userentity.User{}).Where("role IN ?", []string{"", "user"}).
+			Update("role", tenancy.RoleAdmin).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to assign roles to existing users: %v", err)
+	}
+
+	var admins []string
+	for _, admin := range strings.Split(os.Getenv("PLATFORM_ADMINS"), ",") {
+		if admin = strings.TrimSpace(admin); admin != "" {
+			admins = append(admins, admin)
+		}
+	}
+	if len(admins) == 0 {
+		return nil
+	}
+	if err := db.Model(&userentity.User{}).Where("username IN ?", admins).
+		Update("role", tenancy.RolePlatformAdmin).Error; err != nil {
+		return fmt.Errorf("failed to promote platform admins: %v", err)
+	}
+	return nil
+}
+
+type TenantService struct {
+	tenant string
+}
+
+func NewTenantService() *TenantService {
+	return &TenantService{}
+}
+
+// ForTenant returns a service that only sees and changes the users of tenant, as for tenant admins.
+// An empty tenant, as for platform admins, covers all tenants.
+func (s *TenantService) ForTenant(tenant string) *TenantService {
+	return &TenantService{tenant: tenant}
+}
+
+func (s *TenantService) db() *gorm.DB {
+	return tenancy.Scoped(dbconfig.GetDB(), s.tenant)
+}
+
+func (s *TenantService) ListTenants() ([]tenantentity.Tenant, error) {
+	var tenants []tenantentity.Tenant
+	if err := dbconfig.GetDB().Order("name").Find(&tenants).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve tenants: %v", err)
+	}
+	return tenants, nil
+}
+
+// CreateTenant creates a tenant, in tx when one is given
+func (s *TenantService) CreateTenant(tx *gorm.DB, name string) (tenantentity.Tenant, error) {
+	if tx == nil {
+		tx = dbconfig.GetDB()
+	}
+	if !ValidTenantName(name) {
+		return tenantentity.Tenant{}, ErrInvalidTenant
+	}
+	tenant := tenantentity.Tenant{Name: name}
+	if err := tx.Create(&tenant).Error; err != nil {
+		if strings.Contains(err.Error(), "Duplicate entry") || err == gorm.ErrDuplicatedKey {
+			return tenantentity.Tenant{}, ErrTenantExists
+		}
+		return tenantentity.Tenant{}, fmt.Errorf("failed to create tenant: %v", err)
+	}
+	return tenant, nil
+}
+
+// ListUsers returns the users the service sees, ordered by username
+func (s *TenantService) ListUsers() ([]userentity.User, error) {
+	var users []userentity.User
+	if err := s.db().Order("username").Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve users: %v", err)
+	}
+	return users, nil
+}
+
+// UpdateUser moves a user to another existing tenant and/or changes their role.
+// A service limited to one tenant may only change roles other than platform_admin.
+func (s *TenantService) UpdateUser(username string, tenant, role *string) (userentity.User, error) {
+	var user userentity.User
+	err := s.db().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("username = ?", username).First(&user).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return ErrUnknownUser
+			}
+			return err
+		}
+		if s.tenant != "" && (tenant != nil || user.Role == tenancy.RolePlatformAdmin ||
+			(role != nil && *role == tenancy.RolePlatformAdmin)) {
+			return ErrPlatformAdminOnly
+		}
+		updates := map[string]interface{}{}
+		if tenant != nil {
+			if err := tx.Where("name = ?", *tenant).First(&tenantentity.Tenant{}).Error; err != nil {
+				if err == gorm.ErrRecordNotFound {
+					return ErrUnknownTenant
+				}
+				return err
+			}
+			updates["tenant"] = *tenant
+		}
+		if role != nil {
+			if !tenancy.ValidRole(*role) {
+				return ErrInvalidUserRole
+			}
+			updates["role"] = *role
+		}
+		if len(updates) == 0 {
+			return nil
+		}
+		return tx.Model(&user).Updates(updates).Error
+	})
+	return user, err
+}
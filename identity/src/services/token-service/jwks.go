@@ -0,0 +1,133 @@
+package tokenservice
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// JWK is the public half of a signing key, as published in the JWKS (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty" example:"EC"`
+	Use string `json:"use,omitempty" example:"sig"`
+	Alg string `json:"alg,omitempty" example:"ES256"`
+	Kid string `json:"kid" example:"0ZcOCORZNYy-DWpqq30jZyJGHTN0d2HglBV3uiguA4I"`
+	Crv string `json:"crv,omitempty" example:"P-256"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+// JWKS is the key set served at /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+var b64 = base64.RawURLEncoding
+
+// PublicJWK describes an RSA or P-256 public key; its kid is the key's RFC 7638 thumbprint
+func PublicJWK(public crypto.PublicKey) (JWK, error) {
+	var jwk JWK
+	switch public := public.(type) {
+	case *rsa.PublicKey:
+		jwk = JWK{
+			Kty: "RSA",
+			Alg: "RS256",
+			N:   b64.EncodeToString(public.N.Bytes()),
+			E:   b64.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+		}
+	case *ecdsa.PublicKey:
+		if public.Curve != elliptic.P256() {
+			return JWK{}, errors.New("ECDSA keys must use the P-256 curve")
+		}
+		jwk = JWK{
+			Kty: "EC",
+			Alg: "ES256",
+			Crv: "P-256",
+			X:   b64.EncodeToString(public.X.FillBytes(make([]byte, 32))),
+			Y:   b64.EncodeToString(public.Y.FillBytes(make([]byte, 32))),
+		}
+	default:
+		return JWK{}, fmt.Errorf("unsupported key type %T", public)
+	}
+	jwk.Use = "sig"
+	jwk.Kid = jwk.thumbprint()
+	return jwk, nil
+}
+
+// thumbprint hashes the key's required members in lexicographic order (RFC 7638)
+func (k JWK) thumbprint() string {
+	var members string
+	if k.Kty == "RSA" {
+		members = fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`, k.E, k.N)
+	} else {
+		members = fmt.Sprintf(`{"crv":%q,"kty":"EC","x":%q,"y":%q}`, k.Crv, k.X, k.Y)
+	}
+	sum := sha256.Sum256([]byte(members))
+	return b64.EncodeToString(sum[:])
+}
+
+// publicKey decodes an RSA or P-256 signing key of a fetched JWKS
+func (k JWK) publicKey() (crypto.PublicKey, error) {
+	if k.Use != "" && k.Use != "sig" {
+		return nil, fmt.Errorf("key %s is not a signing key", k.Kid)
+	}
+	switch k.Kty {
+	case "RSA":
+		n, errN := b64.DecodeString(k.N)
+		e, errE := b64.DecodeString(k.E)
+		if errN != nil || errE != nil || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("key %s has an invalid modulus or exponent", k.Kid)
+		}
+		public := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if public.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("key %s is shorter than %d bits", k.Kid, minRSABits)
+		}
+		return public, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("key %s uses unsupported curve %q", k.Kid, k.Crv)
+		}
+		x, errX := b64.DecodeString(k.X)
+		y, errY := b64.DecodeString(k.Y)
+		if errX != nil || errY != nil || len(x) != 32 || len(y) != 32 {
+			return nil, fmt.Errorf("key %s has invalid coordinates", k.Kid)
+		}
+		// crypto/ecdh rejects points that are not on the curve
+		if _, err := ecdh.P256().NewPublicKey(append(append([]byte{4}, x...), y...)); err != nil {
+			return nil, fmt.Errorf("key %s is not a valid P-256 point", k.Kid)
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	default:
+		return nil, fmt.Errorf("key %s has unsupported type %q", k.Kid, k.Kty)
+	}
+}
+
+// parseJWKS decodes a key set, skipping keys this service cannot verify with
+func parseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var jwks JWKS
+	if err := json.Unmarshal(data, &jwks); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %v", err)
+	}
+	keys := make(map[string]crypto.PublicKey, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.Kid == "" {
+			continue
+		}
+		public, err := jwk.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = public
+	}
+	return keys, nil
+}
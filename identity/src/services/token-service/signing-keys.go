@@ -0,0 +1,117 @@
+package tokenservice
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// minRSABits is the smallest RSA key accepted for RS256
+const minRSABits = 2048
+
+// signingKey is a private key this service signs tokens with
+type signingKey struct {
+	kid     string
+	method  jwt.SigningMethod
+	private crypto.Signer
+}
+
+var (
+	keysOnce    sync.Once
+	signingKeys []signingKey
+)
+
+// localKeys returns the keys listed in JWT_SIGNING_KEYS, stopping the service when one cannot be loaded.
+// The first key signs new tokens; the others are still published and accepted, so tokens they signed
+// stay valid while keys are rotated.
+func localKeys() []signingKey {
+	keysOnce.Do(func() {
+		for _, path := range strings.Split(os.Getenv("JWT_SIGNING_KEYS"), ",") {
+			if path = strings.TrimSpace(path); path == "" {
+				continue
+			}
+			key, err := loadSigningKey(path)
+			if err != nil {
+				log.Fatalf("Failed to load JWT signing key %s: %v", path, err)
+			}
+			signingKeys = append(signingKeys, key)
+		}
+	})
+	return signingKeys
+}
+
+// CanIssue reports whether this service holds signing keys and can log users in
+func CanIssue() bool {
+	return len(localKeys()) > 0
+}
+
+// PublicJWKS returns the public halves of the signing keys
+func PublicJWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	for _, key := range localKeys() {
+		jwk, _ := PublicJWK(key.private.Public())
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	return jwks
+}
+
+// loadSigningKey reads a PEM encoded RSA (PKCS #1 or #8) or P-256 ECDSA (SEC 1 or PKCS #8) private key
+func loadSigningKey(path string) (signingKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return signingKey{}, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return signingKey{}, errors.New("no PEM block found")
+	}
+
+	var private interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		private, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return signingKey{}, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return signingKey{}, err
+	}
+
+	key := signingKey{}
+	switch private := private.(type) {
+	case *rsa.PrivateKey:
+		if private.N.BitLen() < minRSABits {
+			return signingKey{}, fmt.Errorf("RSA keys must have at least %d bits", minRSABits)
+		}
+		key.method, key.private = jwt.SigningMethodRS256, private
+	case *ecdsa.PrivateKey:
+		if private.Curve != elliptic.P256() {
+			return signingKey{}, errors.New("ECDSA keys must use the P-256 curve")
+		}
+		key.method, key.private = jwt.SigningMethodES256, private
+	default:
+		return signingKey{}, fmt.Errorf("unsupported key type %T; use RSA or P-256 ECDSA", private)
+	}
+
+	jwk, err := PublicJWK(key.private.Public())
+	if err != nil {
+		return signingKey{}, err
+	}
+	key.kid = jwk.Kid
+	return key, nil
+}
@@ -0,0 +1,85 @@
+// Package tokenservice issues and verifies the access tokens both services accept
+package tokenservice
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	userentity "github.com/yatender-pareek/identity/src/models/user-model"
+	sessionservice "github.com/yatender-pareek/identity/src/services/session-service"
+)
+
+var (
+	// ErrNoSession is returned for tokens issued before sessions existed, which cannot be revoked
+	ErrNoSession = errors.New("token has no session")
+	// ErrCannotIssue is returned when this service has no signing keys
+	ErrCannotIssue = errors.New("no JWT signing key configured")
+)
+
+// Claims are what an access token asserts about its bearer
+type Claims struct {
+	Username  string
+	Tenant    string
+	Role      string
+	JTI       string
+	SessionID string
+	ExpiresAt time.Time
+}
+
+// IssueAccessToken signs an access token for user in session with the first signing key,
+// valid for sessionservice.AccessTokenTTL
+func IssueAccessToken(user userentity.User, sessionID string) (string, time.Duration, error) {
+	keys := localKeys()
+	if len(keys) == 0 {
+		return "", 0, ErrCannotIssue
+	}
+	jti, err := sessionservice.NewTokenID()
+	if err != nil {
+		return "", 0, err
+	}
+	ttl := sessionservice.AccessTokenTTL()
+	now := time.Now()
+	token := jwt.NewWithClaims(keys[0].method, jwt.MapClaims{
+		"username": user.Username,
+		"tenant":   user.Tenant,
+		"role":     user.Role,
+		"sid":      sessionID,
+		"jti":      jti,
+		"iat":      now.Unix(),
+		"exp":      now.Add(ttl).Unix(),
+	})
+	token.Header["kid"] = keys[0].kid
+	signed, err := token.SignedString(keys[0].private)
+	if err != nil {
+		return "", 0, err
+	}
+	return signed, ttl, nil
+}
+
+// Parse verifies the token's signature and expiry and returns its claims.
+// It does not consult the revocation list.
+func (v *Verifier) Parse(tokenString string) (Claims, error) {
+	token, err := jwt.Parse(tokenString, v.Keyfunc, jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return Claims{}, err
+	}
+	mapClaims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return Claims{}, jwt.ErrTokenInvalidClaims
+	}
+
+	var claims Claims
+	claims.Username, _ = mapClaims["username"].(string)
+	claims.Tenant, _ = mapClaims["tenant"].(string)
+	claims.Role, _ = mapClaims["role"].(string)
+	claims.JTI, _ = mapClaims["jti"].(string)
+	claims.SessionID, _ = mapClaims["sid"].(string)
+	if expiresAt, err := mapClaims.GetExpirationTime(); err == nil && expiresAt != nil {
+		claims.ExpiresAt = expiresAt.Time
+	}
+	if claims.JTI == "" || claims.SessionID == "" {
+		return Claims{}, ErrNoSession
+	}
+	return claims, nil
+}
@@ -0,0 +1,143 @@
+package tokenservice
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	defaultJWKSCacheTTL = 5 * time.Minute
+	// jwksRefetchInterval limits refetches for unknown kids, so forged kids cannot flood the issuer
+	jwksRefetchInterval = 30 * time.Second
+	maxJWKSBytes        = 1 << 20
+)
+
+// ErrUnknownKey is returned for tokens signed by a key missing from the JWKS
+var ErrUnknownKey = errors.New("token signed by an unknown key")
+
+// jwksCacheTTL is how long a fetched JWKS is used before it is fetched again, from JWT_JWKS_CACHE_TTL
+func jwksCacheTTL() time.Duration {
+	if ttl, err := time.ParseDuration(os.Getenv("JWT_JWKS_CACHE_TTL")); err == nil && ttl > 0 {
+		return ttl
+	}
+	return defaultJWKSCacheTTL
+}
+
+// Verifier checks access token signatures against a JWKS: the one fetched from
+// JWT_JWKS_URL, or else this service's own signing keys
+type Verifier struct {
+	url    string
+	ttl    time.Duration
+	client *http.Client
+
+	mu          sync.Mutex
+	keys        map[string]crypto.PublicKey
+	fetchedAt   time.Time
+	attemptedAt time.Time
+}
+
+// NewVerifier returns the verifier for this service's configuration, stopping the service
+// when it has neither a JWKS URL nor signing keys
+func NewVerifier() *Verifier {
+	if url := os.Getenv("JWT_JWKS_URL"); url != "" {
+		return NewJWKSVerifier(url)
+	}
+	keys := localKeys()
+	if len(keys) == 0 {
+		log.Fatal("Set JWT_SIGNING_KEYS to sign tokens or JWT_JWKS_URL to verify tokens signed elsewhere")
+	}
+	v := &Verifier{keys: make(map[string]crypto.PublicKey, len(keys))}
+	for _, key := range keys {
+		v.keys[key.kid] = key.private.Public()
+	}
+	return v
+}
+
+// NewJWKSVerifier returns a verifier for the keys published at url, such as an identity provider's jwks_uri
+func NewJWKSVerifier(url string) *Verifier {
+	return &Verifier{url: url, ttl: jwksCacheTTL(), client: &http.Client{Timeout: 5 * time.Second}}
+}
+
+// Keyfunc resolves the key a token names in its kid header, for jwt.Parse
+func (v *Verifier) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, errors.New("token has no kid header")
+	}
+	return v.keyFor(kid, token.Method.Alg())
+}
+
+// publicKey returns the key with the given kid, fetching the JWKS when the cache is stale or lacks it
+func (v *Verifier) publicKey(kid string) (crypto.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	key, ok := v.keys[kid]
+	if v.url == "" {
+		if !ok {
+			return nil, ErrUnknownKey
+		}
+		return key, nil
+	}
+	stale := time.Since(v.fetchedAt) > v.ttl
+	if (stale || !ok) && time.Since(v.attemptedAt) > jwksRefetchInterval {
+		v.attemptedAt = time.Now()
+		// A failed fetch keeps the cached keys, so an unreachable issuer does not log everyone out
+		if keys, err := v.fetch(); err != nil {
+			log.Printf("JWKS fetch error: %v", err)
+		} else {
+			v.keys, v.fetchedAt = keys, time.Now()
+			key, ok = v.keys[kid]
+		}
+	}
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return key, nil
+}
+
+func (v *Verifier) fetch() (map[string]crypto.PublicKey, error) {
+	resp, err := v.client.Get(v.url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s answered %s", v.url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxJWKSBytes))
+	if err != nil {
+		return nil, err
+	}
+	return parseJWKS(data)
+}
+
+// keyFor returns the key a token must be verified with, refusing algorithms that do not match its type
+func (v *Verifier) keyFor(kid, alg string) (interface{}, error) {
+	key, err := v.publicKey(kid)
+	if err != nil {
+		return nil, err
+	}
+	switch key.(type) {
+	case *rsa.PublicKey:
+		if alg == "RS256" {
+			return key, nil
+		}
+	case *ecdsa.PublicKey:
+		if alg == "ES256" {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("key %s does not sign %s tokens", kid, alg)
+}
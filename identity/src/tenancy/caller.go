@@ -0,0 +1,63 @@
+package tenancy
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultTenant owns users and data that were not assigned a tenant
+const DefaultTenant = "default"
+
+// Caller is the authenticated user or API key of a request, as stored by AuthMiddleware
+type Caller struct {
+	Username string
+	Tenant   string
+	Role     string
+	// Permissions, when set, replace those of Role; API keys carry the permissions of their scopes
+	Permissions []Permission
+}
+
+// CallerOf returns the caller of a request
+func CallerOf(c *gin.Context) Caller {
+	caller := Caller{Username: c.GetString("username"), Tenant: c.GetString("tenant"), Role: c.GetString("role")}
+	if permissions, ok := c.Get("permissions"); ok {
+		caller.Permissions, _ = permissions.([]Permission)
+	}
+	return caller
+}
+
+// SetCaller stores the authenticated caller of a request for CallerOf
+func SetCaller(c *gin.Context, caller Caller) {
+	c.Set("username", caller.Username)
+	c.Set("tenant", caller.Tenant)
+	c.Set("role", caller.Role)
+	if caller.Permissions != nil {
+		c.Set("permissions", caller.Permissions)
+	}
+}
+
+// IsPlatformAdmin reports whether the caller may act across tenants
+func (c Caller) IsPlatformAdmin() bool {
+	return c.Role == RolePlatformAdmin
+}
+
+// ScopeTenant is the tenant the caller's data access is limited to; platform admins see every tenant
+func (c Caller) ScopeTenant() string {
+	if c.IsPlatformAdmin() {
+		return ""
+	}
+	return c.Tenant
+}
+
+// RequirePlatformAdmin rejects callers that are not platform admins
+func RequirePlatformAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !CallerOf(c).IsPlatformAdmin() {
+			c.JSON(http.StatusForbidden, gin.H{"error": "platform admin role required"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,81 @@
+package tenancy
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// RoleAdmin manages their tenant: everything analysts and ingestors do, deletes, role assignment and API keys
+	RoleAdmin = "admin"
+	// RoleAnalyst reads logs, runs analyses, triages threats and manages incidents
+	RoleAnalyst = "analyst"
+	// RoleViewer only reads logs, threats, incidents and rules
+	RoleViewer = "viewer"
+	// RoleIngestor only sends logs
+	RoleIngestor = "ingestor"
+	// RolePlatformAdmin may do everything in every tenant and manage the tenants themselves
+	RolePlatformAdmin = "platform_admin"
+)
+
+// Roles lists every role a user can hold
+var Roles = []string{RoleAdmin, RoleAnalyst, RoleViewer, RoleIngestor, RolePlatformAdmin}
+
+// ValidRole reports whether role is one of Roles
+func ValidRole(role string) bool {
+	for _, r := range Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Permission is an action a route requires
+type Permission string
+
+const (
+	PermIngest      Permission = "ingest"
+	PermRead        Permission = "read"
+	PermAnalyze     Permission = "analyze"
+	PermDelete      Permission = "delete"
+	PermManageUsers Permission = "manage_users"
+	PermManageKeys  Permission = "manage_api_keys"
+)
+
+var rolePermissions = map[string][]Permission{
+	RoleAdmin:    {PermIngest, PermRead, PermAnalyze, PermDelete, PermManageUsers, PermManageKeys},
+	RoleAnalyst:  {PermRead, PermAnalyze},
+	RoleViewer:   {PermRead},
+	RoleIngestor: {PermIngest},
+}
+
+// Can reports whether the caller's role grants permission; platform admins hold every permission
+func (c Caller) Can(permission Permission) bool {
+	if c.IsPlatformAdmin() {
+		return true
+	}
+	granted := rolePermissions[c.Role]
+	if c.Permissions != nil {
+		granted = c.Permissions
+	}
+	for _, p := range granted {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Require rejects callers whose role lacks permission
+func Require(permission Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !CallerOf(c).Can(permission) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "your role does not have the " + string(permission) + " permission"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
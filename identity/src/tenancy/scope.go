@@ -0,0 +1,101 @@
+// Package tenancy identifies the caller's tenant and keeps database access inside it
+package tenancy
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrCrossTenant is returned when a scoped session writes a row of another tenant
+var ErrCrossTenant = errors.New("record belongs to another tenant")
+
+type contextKey struct{}
+
+// WithTenant returns a context that limits database access to tenant
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, contextKey{}, tenant)
+}
+
+// TenantOf returns the tenant database access is limited to, if any
+func TenantOf(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	tenant, ok := ctx.Value(contextKey{}).(string)
+	return tenant, ok
+}
+
+// Scoped returns db limited to tenant; an empty tenant leaves it unscoped
+func Scoped(db *gorm.DB, tenant string) *gorm.DB {
+	if tenant == "" {
+		return db
+	}
+	return db.WithContext(WithTenant(context.Background(), tenant))
+}
+
+// RegisterCallbacks makes every statement run with a tenant context filter models that
+// have a Tenant field by it, and makes creates fill in or verify that field
+func RegisterCallbacks(db *gorm.DB) error {
+	callback := db.Callback()
+	if err := callback.Query().Before("gorm:query").Register("tenancy:scope", scope); err != nil {
+		return err
+	}
+	if err := callback.Row().Before("gorm:row").Register("tenancy:scope", scope); err != nil {
+		return err
+	}
+	if err := callback.Update().Before("gorm:update").Register("tenancy:scope", scope); err != nil {
+		return err
+	}
+	if err := callback.Delete().Before("gorm:delete").Register("tenancy:scope", scope); err != nil {
+		return err
+	}
+	return callback.Create().Before("gorm:create").Register("tenancy:assign", assign)
+}
+
+func scope(db *gorm.DB) {
+	tenant, ok := TenantOf(db.Statement.Context)
+	if !ok || db.Statement.Schema == nil {
+		return
+	}
+	field := db.Statement.Schema.LookUpField("Tenant")
+	if field == nil || field.DBName == "" {
+		return
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: tenant},
+	}})
+}
+
+func assign(db *gorm.DB) {
+	tenant, ok := TenantOf(db.Statement.Context)
+	if !ok || db.Statement.Schema == nil {
+		return
+	}
+	field := db.Statement.Schema.LookUpField("Tenant")
+	if field == nil || field.DBName == "" {
+		return
+	}
+	ctx := db.Statement.Context
+	check := func(record reflect.Value) {
+		value, zero := field.ValueOf(ctx, record)
+		if zero {
+			if err := field.Set(ctx, record, tenant); err != nil {
+				db.AddError(err)
+			}
+		} else if value != tenant {
+			db.AddError(ErrCrossTenant)
+		}
+	}
+	switch records := reflect.Indirect(db.Statement.ReflectValue); records.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < records.Len(); i++ {
+			check(reflect.Indirect(records.Index(i)))
+		}
+	case reflect.Struct:
+		check(records)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"sync"
 	"time"
 
+	dbconfig "github.com/yatender-pareek/identity/src/config/db-config"
+	"github.com/yatender-pareek/identity/src/tenancy"
 	"github.com/yatender-pareek/log-ingestor-service/src/models"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
@@ -74,6 +76,9 @@ func newDB() (*gorm.DB, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to MySQL database %s: %v", dbName, err)
 	}
+	if err := tenancy.RegisterCallbacks(db); err != nil {
+		return nil, fmt.Errorf("failed to register tenant scoping: %v", err)
+	}
 
 	var dbNameCheck string
 	if err := db.Raw("SELECT DATABASE()").Scan(&dbNameCheck).Error; err != nil {
@@ -95,6 +100,7 @@ func newDB() (*gorm.DB, error) {
 	if err := db.AutoMigrate(models.GetAllModels()...); err != nil {
 		return nil, fmt.Errorf("failed to migrate tables: %v", err)
 	}
+	dbconfig.SetDB(db)
 
 	return db, nil
 }
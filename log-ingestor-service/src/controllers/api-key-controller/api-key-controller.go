@@ -0,0 +1,158 @@
+package apikeycontroller
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	auditservice "github.com/yatender-pareek/identity/src/services/audit-service"
+	tenantservice "github.com/yatender-pareek/identity/src/services/tenant-service"
+	"github.com/yatender-pareek/identity/src/tenancy"
+	apikeydto "github.com/yatender-pareek/log-ingestor-service/src/dtos/api-key-dto"
+	genricerror "github.com/yatender-pareek/log-ingestor-service/src/genric_error"
+	apikeyservice "github.com/yatender-pareek/log-ingestor-service/src/services/api-key-service"
+)
+
+var apiKeyService = apikeyservice.NewAPIKeyService()
+
+// apiKeyServiceFor limits API key management to the caller's tenant
+func apiKeyServiceFor(c *gin.Context) *apikeyservice.APIKeyService {
+	return apiKeyService.ForTenant(tenancy.CallerOf(c).ScopeTenant())
+}
+
+// CreateAPIKey godoc
+// @Summary Create an API key
+// @Description Issues a long-lived key for a log shipper. The full key is only returned by this call; store it right away.
+// @Description Send it in the X-API-Key header (or as a Bearer token). Requires the manage_api_keys permission.
+// @Description Only platform admins can create keys with the audit:write scope, which services use to ship their audit events.
+// @Tags API keys
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param key body apikeydto.CreateAPIKeyRequest true "Key to create"
+// @Success 201 {object} apikeydto.IssuedAPIKeyResponse
+// @Failure 400 {object} genricerror.ErrorResponse
+// @Failure 403 {object} genricerror.ErrorResponse "Role lacks the manage_api_keys permission, tenant differs from the caller's, or audit:write requested by someone other than a platform admin"
+// @Failure 500 {object} genricerror.ErrorResponse
+// @Router /api/api-keys [post]
+func CreateAPIKey(c *gin.Context) {
+	var req apikeydto.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, genricerror.ErrorResponse{Message: err.Error()})
+		return
+	}
+	caller := tenancy.CallerOf(c)
+	tenant := caller.Tenant
+	if req.Tenant != "" && req.Tenant != caller.Tenant {
+		if !caller.IsPlatformAdmin() {
+			c.JSON(http.StatusForbidden, genricerror.ErrorResponse{Message: "only platform admins can create API keys for another tenant"})
+			return
+		}
+		tenant = req.Tenant
+	}
+
+	key, secret, err := apiKeyServiceFor(c).CreateAPIKey(req.Name, req.Scopes, req.AllowedCIDRs, req.ExpiresAt, tenant, caller.Username)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	auditservice.RecordRequest(c, auditservice.ActionAPIKeyCreated, "target", key.Prefix, "scopes", strings.Join(req.Scopes, ","))
+	c.JSON(http.StatusCreated, apikeydto.IssuedAPIKeyResponse{APIKeyResponse: apikeydto.NewAPIKeyResponse(key), Key: secret})
+}
+
+// GetAPIKeys godoc
+// @Summary List API keys
+// @Description Lists the API keys of the caller's tenant, or of every tenant for platform admins, including revoked ones and when each was last used.
+// @Description Requires the manage_api_keys permission.
+// @Tags API keys
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} apikeydto.APIKeyResponse
+// @Failure 403 {object} map[string]string "Role lacks the manage_api_keys permission"
+// @Failure 500 {object} genricerror.ErrorResponse
+// @Router /api/api-keys [get]
+func GetAPIKeys(c *gin.Context) {
+	keys, err := apiKeyServiceFor(c).ListAPIKeys()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, genricerror.ErrorResponse{Message: err.Error()})
+		return
+	}
+	response := make([]apikeydto.APIKeyResponse, len(keys))
+	for i, key := range keys {
+		response[i] = apikeydto.NewAPIKeyResponse(key)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// RotateAPIKey godoc
+// @Summary Rotate an API key
+// @Description Replaces the key's secret and returns the new full key; the old one stops working immediately. Requires the manage_api_keys permission.
+// @Tags API keys
+// @Produce json
+// @Security BearerAuth
+// @Param keyId path int true "API key ID"
+// @Success 200 {object} apikeydto.IssuedAPIKeyResponse
+// @Failure 400 {object} genricerror.ErrorResponse
+// @Failure 403 {object} map[string]string "Role lacks the manage_api_keys permission, or the key has the audit:write scope and the caller is not a platform admin"
+// @Failure 404 {object} genricerror.ErrorResponse "API key not found"
+// @Failure 409 {object} genricerror.ErrorResponse "API key has been revoked"
+// @Failure 500 {object} genricerror.ErrorResponse
+// @Router /api/api-keys/{keyId}/rotate [post]
+func RotateAPIKey(c *gin.Context) {
+	keyID, err := strconv.ParseUint(c.Param("keyId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, genricerror.ErrorResponse{Message: "error in parse API key id"})
+		return
+	}
+	key, secret, err := apiKeyServiceFor(c).RotateAPIKey(keyID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	auditservice.RecordRequest(c, auditservice.ActionAPIKeyRotated, "target", key.Prefix)
+	c.JSON(http.StatusOK, apikeydto.IssuedAPIKeyResponse{APIKeyResponse: apikeydto.NewAPIKeyResponse(key), Key: secret})
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke an API key
+// @Description Disables the key for good; it stays listed with its revocation time. Requires the manage_api_keys permission.
+// @Tags API keys
+// @Produce json
+// @Security BearerAuth
+// @Param keyId path int true "API key ID"
+// @Success 200 {object} apikeydto.APIKeyResponse
+// @Failure 400 {object} genricerror.ErrorResponse
+// @Failure 403 {object} map[string]string "Role lacks the manage_api_keys permission"
+// @Failure 404 {object} genricerror.ErrorResponse "API key not found"
+// @Failure 500 {object} genricerror.ErrorResponse
+// @Router /api/api-keys/{keyId} [delete]
+func RevokeAPIKey(c *gin.Context) {
+	keyID, err := strconv.ParseUint(c.Param("keyId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, genricerror.ErrorResponse{Message: "error in parse API key id"})
+		return
+	}
+	key, err := apiKeyServiceFor(c).RevokeAPIKey(keyID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	auditservice.RecordRequest(c, auditservice.ActionAPIKeyRevoked, "target", key.Prefix)
+	c.JSON(http.StatusOK, apikeydto.NewAPIKeyResponse(key))
+}
+
+func respondError(c *gin.Context, err error) {
+	switch err {
+	case apikeyservice.ErrUnknownAPIKey:
+		c.JSON(http.StatusNotFound, genricerror.ErrorResponse{Message: err.Error()})
+	case apikeyservice.ErrAuditScope:
+		c.JSON(http.StatusForbidden, genricerror.ErrorResponse{Message: err.Error()})
+	case apikeyservice.ErrAPIKeyRevoked:
+		c.JSON(http.StatusConflict, genricerror.ErrorResponse{Message: err.Error()})
+	case apikeyservice.ErrInvalidScope, apikeyservice.ErrInvalidCIDR, apikeyservice.ErrExpiryInPast, tenantservice.ErrUnknownTenant:
+		c.JSON(http.StatusBadRequest, genricerror.ErrorResponse{Message: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, genricerror.ErrorResponse{Message: err.Error()})
+	}
+}
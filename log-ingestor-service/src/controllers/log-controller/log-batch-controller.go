@@ -0,0 +1,224 @@
+package controllers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	logdto "github.com/yatender-pareek/log-ingestor-service/src/dtos/log-dto"
+	genricerror "github.com/yatender-pareek/log-ingestor-service/src/genric_error"
+)
+
+const defaultMaxBatchEntries = 1000
+
+// maxBatchEntries is the largest batch POST /logs/batch accepts, from LOG_BATCH_MAX_ENTRIES
+func maxBatchEntries() int {
+	if value, err := strconv.Atoi(os.Getenv("LOG_BATCH_MAX_ENTRIES")); err == nil && value > 0 {
+		return value
+	}
+	return defaultMaxBatchEntries
+}
+
+// CreateLogBatch godoc
+// @Summary Create logs in bulk
+// @Description Accepts a JSON array of logs, or one log per line with Content-Type application/x-ndjson.
+// @Description Every entry is validated like POST /logs. In the default atomic mode nothing is stored unless every entry is valid;
+// @Description with mode=partial the valid entries are stored and the invalid ones rejected. The response reports each entry by index.
+// @Tags Logs
+// @Accept json
+// @Accept application/x-ndjson
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param logs body []logdto.CreateLogRequest true "Logs to create"
+// @Param mode query string false "atomic (default) or partial" Enums(atomic, partial)
+// @Param X-Tenant header string false "Tenant to ingest for (platform admins only)"
+// @Param X-Log-Source header string false "Default source"
+// @Success 201 {object} logdto.CreateLogBatchResponse "Every entry was stored"
+// @Success 207 {object} logdto.CreateLogBatchResponse "Some entries were stored, some rejected"
+// @Failure 400 {object} logdto.CreateLogBatchResponse "No entry was stored"
+// @Failure 403 {object} genricerror.ErrorResponse "X-Tenant differs from the credential's, X-Log-Source is a reserved audit source, or role lacks the ingest permission"
+// @Failure 413 {object} genricerror.ErrorResponse "Too many entries"
+// @Failure 500 {object} genricerror.ErrorResponse
+// @Router /api/logs/batch [post]
+func CreateLogBatch(c *gin.Context) {
+	mode, ok := batchMode(c)
+	if !ok {
+		return
+	}
+	o, err := requestOrigin(c)
+	if err != nil {
+		c.JSON(http.StatusForbidden, genricerror.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	limit := maxBatchEntries()
+	var items []json.RawMessage
+	if strings.HasPrefix(c.ContentType(), "application/x-ndjson") || c.ContentType() == "application/jsonl" {
+		items, err = readNDJSON(c.Request.Body, limit)
+	} else {
+		items, err = readJSONArray(c.Request.Body, limit)
+	}
+	if err == errBatchTooLarge {
+		c.JSON(http.StatusRequestEntityTooLarge, genricerror.ErrorResponse{Message: fmt.Sprintf("a batch holds at most %d entries", limit)})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, genricerror.ErrorResponse{Message: err.Error()})
+		return
+	}
+	if len(items) == 0 {
+		c.JSON(http.StatusBadRequest, genricerror.ErrorResponse{Message: "batch is empty"})
+		return
+	}
+
+	entries := make([]batchEntry, len(items))
+	for i, item := range items {
+		entries[i].log, entries[i].err = validateBatchItem(item, o)
+	}
+	storeBatch(c, mode, entries)
+}
+
+// batchEntry is one entry of a batch: the decoded log or why it was rejected
+type batchEntry struct {
+	line int
+	log  logdto.CreateLogRequest
+	err  error
+}
+
+// batchMode reads the mode query parameter, answering 400 for unknown modes
+func batchMode(c *gin.Context) (string, bool) {
+	mode := c.DefaultQuery("mode", "atomic")
+	if mode != "atomic" && mode != "partial" {
+		c.JSON(http.StatusBadRequest, genricerror.ErrorResponse{Message: "mode must be atomic or partial"})
+		return "", false
+	}
+	return mode, true
+}
+
+// storeBatch stores the valid entries according to mode and reports on every entry
+func storeBatch(c *gin.Context, mode string, entries []batchEntry) {
+	response := logdto.CreateLogBatchResponse{Results: make([]logdto.LogBatchItemResult, len(entries))}
+	var valid []logdto.CreateLogRequest
+	var validIndexes []int
+	for i, entry := range entries {
+		response.Results[i].Index = i
+		response.Results[i].Line = entry.line
+		if entry.err != nil {
+			response.Results[i].Status = logdto.BatchItemRejected
+			response.Results[i].Error = entry.err.Error()
+			response.Rejected++
+			continue
+		}
+		valid = append(valid, entry.log)
+		validIndexes = append(validIndexes, i)
+	}
+
+	if mode == "atomic" && response.Rejected > 0 {
+		for _, i := range validIndexes {
+			response.Results[i].Status = logdto.BatchItemRejected
+			response.Results[i].Error = "batch rejected because another entry is invalid"
+		}
+		response.Rejected = len(entries)
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	created, err := logService(c).CreateLogs(valid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, genricerror.ErrorResponse{Message: err.Error()})
+		return
+	}
+	for n, i := range validIndexes {
+		id := created[n].ID
+		response.Results[i].Status = logdto.BatchItemAccepted
+		response.Results[i].ID = &id
+	}
+	response.Accepted = len(created)
+
+	switch {
+	case response.Rejected == 0:
+		c.JSON(http.StatusCreated, response)
+	case response.Accepted > 0:
+		c.JSON(http.StatusMultiStatus, response)
+	default:
+		c.JSON(http.StatusBadRequest, response)
+	}
+}
+
+var errBatchTooLarge = errors.New("batch too large")
+
+// validateBatchItem decodes one raw entry and applies the same checks as POST /logs
+func validateBatchItem(item json.RawMessage, o origin) (logdto.CreateLogRequest, error) {
+	var logDto logdto.CreateLogRequest
+	if err := json.Unmarshal(item, &logDto); err != nil {
+		return logDto, fmt.Errorf("invalid entry: %v", err)
+	}
+	if err := o.apply(&logDto); err != nil {
+		return logDto, err
+	}
+	return logDto, validateLog(logDto)
+}
+
+// validateLog applies the checks of POST /logs to a decoded log
+func validateLog(logDto logdto.CreateLogRequest) error {
+	if err := validate.Struct(logDto); err != nil {
+		return fmt.Errorf("validation failed: %v", err)
+	}
+	return LogService.ValidateLog(logDto)
+}
+
+func readJSONArray(body io.Reader, limit int) ([]json.RawMessage, error) {
+	decoder := json.NewDecoder(body)
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, fmt.Errorf("invalid batch: %v", err)
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return nil, fmt.Errorf("invalid batch: expected a JSON array")
+	}
+	var items []json.RawMessage
+	for decoder.More() {
+		if len(items) == limit {
+			return nil, errBatchTooLarge
+		}
+		var item json.RawMessage
+		if err := decoder.Decode(&item); err != nil {
+			return nil, fmt.Errorf("invalid batch: %v", err)
+		}
+		items = append(items, item)
+	}
+	if _, err := decoder.Token(); err != nil {
+		return nil, fmt.Errorf("invalid batch: %v", err)
+	}
+	return items, nil
+}
+
+// readNDJSON splits the body into one entry per non-blank line
+func readNDJSON(body io.Reader, limit int) ([]json.RawMessage, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var items []json.RawMessage
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if len(items) == limit {
+			return nil, errBatchTooLarge
+		}
+		items = append(items, json.RawMessage(append([]byte(nil), line...)))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("invalid batch: %v", err)
+	}
+	return items, nil
+}
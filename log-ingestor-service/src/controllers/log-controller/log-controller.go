@@ -2,16 +2,23 @@
 package controllers
 
 import (
+	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 	"unicode"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
+	auditservice "github.com/yatender-pareek/identity/src/services/audit-service"
+	"github.com/yatender-pareek/identity/src/tenancy"
 	logdto "github.com/yatender-pareek/log-ingestor-service/src/dtos/log-dto"
 	genricerror "github.com/yatender-pareek/log-ingestor-service/src/genric_error"
+	logDataentity "github.com/yatender-pareek/log-ingestor-service/src/models/log-data-model"
 	logingestorservice "github.com/yatender-pareek/log-ingestor-service/src/services/log-ingestor-service"
 	"gorm.io/gorm"
 )
@@ -46,16 +53,26 @@ func InitControllers() {
 	LogService = logingestorservice.NewLogIngestorService()
 }
 
+// logService returns LogService limited to the caller's tenant
+func logService(c *gin.Context) *logingestorservice.LogIngestorService {
+	return LogService.ForTenant(tenancy.CallerOf(c).ScopeTenant())
+}
+
 // CreateLog godoc
 // @Summary Create a new log
-// @Description Create a new log record with provided details
+// @Description Create a new log record with provided details. The tenant is taken from the credential;
+// @Description only platform admins may name another one in the log or the X-Tenant header. source defaults to the X-Log-Source header.
 // @Tags Logs
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param log body logdto.CreateLogRequest true "Log to create"
+// @Param X-Tenant header string false "Tenant to ingest for (platform admins only)"
+// @Param X-Log-Source header string false "Default source"
 // @Success 201 {object} logdto.CreateLogRequest
 // @Failure 400 {object} genricerror.ErrorResponse
+// @Failure 403 {object} genricerror.ErrorResponse "Tenant differs from the credential's, source is a reserved audit source, or role lacks the ingest permission"
 // @Failure 500 {object} genricerror.ErrorResponse
 // @Router /api/logs [post]
 func CreateLog(c *gin.Context) {
@@ -70,7 +87,16 @@ func CreateLog(c *gin.Context) {
 		return
 	}
 
-	createdLog, err := LogService.CreateLog(logDto)
+	o, err := requestOrigin(c)
+	if err == nil {
+		err = o.apply(&logDto)
+	}
+	if err != nil {
+		c.JSON(http.StatusForbidden, genricerror.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	createdLog, err := logService(c).CreateLog(logDto)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, genricerror.ErrorResponse{Message: err.Error()})
 		return
@@ -86,12 +112,14 @@ func CreateLog(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Security ApiKeyAuth
 // @Success 200 {array} logdto.CreateLogRequest
+// @Failure 403 {object} map[string]string "Role lacks the read permission"
 // @Failure 500 {object} genricerror.ErrorResponse
 // @Router /api/logs [get]
 func GetAllLogs(c *gin.Context) {
 
-	logs, err := LogService.GetAllLogs()
+	logs, err := logService(c).GetAllLogs()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, genricerror.ErrorResponse{Message: err.Error()})
 		return
@@ -106,9 +134,11 @@ func GetAllLogs(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param logId path int true "Log ID"
 // @Success 200 {object} logdto.CreateLogRequest
 // @Failure 400 {object} genricerror.ErrorResponse
+// @Failure 403 {object} map[string]string "Role lacks the read permission"
 // @Failure 404 {object} genricerror.ErrorResponse
 // @Failure 500 {object} genricerror.ErrorResponse
 // @Router /api/logs/{logId} [get]
@@ -120,7 +150,7 @@ func GetLogByID(c *gin.Context) {
 		return
 	}
 
-	logEntry, err := LogService.GetLogByID(logID)
+	logEntry, err := logService(c).GetLogByID(logID)
 	if err != nil {
 		if err.Error() == "log not found" {
 			c.JSON(http.StatusNotFound, genricerror.ErrorResponse{Message: "Log not found"})
@@ -141,6 +171,7 @@ func GetLogByID(c *gin.Context) {
 // @Security BearerAuth
 // @Param logId path int true "Log ID"
 // @Success 204 {object} nil "log deleted successfully"
+// @Failure 403 {object} map[string]string "Role lacks the delete permission"
 // @Failure 404 {object} map[string]string "log not found"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /api/logs/{logId} [delete]
@@ -151,7 +182,7 @@ func DeletelogByID(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, genricerror.ErrorResponse{Message: "Invalid log ID"})
 		return
 	}
-	err = LogService.DeleteLogByID(logID)
+	err = logService(c).DeleteLogByID(logID)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Log not found"})
@@ -160,28 +191,34 @@ func DeletelogByID(c *gin.Context) {
 		}
 		return
 	}
+	auditservice.RecordRequest(c, auditservice.ActionLogDeleted, "target", logIDStr)
 
 	c.Status(http.StatusNoContent)
 }
 
 // SearchLogs godoc
 // @Summary Search logs
-// @Description Retrieve logs based on time range, source, or user
+// @Description Retrieve logs based on time range, source system, IP address, tenant, user or attributes
 // @Tags Logs
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param start_time query string false "Start time (RFC3339)"
 // @Param end_time query string false "End time (RFC3339)"
-// @Param source query string false "Source IP address"
+// @Param source query string false "Source system"
+// @Param ip_address query string false "IP address"
+// @Param tenant query string false "Tenant"
 // @Param user_id query string false "User ID"
+// @Param attr.{key} query string false "Attribute filters: attr.key=value (repeat for any of several values), attr.key[prefix]=value, attr.key[exists]=true|false"
 // @Success 200 {array} logdto.CreateLogRequest
 // @Failure 400 {object} genricerror.ErrorResponse
+// @Failure 403 {object} map[string]string "Role lacks the read permission"
 // @Failure 500 {object} genricerror.ErrorResponse
 // @Router /api/logs/search [get]
 func SearchLogs(c *gin.Context) {
 	var startTime, endTime *time.Time
-	var source, userID *string
+	var source, ipAddress, tenant, userID *string
 
 	if start := c.Query("start_time"); start != "" {
 		t, err := time.Parse(time.RFC3339, start)
@@ -205,11 +242,25 @@ func SearchLogs(c *gin.Context) {
 		source = &src
 	}
 
+	if ip := c.Query("ip_address"); ip != "" {
+		ipAddress = &ip
+	}
+
+	if t := c.Query("tenant"); t != "" {
+		tenant = &t
+	}
+
 	if uid := c.Query("user_id"); uid != "" {
 		userID = &uid
 	}
 
-	logs, err := LogService.SearchLogs(startTime, endTime, source, userID)
+	attributes, err := attributeFilters(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, genricerror.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	logs, err := logService(c).SearchLogs(startTime, endTime, source, ipAddress, tenant, userID, attributes)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, genricerror.ErrorResponse{Message: err.Error()})
 		return
@@ -217,3 +268,43 @@ func SearchLogs(c *gin.Context) {
 
 	c.JSON(http.StatusOK, logs)
 }
+
+// attributeFilters reads the attr.<key>, attr.<key>[prefix] and attr.<key>[exists]
+// query parameters
+func attributeFilters(query url.Values) ([]logdto.AttributeFilter, error) {
+	var filters []logdto.AttributeFilter
+	for name, values := range query {
+		key, found := strings.CutPrefix(name, "attr.")
+		if !found {
+			continue
+		}
+		filter := logdto.AttributeFilter{Op: logdto.AttributeEquals, Values: values}
+		if open := strings.IndexByte(key, '['); open >= 0 && strings.HasSuffix(key, "]") {
+			key, filter.Op = key[:open], key[open+1:len(key)-1]
+		}
+		if !logDataentity.ValidKey(key) {
+			return nil, fmt.Errorf("invalid attribute filter %q", name)
+		}
+		filter.Key = key
+		switch filter.Op {
+		case logdto.AttributeEquals, logdto.AttributePrefix:
+		case logdto.AttributeExists:
+			present, err := strconv.ParseBool(values[len(values)-1])
+			if err != nil {
+				return nil, fmt.Errorf("%s must be true or false", name)
+			}
+			filter.Present = present
+		default:
+			return nil, fmt.Errorf("unknown attribute operator %q, use eq, prefix or exists", filter.Op)
+		}
+		filters = append(filters, filter)
+	}
+	// Map iteration order is random; a stable order keeps the generated SQL stable
+	sort.Slice(filters, func(i, j int) bool {
+		if filters[i].Key != filters[j].Key {
+			return filters[i].Key < filters[j].Key
+		}
+		return filters[i].Op < filters[j].Op
+	})
+	return filters, nil
+}
@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	eventformats "github.com/yatender-pareek/log-ingestor-service/src/event-formats"
+	genricerror "github.com/yatender-pareek/log-ingestor-service/src/genric_error"
+)
+
+// CreateLogsFromEvents godoc
+// @Summary Ingest CEF or LEEF events
+// @Description Accepts one ArcSight CEF or IBM LEEF event per line; a syslog header before CEF: or LEEF: is ignored.
+// @Description suser, src, act and fname (usrName, src, act and fname for LEEF) become the user, IP address, action and file name;
+// @Description all other extensions and the header fields are kept as attributes. Parse and validation failures are reported per line.
+// @Description mode works as for POST /logs/batch.
+// @Tags Logs
+// @Accept plain
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param events body string true "CEF or LEEF lines"
+// @Param mode query string false "atomic (default) or partial" Enums(atomic, partial)
+// @Param X-Tenant header string false "Tenant to ingest for (platform admins only)"
+// @Param X-Log-Source header string false "Source for events without a device vendor and product"
+// @Success 201 {object} logdto.CreateLogBatchResponse "Every event was stored"
+// @Success 207 {object} logdto.CreateLogBatchResponse "Some events were stored, some rejected"
+// @Failure 400 {object} logdto.CreateLogBatchResponse "No event was stored"
+// @Failure 403 {object} genricerror.ErrorResponse "X-Tenant differs from the credential's, X-Log-Source is a reserved audit source, or role lacks the ingest permission"
+// @Failure 413 {object} genricerror.ErrorResponse "Too many events"
+// @Failure 500 {object} genricerror.ErrorResponse
+// @Router /api/logs/events [post]
+func CreateLogsFromEvents(c *gin.Context) {
+	mode, ok := batchMode(c)
+	if !ok {
+		return
+	}
+	o, err := requestOrigin(c)
+	if err != nil {
+		c.JSON(http.StatusForbidden, genricerror.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	limit := maxBatchEntries()
+	now := time.Now()
+	var entries []batchEntry
+	scanner := bufio.NewScanner(c.Request.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if len(entries) == limit {
+			c.JSON(http.StatusRequestEntityTooLarge, genricerror.ErrorResponse{Message: fmt.Sprintf("a batch holds at most %d entries", limit)})
+			return
+		}
+		entry := batchEntry{line: lineNumber}
+		event, err := eventformats.ParseLine(line)
+		if err != nil {
+			entry.err = err
+		} else {
+			entry.log = eventformats.ToLog(event, now)
+			if entry.err = o.apply(&entry.log); entry.err == nil {
+				entry.err = validateLog(entry.log)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		c.JSON(http.StatusBadRequest, genricerror.ErrorResponse{Message: "invalid batch: " + err.Error()})
+		return
+	}
+	if len(entries) == 0 {
+		c.JSON(http.StatusBadRequest, genricerror.ErrorResponse{Message: "batch is empty"})
+		return
+	}
+	storeBatch(c, mode, entries)
+}
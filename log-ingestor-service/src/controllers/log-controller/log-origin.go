@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	auditservice "github.com/yatender-pareek/identity/src/services/audit-service"
+	"github.com/yatender-pareek/identity/src/tenancy"
+	logdto "github.com/yatender-pareek/log-ingestor-service/src/dtos/log-dto"
+	apikeyservice "github.com/yatender-pareek/log-ingestor-service/src/services/api-key-service"
+)
+
+var (
+	errTenantMismatch = errors.New("tenant does not match the tenant of the credential")
+	errReservedSource = fmt.Errorf("sources starting with %q are reserved for audit events", auditservice.SourcePrefix)
+)
+
+// origin is the tenant and source a request ingests logs under
+type origin struct {
+	tenant string
+	// fixed is set unless the caller is a platform admin, who may ingest for any tenant
+	fixed  bool
+	source string
+	// audit is set for API keys with the audit:write scope, the only callers that may use audit sources.
+	// Their audit events may name any tenant, so that every service stores them under the user's tenant.
+	audit bool
+}
+
+// requestOrigin takes the tenant from the credential and the source from the X-Log-Source
+// header. Platform admins may pick the tenant with X-Tenant; for anyone else it fails when
+// X-Tenant names another tenant than the credential. It also fails when X-Log-Source is an
+// audit source the caller may not use.
+func requestOrigin(c *gin.Context) (origin, error) {
+	caller := tenancy.CallerOf(c)
+	o := origin{
+		tenant: caller.Tenant,
+		fixed:  !caller.IsPlatformAdmin(),
+		source: c.GetHeader("X-Log-Source"),
+		audit:  apikeyservice.CanIngestAudit(caller),
+	}
+	if !o.audit && auditservice.IsAuditSource(o.source) {
+		return o, errReservedSource
+	}
+	if header := c.GetHeader("X-Tenant"); header != "" && header != o.tenant {
+		if o.fixed {
+			return o, errTenantMismatch
+		}
+		o.tenant = header
+	}
+	return o, nil
+}
+
+// apply fills in the tenant and source a log leaves empty, and rejects audit sources the caller may not use
+func (o origin) apply(logDto *logdto.CreateLogRequest) error {
+	if logDto.Source == "" {
+		logDto.Source = o.source
+	} else if !o.audit && auditservice.IsAuditSource(logDto.Source) {
+		return errReservedSource
+	}
+	if logDto.Tenant == "" {
+		logDto.Tenant = o.tenant
+	} else if o.fixed && logDto.Tenant != o.tenant && !(o.audit && auditservice.IsAuditSource(logDto.Source)) {
+		return errTenantMismatch
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"testing"
+
+	logdto "github.com/yatender-pareek/log-ingestor-service/src/dtos/log-dto"
+)
+
+func TestOriginApply(t *testing.T) {
+	tenantKey := origin{tenant: "acme", fixed: true, source: "fluent-bit"}
+	auditKey := origin{tenant: "default", fixed: true, audit: true}
+	admin := origin{tenant: "default"}
+	tests := []struct {
+		name       string
+		origin     origin
+		tenant     string
+		source     string
+		wantErr    error
+		wantTenant string
+		wantSource string
+	}{
+		{name: "fills in tenant and source", origin: tenantKey, wantTenant: "acme", wantSource: "fluent-bit"},
+		{name: "keeps the entry's source", origin: tenantKey, source: "nginx", wantTenant: "acme", wantSource: "nginx"},
+		{name: "own tenant", origin: tenantKey, tenant: "acme", wantTenant: "acme", wantSource: "fluent-bit"},
+		{name: "other tenant", origin: tenantKey, tenant: "globex", wantErr: errTenantMismatch},
+		{name: "audit source without audit:write", origin: tenantKey, source: "audit:log-ingestor-service", wantErr: errReservedSource},
+		{name: "audit source in another case", origin: tenantKey, source: " Audit:x", wantErr: errReservedSource},
+		{name: "audit source from a platform admin token", origin: admin, source: "audit:x", wantErr: errReservedSource},
+		{name: "platform admin names any tenant", origin: admin, tenant: "globex", source: "nginx", wantTenant: "globex", wantSource: "nginx"},
+		{
+			name:       "audit key stores audit events under the user's tenant",
+			origin:     auditKey,
+			tenant:     "acme",
+			source:     "audit:threat-analyzer-service",
+			wantTenant: "acme",
+			wantSource: "audit:threat-analyzer-service",
+		},
+		{
+			name:       "audit key defaults to its own tenant",
+			origin:     auditKey,
+			source:     "audit:threat-analyzer-service",
+			wantTenant: "default",
+			wantSource: "audit:threat-analyzer-service",
+		},
+		{name: "audit key cannot name another tenant for other sources", origin: auditKey, tenant: "acme", source: "nginx", wantErr: errTenantMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := logdto.CreateLogRequest{Tenant: tt.tenant, Source: tt.source}
+			err := tt.origin.apply(&entry)
+			if err != tt.wantErr {
+				t.Fatalf("apply() error %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (entry.Tenant != tt.wantTenant || entry.Source != tt.wantSource) {
+				t.Fatalf("tenant %q and source %q, want %q and %q", entry.Tenant, entry.Source, tt.wantTenant, tt.wantSource)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	logdto "github.com/yatender-pareek/log-ingestor-service/src/dtos/log-dto"
+	otlpreceiver "github.com/yatender-pareek/log-ingestor-service/src/otlp-receiver"
+	collectorlogs "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	"google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	otlpProtobuf = "application/x-protobuf"
+	otlpJSON     = "application/json"
+
+	maxOTLPBodyBytes = 16 << 20
+)
+
+// ExportOTLPLogs godoc
+// @Summary Receive OpenTelemetry logs (OTLP/HTTP)
+// @Description Accepts an OTLP ExportLogsServiceRequest encoded as protobuf (application/x-protobuf) or JSON (application/json),
+// @Description optionally gzip compressed, and answers in the same encoding. Attributes are mapped to log fields according to OTLP_MAPPING;
+// @Description the remaining record and resource attributes are kept as attributes, with the body, severity and scope as otel.body, otel.severity and otel.scope.
+// @Description Records that fail validation are reported through partialSuccess, the others are stored.
+// @Description Requests with more records than LOG_BATCH_MAX_ENTRIES (default 1000) are refused with 413.
+// @Tags Logs
+// @Accept application/x-protobuf
+// @Accept json
+// @Produce application/x-protobuf
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param request body object true "OTLP ExportLogsServiceRequest"
+// @Param X-Tenant header string false "Tenant to ingest for (platform admins only)"
+// @Param X-Log-Source header string false "Source for records without one"
+// @Success 200 {object} object "OTLP ExportLogsServiceResponse"
+// @Failure 400 {object} object "google.rpc.Status"
+// @Failure 403 {object} object "google.rpc.Status, or an error object when the role lacks the ingest permission"
+// @Failure 413 {object} object "google.rpc.Status"
+// @Failure 415 {object} object "google.rpc.Status"
+// @Failure 500 {object} object "google.rpc.Status"
+// @Router /api/v1/logs [post]
+func ExportOTLPLogs(c *gin.Context) {
+	contentType := c.ContentType()
+	if contentType != otlpProtobuf && contentType != otlpJSON {
+		respondOTLPError(c, otlpJSON, http.StatusUnsupportedMediaType, "content type must be application/x-protobuf or application/json")
+		return
+	}
+
+	o, err := requestOrigin(c)
+	if err != nil {
+		respondOTLPError(c, contentType, http.StatusForbidden, err.Error())
+		return
+	}
+
+	body, err := readOTLPBody(c)
+	if err != nil {
+		respondOTLPError(c, contentType, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(body) > maxOTLPBodyBytes {
+		respondOTLPError(c, contentType, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds %d bytes", maxOTLPBodyBytes))
+		return
+	}
+
+	request := &collectorlogs.ExportLogsServiceRequest{}
+	if contentType == otlpProtobuf {
+		err = proto.Unmarshal(body, request)
+	} else {
+		err = protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(body, request)
+	}
+	if err != nil {
+		respondOTLPError(c, contentType, http.StatusBadRequest, "invalid export request: "+err.Error())
+		return
+	}
+	if count, limit := otlpreceiver.RecordCount(request), maxBatchEntries(); count > limit {
+		respondOTLPError(c, contentType, http.StatusRequestEntityTooLarge, fmt.Sprintf("export request has %d log records, at most %d are accepted", count, limit))
+		return
+	}
+
+	var valid []logdto.CreateLogRequest
+	var rejected int64
+	var firstError string
+	for _, logDto := range otlpreceiver.Convert(request, otlpreceiver.Configured(), c.ClientIP(), time.Now()) {
+		err := o.apply(&logDto)
+		if err == nil {
+			err = validateLog(logDto)
+		}
+		if err != nil {
+			if rejected == 0 {
+				firstError = err.Error()
+			}
+			rejected++
+			continue
+		}
+		valid = append(valid, logDto)
+	}
+
+	if len(valid) > 0 {
+		if _, err := logService(c).CreateLogs(valid); err != nil {
+			respondOTLPError(c, contentType, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	response := &collectorlogs.ExportLogsServiceResponse{}
+	if rejected > 0 {
+		response.PartialSuccess = &collectorlogs.ExportLogsPartialSuccess{
+			RejectedLogRecords: rejected,
+			ErrorMessage:       fmt.Sprintf("%d log records rejected, first: %s", rejected, firstError),
+		}
+	}
+	respondOTLP(c, contentType, http.StatusOK, response)
+}
+
+// readOTLPBody reads the request body, decompressing it when Content-Encoding is gzip.
+// One byte past the limit is read so oversized bodies can be told apart.
+func readOTLPBody(c *gin.Context) ([]byte, error) {
+	var reader io.Reader = c.Request.Body
+	switch strings.ToLower(c.GetHeader("Content-Encoding")) {
+	case "", "identity":
+	case "gzip":
+		gz, err := gzip.NewReader(c.Request.Body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip body: %v", err)
+		}
+		defer gz.Close()
+		reader = gz
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %q", c.GetHeader("Content-Encoding"))
+	}
+	body, err := io.ReadAll(io.LimitReader(reader, maxOTLPBodyBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read body: %v", err)
+	}
+	return body, nil
+}
+
+// respondOTLP writes message in the encoding the request used
+func respondOTLP(c *gin.Context, contentType string, code int, message proto.Message) {
+	var data []byte
+	var err error
+	if contentType == otlpProtobuf {
+		data, err = proto.Marshal(message)
+	} else {
+		data, err = protojson.Marshal(message)
+	}
+	if err != nil {
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	c.Data(code, contentType, data)
+}
+
+// respondOTLPError answers with a google.rpc.Status, as the OTLP/HTTP specification requires
+func respondOTLPError(c *gin.Context, contentType string, code int, message string) {
+	respondOTLP(c, contentType, code, &status.Status{Message: message})
+}
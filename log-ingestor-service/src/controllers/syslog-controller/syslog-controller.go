@@ -0,0 +1,28 @@
+package syslogcontroller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	genricerror "github.com/yatender-pareek/log-ingestor-service/src/genric_error"
+	sysloglistener "github.com/yatender-pareek/log-ingestor-service/src/syslog-listener"
+)
+
+// GetSyslogStats godoc
+// @Summary Syslog ingestion counters
+// @Description Counts of syslog messages received, rejected, dropped and stored since startup, plus the current queue fill. Platform admins only.
+// @Tags Syslog
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} sysloglistener.Stats
+// @Failure 403 {object} map[string]string "Platform admin role required"
+// @Failure 404 {object} genricerror.ErrorResponse "Syslog ingestion is not enabled"
+// @Router /api/syslog/stats [get]
+func GetSyslogStats(c *gin.Context) {
+	stats, ok := sysloglistener.GetStats()
+	if !ok {
+		c.JSON(http.StatusNotFound, genricerror.ErrorResponse{Message: "syslog ingestion is not enabled"})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
@@ -0,0 +1,170 @@
+package tenantcontroller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	userentity "github.com/yatender-pareek/identity/src/models/user-model"
+	auditservice "github.com/yatender-pareek/identity/src/services/audit-service"
+	sessionservice "github.com/yatender-pareek/identity/src/services/session-service"
+	tenantservice "github.com/yatender-pareek/identity/src/services/tenant-service"
+	"github.com/yatender-pareek/identity/src/tenancy"
+	tenantdto "github.com/yatender-pareek/log-ingestor-service/src/dtos/tenant-dto"
+	genricerror "github.com/yatender-pareek/log-ingestor-service/src/genric_error"
+)
+
+var tenantService = tenantservice.NewTenantService()
+
+// GetTenants godoc
+// @Summary List tenants
+// @Description Lists every tenant. Platform admins only.
+// @Tags Tenants
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} tenantentity.Tenant
+// @Failure 403 {object} map[string]string "Platform admin role required"
+// @Failure 500 {object} genricerror.ErrorResponse
+// @Router /api/tenants [get]
+func GetTenants(c *gin.Context) {
+	tenants, err := tenantService.ListTenants()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, genricerror.ErrorResponse{Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, tenants)
+}
+
+// CreateTenant godoc
+// @Summary Create a tenant
+// @Description Creates an empty tenant; move users into it with PATCH /users/{username}. Platform admins only.
+// @Tags Tenants
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param tenant body tenantdto.CreateTenantRequest true "Tenant to create"
+// @Success 201 {object} tenantentity.Tenant
+// @Failure 400 {object} genricerror.ErrorResponse
+// @Failure 403 {object} map[string]string "Platform admin role required"
+// @Failure 409 {object} genricerror.ErrorResponse "Tenant already exists"
+// @Failure 500 {object} genricerror.ErrorResponse
+// @Router /api/tenants [post]
+func CreateTenant(c *gin.Context) {
+	var req tenantdto.CreateTenantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, genricerror.ErrorResponse{Message: err.Error()})
+		return
+	}
+	tenant, err := tenantService.CreateTenant(nil, req.Name)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, tenant)
+}
+
+// GetUsers godoc
+// @Summary List users
+// @Description Lists the users of the caller's tenant, or of every tenant for platform admins. Requires the manage_users permission.
+// @Tags Tenants
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} tenantdto.UserResponse
+// @Failure 403 {object} map[string]string "Role lacks the manage_users permission"
+// @Failure 500 {object} genricerror.ErrorResponse
+// @Router /api/users [get]
+func GetUsers(c *gin.Context) {
+	users, err := tenantServiceFor(c).ListUsers()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, genricerror.ErrorResponse{Message: err.Error()})
+		return
+	}
+	response := make([]tenantdto.UserResponse, len(users))
+	for i, user := range users {
+		response[i] = userResponse(user)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// UpdateUser godoc
+// @Summary Assign a role or move a user to another tenant
+// @Description Sets a user's role (admin, analyst, viewer, ingestor or platform_admin) and/or tenant. The change applies to tokens issued afterwards.
+// @Description Tenant admins may change the roles of their tenant's users; only platform admins may move users or grant or revoke platform_admin.
+// @Tags Tenants
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param username path string true "Username"
+// @Param user body tenantdto.UpdateUserRequest true "Fields to change"
+// @Success 200 {object} tenantdto.UserResponse
+// @Failure 400 {object} genricerror.ErrorResponse
+// @Failure 403 {object} genricerror.ErrorResponse "Change reserved to platform admins"
+// @Failure 404 {object} genricerror.ErrorResponse "User not found"
+// @Failure 500 {object} genricerror.ErrorResponse
+// @Router /api/users/{username} [patch]
+func UpdateUser(c *gin.Context) {
+	var req tenantdto.UpdateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, genricerror.ErrorResponse{Message: err.Error()})
+		return
+	}
+	user, err := tenantServiceFor(c).UpdateUser(c.Param("username"), req.Tenant, req.Role)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	auditservice.RecordRequest(c, auditservice.ActionUserUpdated, "target", user.Username, "role", user.Role, "userTenant", user.Tenant)
+	c.JSON(http.StatusOK, userResponse(user))
+}
+
+// RevokeUserSessions godoc
+// @Summary Revoke all sessions of a user
+// @Description Logs a user out everywhere: their refresh tokens stop working and their access tokens are rejected at once.
+// @Description Tenant admins may revoke the sessions of their tenant's users except platform admins.
+// @Tags Tenants
+// @Produce json
+// @Security BearerAuth
+// @Param username path string true "Username"
+// @Success 200 {object} tenantdto.RevokeSessionsResponse
+// @Failure 403 {object} genricerror.ErrorResponse "Role lacks the manage_users permission, or the user is a platform admin"
+// @Failure 404 {object} genricerror.ErrorResponse "User not found"
+// @Failure 500 {object} genricerror.ErrorResponse
+// @Router /api/users/{username}/sessions [delete]
+func RevokeUserSessions(c *gin.Context) {
+	revoked, err := sessionservice.NewSessionService().ForTenant(tenancy.CallerOf(c).ScopeTenant()).RevokeUserSessions(c.Param("username"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	auditservice.RecordRequest(c, auditservice.ActionSessionsRevoked, "target", c.Param("username"))
+	c.JSON(http.StatusOK, tenantdto.RevokeSessionsResponse{Revoked: revoked})
+}
+
+// tenantServiceFor limits user management to the caller's tenant
+func tenantServiceFor(c *gin.Context) *tenantservice.TenantService {
+	return tenantService.ForTenant(tenancy.CallerOf(c).ScopeTenant())
+}
+
+func userResponse(user userentity.User) tenantdto.UserResponse {
+	return tenantdto.UserResponse{
+		ID:       user.ID,
+		Username: user.Username,
+		Email:    user.Email,
+		Tenant:   user.Tenant,
+		Role:     user.Role,
+	}
+}
+
+func respondError(c *gin.Context, err error) {
+	switch err {
+	case tenantservice.ErrUnknownUser:
+		c.JSON(http.StatusNotFound, genricerror.ErrorResponse{Message: err.Error()})
+	case tenantservice.ErrPlatformAdminOnly:
+		c.JSON(http.StatusForbidden, genricerror.ErrorResponse{Message: err.Error()})
+	case tenantservice.ErrTenantExists:
+		c.JSON(http.StatusConflict, genricerror.ErrorResponse{Message: err.Error()})
+	case tenantservice.ErrUnknownTenant, tenantservice.ErrInvalidTenant, tenantservice.ErrInvalidUserRole:
+		c.JSON(http.StatusBadRequest, genricerror.ErrorResponse{Message: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, genricerror.ErrorResponse{Message: err.Error()})
+	}
+}
@@ -15,97 +15,57 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/login": {
-            "post": {
-                "description": "Authenticates a user and returns a JWT token using query parameters",
-                "consumes": [
-                    "application/json"
-                ],
+        "/.well-known/jwks.json": {
+            "get": {
+                "description": "Public keys of the token issuer (RFC 7517). Tokens name their key in the kid header; during a key rotation\nboth the new and the retiring keys are listed. Services verifying tokens fetch and cache this set.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Auth"
                 ],
-                "summary": "User login",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "User username",
-                        "name": "username",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "User password",
-                        "name": "password",
-                        "in": "query",
-                        "required": true
-                    }
-                ],
+                "summary": "JSON Web Key Set",
                 "responses": {
                     "200": {
-                        "description": "Login success with JWT token",
-                        "schema": {
-                            "$ref": "#/definitions/authdto.LoginResponseDTO"
-                        }
-                    },
-                    "400": {
-                        "description": "error: Invalid input",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
-                    "401": {
-                        "description": "error: Invalid credentials",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
-                    "500": {
-                        "description": "error: Server error",
+                        "description": "Signing keys",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/tokenservice.JWKS"
                         }
                     }
                 }
             }
         },
-        "/api/logs": {
+        "/api/api-keys": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get list of all logs",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Lists the API keys of the caller's tenant, or of every tenant for platform admins, including revoked ones and when each was last used.\nRequires the manage_api_keys permission.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Logs"
+                    "API keys"
                 ],
-                "summary": "Get all logs",
+                "summary": "List API keys",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/logdto.CreateLogRequest"
+                                "$ref": "#/definitions/apikeydto.APIKeyResponse"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Role lacks the manage_api_keys permission",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
                             }
                         }
                     },
@@ -123,7 +83,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Create a new log record with provided details",
+                "description": "Issues a long-lived key for a log shipper. The full key is only returned by this call; store it right away.\nSend it in the X-API-Key header (or as a Bearer token). Requires the manage_api_keys permission.\nOnly platform admins can create keys with the audit:write scope, which services use to ship their audit events.",
                 "consumes": [
                     "application/json"
                 ],
@@ -131,17 +91,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Logs"
+                    "API keys"
                 ],
-                "summary": "Create a new log",
+                "summary": "Create an API key",
                 "parameters": [
                     {
-                        "description": "Log to create",
-                        "name": "log",
+                        "description": "Key to create",
+                        "name": "key",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/logdto.CreateLogRequest"
+                            "$ref": "#/definitions/apikeydto.CreateAPIKeyRequest"
                         }
                     }
                 ],
@@ -149,7 +109,7 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/logdto.CreateLogRequest"
+                            "$ref": "#/definitions/apikeydto.IssuedAPIKeyResponse"
                         }
                     },
                     "400": {
@@ -158,6 +118,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/genricerror.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Role lacks the manage_api_keys permission, tenant differs from the caller's, or audit:write requested by someone other than a platform admin",
+                        "schema": {
+                            "$ref": "#/definitions/genricerror.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -167,58 +133,35 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/logs/search": {
-            "get": {
+        "/api/api-keys/{keyId}": {
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Retrieve logs based on time range, source, or user",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Disables the key for good; it stays listed with its revocation time. Requires the manage_api_keys permission.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Logs"
+                    "API keys"
                 ],
-                "summary": "Search logs",
+                "summary": "Revoke an API key",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Start time (RFC3339)",
-                        "name": "start_time",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "End time (RFC3339)",
-                        "name": "end_time",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Source IP address",
-                        "name": "source",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "User ID",
-                        "name": "user_id",
-                        "in": "query"
+                        "type": "integer",
+                        "description": "API key ID",
+                        "name": "keyId",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/logdto.CreateLogRequest"
-                            }
+                            "$ref": "#/definitions/apikeydto.APIKeyResponse"
                         }
                     },
                     "400": {
@@ -227,6 +170,21 @@ const docTemplate = `{
                             "$ref": "#/definitions/genricerror.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Role lacks the manage_api_keys permission",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "API key not found",
+                        "schema": {
+                            "$ref": "#/definitions/genricerror.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -236,29 +194,26 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/logs/{logId}": {
-            "get": {
+        "/api/api-keys/{keyId}/rotate": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Retrieve a log by its ID",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Replaces the key's secret and returns the new full key; the old one stops working immediately. Requires the manage_api_keys permission.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Logs"
+                    "API keys"
                 ],
-                "summary": "Get a specific log",
+                "summary": "Rotate an API key",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Log ID",
-                        "name": "logId",
+                        "description": "API key ID",
+                        "name": "keyId",
                         "in": "path",
                         "required": true
                     }
@@ -267,7 +222,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/logdto.CreateLogRequest"
+                            "$ref": "#/definitions/apikeydto.IssuedAPIKeyResponse"
                         }
                     },
                     "400": {
@@ -276,8 +231,23 @@ const docTemplate = `{
                             "$ref": "#/definitions/genricerror.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Role lacks the manage_api_keys permission, or the key has the audit:write scope and the caller is not a platform admin",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
                     "404": {
-                        "description": "Not Found",
+                        "description": "API key not found",
+                        "schema": {
+                            "$ref": "#/definitions/genricerror.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "API key has been revoked",
                         "schema": {
                             "$ref": "#/definitions/genricerror.ErrorResponse"
                         }
@@ -289,36 +259,70 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
+            }
+        },
+        "/api/login": {
+            "post": {
+                "description": "Authenticates a user using query parameters and opens a session: returns a short-lived JWT access token\nand a refresh token for POST /token/refresh. Users with MFA, or whose role requires it, instead get 202 with\na short-lived challenge token to exchange for the tokens at POST /login/mfa.\nRepeated failures lock out the username and the client address for a growing time (429 with Retry-After).",
+                "consumes": [
+                    "application/json"
                 ],
-                "description": "Deletes a log by its ID",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Logs"
+                    "Auth"
                 ],
-                "summary": "Delete a specific log",
+                "summary": "User login",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Log ID",
-                        "name": "logId",
-                        "in": "path",
+                        "type": "string",
+                        "description": "User username",
+                        "name": "username",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User password",
+                        "name": "password",
+                        "in": "query",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "log deleted successfully"
+                    "200": {
+                        "description": "Login success with JWT token",
+                        "schema": {
+                            "$ref": "#/definitions/authdto.LoginResponseDTO"
+                        }
                     },
-                    "404": {
-                        "description": "log not found",
+                    "202": {
+                        "description": "Password accepted; a second factor is required",
+                        "schema": {
+                            "$ref": "#/definitions/authdto.MFAChallengeResponseDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "error: Invalid input",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "error: Invalid credentials",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "error: Too many failed logins for the username or from the address",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -327,7 +331,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "Internal server error",
+                        "description": "error: Server error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -338,48 +342,33 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/register": {
-            "post": {
-                "description": "Creates a new user account with username, password, and email",
-                "consumes": [
-                    "application/json"
+        "/api/login-lockouts": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Lists the usernames with recent failed logins, with their failure count, lockout end and latest client address.\nTenant admins see their tenant's users; platform admins also see unknown usernames and client addresses.\nRequires the manage_users permission.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
-                ],
-                "summary": "Register a new user",
-                "parameters": [
-                    {
-                        "description": "User registration details",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/authdto.RegisterRequestDTO"
-                        }
-                    }
+                    "Tenants"
                 ],
+                "summary": "List failed logins and lockouts",
                 "responses": {
                     "200": {
-                        "description": "Registration success",
-                        "schema": {
-                            "$ref": "#/definitions/authdto.RegisterResponseDTO"
-                        }
-                    },
-                    "400": {
-                        "description": "error: Invalid input",
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/lockoutentity.LoginThrottle"
                             }
                         }
                     },
-                    "409": {
-                        "description": "error: Username or email already exists",
+                    "403": {
+                        "description": "error: Role lacks the manage_users permission",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -398,14 +387,2187 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "authdto.LoginResponseDTO": {
-            "type": "object",
-            "properties": {
+        },
+        "/api/login-lockouts/ips/{ip}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lifts the login lockout of a client address and forgets its failed logins. Platform admins only.",
+                "tags": [
+                    "Tenants"
+                ],
+                "summary": "Unlock a client address",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Client address",
+                        "name": "ip",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Unlocked"
+                    },
+                    "403": {
+                        "description": "error: Platform admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "error: No failed logins recorded",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: Server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/login/mfa": {
+            "post": {
+                "description": "Exchanges the challenge token of a password login and a code for the access and refresh tokens.\nA verify challenge accepts a TOTP code or an unused recovery code. An enroll challenge takes a TOTP code\nof the secret from POST /login/mfa/enroll, enables MFA and also returns the user's recovery codes.\nA challenge works once and fails after too many wrong codes; wrong codes also count towards the login lockout.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Second login step",
+                "parameters": [
+                    {
+                        "description": "Challenge token and code",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/authdto.MFALoginRequestDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Login success with JWT token",
+                        "schema": {
+                            "$ref": "#/definitions/authdto.LoginResponseDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "error: Invalid input, or enrollment not started",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "error: Invalid code, or challenge expired, used or failed too often",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: Server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/login/mfa/enroll": {
+            "post": {
+                "description": "For an enroll challenge: returns a new TOTP secret to add to an authenticator app. Complete the login with\none of the app's codes at POST /login/mfa. Calling it again replaces the secret.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Enroll in MFA during login",
+                "parameters": [
+                    {
+                        "description": "Challenge token",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/authdto.MFAEnrollChallengeRequestDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "TOTP secret",
+                        "schema": {
+                            "$ref": "#/definitions/mfadto.EnrollmentResponseDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "error: Invalid input",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "error: Challenge expired, used or failed too often",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "error: MFA is already enabled",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: Server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/logout": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revokes the presented access token and its session, so neither it nor the session's refresh token works any more",
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Log out",
+                "responses": {
+                    "204": {
+                        "description": "Logged out"
+                    },
+                    "400": {
+                        "description": "error: Not a login session",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "error: Invalid token",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: Server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/logs": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get list of all logs",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Logs"
+                ],
+                "summary": "Get all logs",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/logdto.CreateLogRequest"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Role lacks the read permission",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/genricerror.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create a new log record with provided details. The tenant is taken from the credential;\nonly platform admins may name another one in the log or the X-Tenant header. source defaults to the X-Log-Source header.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Logs"
+                ],
+                "summary": "Create a new log",
+                "parameters": [
+                    {
+                        "description": "Log to create",
+                        "name": "log",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/logdto.CreateLogRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tenant to ingest for (platform admins only)",
+                        "name": "X-Tenant",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Default source",
+                        "name": "X-Log-Source",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/logdto.CreateLogRequest"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/genricerror.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Tenant differs from the credential's, source is a reserved audit source, or role lacks the ingest permission",
+                        "schema": {
+                            "$ref": "#/definitions/genricerror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/genricerror.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/logs/batch": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Accepts a JSON array of logs, or one log per line with Content-Type application/x-ndjson.\nEvery entry is validated like POST /logs. In the default atomic mode nothing is stored unless every entry is valid;\nwith mode=partial the valid entries are stored and the invalid ones rejected. The response reports each entry by index.",
+                "consumes": [
+                    "application/json",
+                    "application/x-ndjson"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Logs"
+                ],
+                "summary": "Create logs in bulk",
+                "parameters": [
+                    {
+                        "description": "Logs to create",
+                        "name": "logs",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/logdto.CreateLogRequest"
+                            }
+                        }
+                    },
+                    {
+                        "enum": [
+                            "atomic",
+                            "partial"
+                        ],
+                        "type": "string",
+                        "description": "atomic (default) or partial",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tenant to ingest for (platform admins only)",
+                        "name": "X-Tenant",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Default source",
+                        "name": "X-Log-Source",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Every entry was stored",
+                        "schema": {
+                            "$ref": "#/definitions/logdto.CreateLogBatchResponse"
+                        }
+                    },
+                    "207": {
+                        "description": "Some entries were stored, some rejected",
+                        "schema": {
+                            "$ref": "#/definitions/logdto.CreateLogBatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "No entry was stored",
+                        "schema": {
+                            "$ref": "#/definitions/logdto.CreateLogBatchResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "X-Tenant differs from the credential's, X-Log-Source is a reserved audit source, or role lacks the ingest permission",
+                        "schema": {
+                            "$ref": "#/definitions/genricerror.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Too many entries",
+                        "schema": {
+                            "$ref": "#/definitions/genricerror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/genricerror.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/logs/events": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Accepts one ArcSight CEF or IBM LEEF event per line; a syslog header before CEF: or LEEF: is ignored.\nsuser, src, act and fname (usrName, src, act and fname for LEEF) become the user, IP address, action and file name;\nall other extensions and the header fields are kept as attributes. Parse and validation failures are reported per line.\nmode works as for POST /logs/batch.",
+                "consumes": [
+                    "text/plain"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Logs"
+                ],
+                "summary": "Ingest CEF or LEEF events",
+                "parameters": [
+                    {
+                        "description": "CEF or LEEF lines",
+                        "name": "events",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "enum": [
+                            "atomic",
+                            "partial"
+                        ],
+                        "type": "string",
+                        "description": "atomic (default) or partial",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tenant to ingest for (platform admins only)",
+                        "name": "X-Tenant",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Source for events without a device vendor and product",
+                        "name": "X-Log-Source",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Every event was stored",
+                        "schema": {
+                            "$ref": "#/definitions/logdto.CreateLogBatchResponse"
+                        }
+                    },
+                    "207": {
+                        "description": "Some events were stored, some rejected",
+                        "schema": {
+                            "$ref": "#/definitions/logdto.CreateLogBatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "No event was stored",
+                        "schema": {
+                            "$ref": "#/definitions/logdto.CreateLogBatchResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "X-Tenant differs from the credential's, X-Log-Source is a reserved audit source, or role lacks the ingest permission",
+                        "schema": {
+                            "$ref": "#/definitions/genricerror.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Too many events",
+                        "schema": {
+                            "$ref": "#/definitions/genricerror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/genricerror.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/logs/search": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieve logs based on time range, source system, IP address, tenant, user or attributes",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Logs"
+                ],
+                "summary": "Search logs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start time (RFC3339)",
+                        "name": "start_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time (RFC3339)",
+                        "name": "end_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Source system",
+                        "name": "source",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "IP address",
+                        "name": "ip_address",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tenant",
+                        "name": "tenant",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Attribute filters: attr.key=value (repeat for any of several values), attr.key[prefix]=value, attr.key[exists]=true|false",
+                        "name": "attr.{key}",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/logdto.CreateLogRequest"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/genricerror.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Role lacks the read permission",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/genricerror.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/logs/{logId}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieve a log by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Logs"
+                ],
+                "summary": "Get a specific log",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Log ID",
+                        "name": "logId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/logdto.CreateLogRequest"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/genricerror.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Role lacks the read permission",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/genricerror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/genricerror.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Deletes a log by its ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Logs"
+                ],
+                "summary": "Delete a specific log",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Log ID",
+                        "name": "logId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "log deleted successfully"
+                    },
+                    "403": {
+                        "description": "Role lacks the delete permission",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "log not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/mfa": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Reports whether the caller has MFA enabled, whether their role requires it, and how many recovery codes remain",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "MFA"
+                ],
+                "summary": "MFA status",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/mfadto.StatusResponseDTO"
+                        }
+                    },
+                    "403": {
+                        "description": "error: Not a user account",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: Server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/mfa/policy": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists the roles of the caller's tenant that must use MFA. Platform admins may name another tenant.\nRequires the manage_users permission.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "MFA"
+                ],
+                "summary": "MFA policy",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tenant (platform admins only)",
+                        "name": "tenant",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/mfadto.PolicyResponseDTO"
+                        }
+                    },
+                    "403": {
+                        "description": "error: Role lacks the manage_users permission",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: Server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Sets the roles of the caller's tenant that must use MFA, replacing the previous list. Their users without MFA\nenroll at their next password login. Platform admins may name another tenant; only they may require MFA\nfor platform_admin. Requires the manage_users permission.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "MFA"
+                ],
+                "summary": "Require MFA per role",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tenant (platform admins only)",
+                        "name": "tenant",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Roles requiring MFA",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/mfadto.PolicyRequestDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/mfadto.PolicyResponseDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "error: Invalid input or role",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "error: Role lacks the manage_users permission, or change reserved to platform admins",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "error: Tenant not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: Server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/mfa/recovery-codes": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Invalidates the caller's recovery codes and returns new ones after checking a TOTP or recovery code",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "MFA"
+                ],
+                "summary": "Replace recovery codes",
+                "parameters": [
+                    {
+                        "description": "TOTP or recovery code",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/mfadto.CodeRequestDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/mfadto.RecoveryCodesResponseDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "error: Invalid input, or MFA not enabled",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "error: Invalid code",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: Server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/mfa/totp": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns a new TOTP secret for the caller to add to an authenticator app; MFA is enabled once\nPOST /mfa/totp/confirm accepts one of the app's codes. Calling it again replaces an unconfirmed secret.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "MFA"
+                ],
+                "summary": "Start TOTP enrollment",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/mfadto.EnrollmentResponseDTO"
+                        }
+                    },
+                    "403": {
+                        "description": "error: Not a user account",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "error: MFA is already enabled",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: Server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Removes the caller's authenticator and recovery codes after checking a TOTP or recovery code.\nRefused when the caller's role requires MFA.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "MFA"
+                ],
+                "summary": "Disable MFA",
+                "parameters": [
+                    {
+                        "description": "TOTP or recovery code",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/mfadto.CodeRequestDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "MFA disabled"
+                    },
+                    "400": {
+                        "description": "error: Invalid input, or MFA not enabled",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "error: Invalid code",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "error: Role requires MFA",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: Server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/mfa/totp/confirm": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Enables MFA once the code shows the authenticator app works, and returns the caller's recovery codes.\nThey are shown only once; each replaces a TOTP code one time.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "MFA"
+                ],
+                "summary": "Confirm TOTP enrollment",
+                "parameters": [
+                    {
+                        "description": "TOTP code",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/mfadto.CodeRequestDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/mfadto.RecoveryCodesResponseDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "error: Invalid input, or enrollment not started",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "error: Invalid code",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "error: MFA is already enabled",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: Server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/oidc/callback": {
+            "get": {
+                "description": "Redirect target of the identity provider. Validates the ID token, links the provider account to a local user\n(provisioning one on first login), sets the user's role from their provider groups and opens a session.\nUsers with MFA, or whose role requires it, instead get 202 with a challenge token for POST /login/mfa, as with Login.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Single sign-on callback",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization code",
+                        "name": "code",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "State of the login",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Login success with JWT token",
+                        "schema": {
+                            "$ref": "#/definitions/authdto.LoginResponseDTO"
+                        }
+                    },
+                    "202": {
+                        "description": "Provider login accepted; a second factor is required",
+                        "schema": {
+                            "$ref": "#/definitions/authdto.MFAChallengeResponseDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "error: Login expired, already completed or started in another browser",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "error: Provider refused the login or sent an invalid ID token",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "error: No group grants a role, or the linked account was removed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: Server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "502": {
+                        "description": "error: Identity provider unreachable or misconfigured",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/oidc/login": {
+            "get": {
+                "description": "Redirects the browser to the OpenID Connect provider (authorization code flow with PKCE).\nAfter signing in there, the provider sends the browser back to GET /oidc/callback.",
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Single sign-on login",
+                "responses": {
+                    "302": {
+                        "description": "Redirect to the identity provider"
+                    },
+                    "502": {
+                        "description": "error: Identity provider unreachable or misconfigured",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/register": {
+            "post": {
+                "description": "Creates a new user account with username, password, and email. Without a tenant the user joins the default tenant;\nnaming a tenant creates it with the user as its first member and admin, so it must not exist yet.\nUsers joining the default tenant get the viewer role until an admin assigns another.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Register a new user",
+                "parameters": [
+                    {
+                        "description": "User registration details",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/authdto.RegisterRequestDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Registration success",
+                        "schema": {
+                            "$ref": "#/definitions/authdto.RegisterResponseDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "error: Invalid input",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "error: Username, email or tenant already exists",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: Server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/syslog/stats": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Counts of syslog messages received, rejected, dropped and stored since startup, plus the current queue fill. Platform admins only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Syslog"
+                ],
+                "summary": "Syslog ingestion counters",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/sysloglistener.Stats"
+                        }
+                    },
+                    "403": {
+                        "description": "Platform admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Syslog ingestion is not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/genricerror.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/tenants": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists every tenant. Platform admins only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tenants"
+                ],
+                "summary": "List tenants",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/tenantentity.Tenant"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Platform admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/genricerror.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Creates an empty tenant; move users into it with PATCH /users/{username}. Platform admins only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tenants"
+                ],
+                "summary": "Create a tenant",
+                "parameters": [
+                    {
+                        "description": "Tenant to create",
+                        "name": "tenant",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/tenantdto.CreateTenantRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/tenantentity.Tenant"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/genricerror.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Platform admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Tenant already exists",
+                        "schema": {
+                            "$ref": "#/definitions/genricerror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/genricerror.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/token/refresh": {
+            "post": {
+                "description": "Exchanges a refresh token for a new access token and refresh token; the presented refresh token stops working.\nThe new access token carries the user's current tenant and role. Reusing a refresh token revokes its session.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Refresh an access token",
+                "parameters": [
+                    {
+                        "description": "Refresh token",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/authdto.RefreshRequestDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "New token pair",
+                        "schema": {
+                            "$ref": "#/definitions/authdto.LoginResponseDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "error: Invalid input",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "error: Invalid, expired, revoked or reused refresh token",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: Server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists the users of the caller's tenant, or of every tenant for platform admins. Requires the manage_users permission.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tenants"
+                ],
+                "summary": "List users",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/tenantdto.UserResponse"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Role lacks the manage_users permission",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/genricerror.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{username}": {
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Sets a user's role (admin, analyst, viewer, ingestor or platform_admin) and/or tenant. The change applies to tokens issued afterwards.\nTenant admins may change the roles of their tenant's users; only platform admins may move users or grant or revoke platform_admin.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tenants"
+                ],
+                "summary": "Assign a role or move a user to another tenant",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/tenantdto.UpdateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/tenantdto.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/genricerror.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Change reserved to platform admins",
+                        "schema": {
+                            "$ref": "#/definitions/genricerror.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/genricerror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/genricerror.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{username}/lockout": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lifts the login lockout of a user and forgets their failed logins. Their client address stays locked\nif it is locked itself. Tenant admins may unlock their tenant's users except platform admins.",
+                "tags": [
+                    "Tenants"
+                ],
+                "summary": "Unlock a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Unlocked"
+                    },
+                    "403": {
+                        "description": "error: Role lacks the manage_users permission, or the user is a platform admin",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "error: User not found, or no failed logins recorded",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: Server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{username}/mfa": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Removes the authenticator and recovery codes of a user who lost them, so they can log in with their password\nand enroll again. Tenant admins may reset their tenant's users except platform admins.",
+                "tags": [
+                    "MFA"
+                ],
+                "summary": "Reset a user's MFA",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "MFA reset"
+                    },
+                    "400": {
+                        "description": "error: MFA not enabled",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "error: Role lacks the manage_users permission, or the user is a platform admin",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "error: User not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: Server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{username}/sessions": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Logs a user out everywhere: their refresh tokens stop working and their access tokens are rejected at once.\nTenant admins may revoke the sessions of their tenant's users except platform admins.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tenants"
+                ],
+                "summary": "Revoke all sessions of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/tenantdto.RevokeSessionsResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Role lacks the manage_users permission, or the user is a platform admin",
+                        "schema": {
+                            "$ref": "#/definitions/genricerror.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/genricerror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/genricerror.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/logs": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Accepts an OTLP ExportLogsServiceRequest encoded as protobuf (application/x-protobuf) or JSON (application/json),\noptionally gzip compressed, and answers in the same encoding. Attributes are mapped to log fields according to OTLP_MAPPING;\nthe remaining record and resource attributes are kept as attributes, with the body, severity and scope as otel.body, otel.severity and otel.scope.\nRecords that fail validation are reported through partialSuccess, the others are stored.\nRequests with more records than LOG_BATCH_MAX_ENTRIES (default 1000) are refused with 413.",
+                "consumes": [
+                    "application/x-protobuf",
+                    "application/json"
+                ],
+                "produces": [
+                    "application/x-protobuf",
+                    "application/json"
+                ],
+                "tags": [
+                    "Logs"
+                ],
+                "summary": "Receive OpenTelemetry logs (OTLP/HTTP)",
+                "parameters": [
+                    {
+                        "description": "OTLP ExportLogsServiceRequest",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tenant to ingest for (platform admins only)",
+                        "name": "X-Tenant",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Source for records without one",
+                        "name": "X-Log-Source",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OTLP ExportLogsServiceResponse",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "400": {
+                        "description": "google.rpc.Status",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "403": {
+                        "description": "google.rpc.Status, or an error object when the role lacks the ingest permission",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "413": {
+                        "description": "google.rpc.Status",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "415": {
+                        "description": "google.rpc.Status",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "google.rpc.Status",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "apikeydto.APIKeyResponse": {
+            "type": "object",
+            "properties": {
+                "allowedCidrs": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "createdBy": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lastUsedAt": {
+                    "type": "string"
+                },
+                "lastUsedIp": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "revokedAt": {
+                    "type": "string"
+                },
+                "rotatedAt": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "tenant": {
+                    "type": "string"
+                }
+            }
+        },
+        "apikeydto.CreateAPIKeyRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "allowedCidrs": {
+                    "description": "AllowedCIDRs limits the addresses the key may be used from; empty allows any",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "10.0.0.0/8"
+                    ]
+                },
+                "expiresAt": {
+                    "type": "string",
+                    "example": "2027-01-01T00:00:00Z"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "fluent-bit on web-01"
+                },
+                "scopes": {
+                    "description": "Scopes are any of logs:write, logs:read and audit:write; only platform admins may ask for audit:write",
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "logs:write"
+                    ]
+                },
+                "tenant": {
+                    "description": "Tenant the key ingests for; only platform admins may name a tenant other than their own",
+                    "type": "string",
+                    "example": "payments"
+                }
+            }
+        },
+        "apikeydto.IssuedAPIKeyResponse": {
+            "type": "object",
+            "properties": {
+                "allowedCidrs": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "createdBy": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string",
+                    "example": "lis_3f9a1c0b7d2e_..."
+                },
+                "lastUsedAt": {
+                    "type": "string"
+                },
+                "lastUsedIp": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "revokedAt": {
+                    "type": "string"
+                },
+                "rotatedAt": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "tenant": {
+                    "type": "string"
+                }
+            }
+        },
+        "authdto.LoginResponseDTO": {
+            "type": "object",
+            "properties": {
+                "expiresIn": {
+                    "description": "ExpiresIn is the lifetime of Token in seconds",
+                    "type": "integer",
+                    "example": 900
+                },
+                "recoveryCodes": {
+                    "description": "RecoveryCodes are returned once, when a login enrolled the user in MFA",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "refreshToken": {
+                    "description": "RefreshToken obtains a new pair from POST /token/refresh; each refresh token works once",
+                    "type": "string"
+                },
                 "token": {
                     "type": "string"
+                },
+                "tokenType": {
+                    "type": "string",
+                    "example": "Bearer"
+                }
+            }
+        },
+        "authdto.MFAChallengeResponseDTO": {
+            "type": "object",
+            "properties": {
+                "challengeToken": {
+                    "description": "ChallengeToken is exchanged with a code at POST /login/mfa",
+                    "type": "string"
+                },
+                "expiresIn": {
+                    "description": "ExpiresIn is the lifetime of ChallengeToken in seconds",
+                    "type": "integer",
+                    "example": 300
+                },
+                "purpose": {
+                    "description": "Purpose is \"verify\" to enter a code of the user's authenticator, or \"enroll\" when their role\nrequires MFA they have not set up: POST /login/mfa/enroll first returns the secret to add",
+                    "type": "string",
+                    "example": "verify"
+                }
+            }
+        },
+        "authdto.MFAEnrollChallengeRequestDTO": {
+            "type": "object",
+            "required": [
+                "challengeToken"
+            ],
+            "properties": {
+                "challengeToken": {
+                    "type": "string"
+                }
+            }
+        },
+        "authdto.MFALoginRequestDTO": {
+            "type": "object",
+            "required": [
+                "challengeToken",
+                "code"
+            ],
+            "properties": {
+                "challengeToken": {
+                    "type": "string"
+                },
+                "code": {
+                    "description": "Code is a TOTP code or, for a verify challenge, a recovery code",
+                    "type": "string",
+                    "maxLength": 32
+                }
+            }
+        },
+        "authdto.RefreshRequestDTO": {
+            "type": "object",
+            "required": [
+                "refreshToken"
+            ],
+            "properties": {
+                "refreshToken": {
+                    "type": "string"
                 }
             }
         },
@@ -427,6 +2589,11 @@ const docTemplate = `{
                     "maxLength": 100,
                     "minLength": 8
                 },
+                "tenant": {
+                    "description": "Tenant is a new tenant to create with the user as its first member; empty joins \"default\"",
+                    "type": "string",
+                    "maxLength": 64
+                },
                 "username": {
                     "type": "string",
                     "maxLength": 50,
@@ -450,6 +2617,51 @@ const docTemplate = `{
                 }
             }
         },
+        "lockoutentity.LoginThrottle": {
+            "type": "object",
+            "properties": {
+                "failures": {
+                    "description": "Failures counts failed logins since the last success, forgetting them after a quiet period",
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "lastFailureAt": {
+                    "type": "string"
+                },
+                "lastFailureIp": {
+                    "description": "LastFailureIP is the client address of the latest failure",
+                    "type": "string"
+                },
+                "lockedUntil": {
+                    "type": "string"
+                },
+                "subject": {
+                    "type": "string"
+                }
+            }
+        },
+        "logdto.CreateLogBatchResponse": {
+            "type": "object",
+            "properties": {
+                "accepted": {
+                    "type": "integer"
+                },
+                "rejected": {
+                    "type": "integer"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/logdto.LogBatchItemResult"
+                    }
+                }
+            }
+        },
         "logdto.CreateLogRequest": {
             "type": "object",
             "required": [
@@ -459,6 +2671,13 @@ const docTemplate = `{
                 "action": {
                     "type": "string"
                 },
+                "attributes": {
+                    "description": "Attributes carries any further event fields, e.g. {\"userAgent\": \"curl/8.5\", \"httpStatus\": 403}.\nNumbers and booleans are stored as text, nested objects and arrays as JSON.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
                 "databaseQuery": {
                     "type": "string"
                 },
@@ -468,6 +2687,16 @@ const docTemplate = `{
                 "ipaddress": {
                     "type": "string"
                 },
+                "source": {
+                    "description": "Source names the emitting system; it defaults to the X-Log-Source header",
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "tenant": {
+                    "description": "Tenant defaults to the tenant of the credential, then to the X-Tenant header",
+                    "type": "string",
+                    "maxLength": 64
+                },
                 "timestamp": {
                     "type": "string"
                 },
@@ -475,9 +2704,261 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "logdto.LogBatchItemResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "line": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "accepted"
+                }
+            }
+        },
+        "mfadto.CodeRequestDTO": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "description": "Code is a TOTP code or, where a recovery code is accepted, a recovery code",
+                    "type": "string",
+                    "maxLength": 32
+                }
+            }
+        },
+        "mfadto.EnrollmentResponseDTO": {
+            "type": "object",
+            "properties": {
+                "otpauthUri": {
+                    "description": "OtpauthURI is the secret as a key URI, usually shown as a QR code",
+                    "type": "string"
+                },
+                "secret": {
+                    "type": "string",
+                    "example": "JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"
+                }
+            }
+        },
+        "mfadto.PolicyRequestDTO": {
+            "type": "object",
+            "required": [
+                "roles"
+            ],
+            "properties": {
+                "roles": {
+                    "type": "array",
+                    "maxItems": 8,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "mfadto.PolicyResponseDTO": {
+            "type": "object",
+            "properties": {
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "tenant": {
+                    "type": "string"
+                }
+            }
+        },
+        "mfadto.RecoveryCodesResponseDTO": {
+            "type": "object",
+            "properties": {
+                "recoveryCodes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "mfadto.StatusResponseDTO": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "recoveryCodesLeft": {
+                    "type": "integer"
+                },
+                "required": {
+                    "description": "Required is set when the caller's role must use MFA",
+                    "type": "boolean"
+                }
+            }
+        },
+        "sysloglistener.Stats": {
+            "type": "object",
+            "properties": {
+                "dropped": {
+                    "type": "integer"
+                },
+                "invalid": {
+                    "type": "integer"
+                },
+                "parseErrors": {
+                    "type": "integer"
+                },
+                "queueCapacity": {
+                    "type": "integer"
+                },
+                "queueLength": {
+                    "type": "integer"
+                },
+                "received": {
+                    "type": "integer"
+                },
+                "storeErrors": {
+                    "type": "integer"
+                },
+                "stored": {
+                    "type": "integer"
+                }
+            }
+        },
+        "tenantdto.CreateTenantRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "payments"
+                }
+            }
+        },
+        "tenantdto.RevokeSessionsResponse": {
+            "type": "object",
+            "properties": {
+                "revoked": {
+                    "type": "integer"
+                }
+            }
+        },
+        "tenantdto.UpdateUserRequest": {
+            "type": "object",
+            "properties": {
+                "role": {
+                    "type": "string",
+                    "example": "analyst"
+                },
+                "tenant": {
+                    "type": "string",
+                    "example": "payments"
+                }
+            }
+        },
+        "tenantdto.UserResponse": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "tenant": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "tenantentity.Tenant": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "tokenservice.JWK": {
+            "type": "object",
+            "properties": {
+                "alg": {
+                    "type": "string",
+                    "example": "ES256"
+                },
+                "crv": {
+                    "type": "string",
+                    "example": "P-256"
+                },
+                "e": {
+                    "type": "string"
+                },
+                "kid": {
+                    "type": "string",
+                    "example": "0ZcOCORZNYy-DWpqq30jZyJGHTN0d2HglBV3uiguA4I"
+                },
+                "kty": {
+                    "type": "string",
+                    "example": "EC"
+                },
+                "n": {
+                    "type": "string"
+                },
+                "use": {
+                    "type": "string",
+                    "example": "sig"
+                },
+                "x": {
+                    "type": "string"
+                },
+                "y": {
+                    "type": "string"
+                }
+            }
+        },
+        "tokenservice.JWKS": {
+            "type": "object",
+            "properties": {
+                "keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/tokenservice.JWK"
+                    }
+                }
+            }
         }
     },
     "securityDefinitions": {
+        "ApiKeyAuth": {
+            "description": "API key created with POST /api/api-keys",
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        },
         "BearerAuth": {
             "description": "Type \"Bearer\" followed by a space and JWT token",
             "type": "apiKey",
@@ -0,0 +1,61 @@
+package apikeydto
+
+import (
+	"time"
+
+	apikeyentity "github.com/yatender-pareek/log-ingestor-service/src/models/api-key-model"
+)
+
+// CreateAPIKeyRequest describes a new API key
+type CreateAPIKeyRequest struct {
+	Name string `json:"name" binding:"required,max=100" example:"fluent-bit on web-01"`
+	// Scopes are any of logs:write, logs:read and audit:write; only platform admins may ask for audit:write
+	Scopes []string `json:"scopes" binding:"required,min=1" example:"logs:write"`
+	// AllowedCIDRs limits the addresses the key may be used from; empty allows any
+	AllowedCIDRs []string   `json:"allowedCidrs" example:"10.0.0.0/8"`
+	ExpiresAt    *time.Time `json:"expiresAt" example:"2027-01-01T00:00:00Z"`
+	// Tenant the key ingests for; only platform admins may name a tenant other than their own
+	Tenant string `json:"tenant" example:"payments"`
+}
+
+// APIKeyResponse is an API key without its secret
+type APIKeyResponse struct {
+	ID           uint       `json:"id"`
+	Name         string     `json:"name"`
+	Prefix       string     `json:"prefix"`
+	Tenant       string     `json:"tenant"`
+	Scopes       []string   `json:"scopes"`
+	AllowedCIDRs []string   `json:"allowedCidrs"`
+	CreatedBy    string     `json:"createdBy"`
+	ExpiresAt    *time.Time `json:"expiresAt"`
+	LastUsedAt   *time.Time `json:"lastUsedAt"`
+	LastUsedIP   string     `json:"lastUsedIp"`
+	RotatedAt    *time.Time `json:"rotatedAt"`
+	RevokedAt    *time.Time `json:"revokedAt"`
+	CreatedAt    time.Time  `json:"createdAt"`
+}
+
+// IssuedAPIKeyResponse carries the full key, which is only ever shown once
+type IssuedAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key" example:"lis_3f9a1c0b7d2e_..."`
+}
+
+// NewAPIKeyResponse converts a stored key
+func NewAPIKeyResponse(key apikeyentity.APIKey) APIKeyResponse {
+	return APIKeyResponse{
+		ID:           key.ID,
+		Name:         key.Name,
+		Prefix:       key.Prefix,
+		Tenant:       key.Tenant,
+		Scopes:       key.ScopeList(),
+		AllowedCIDRs: key.CIDRList(),
+		CreatedBy:    key.CreatedBy,
+		ExpiresAt:    key.ExpiresAt,
+		LastUsedAt:   key.LastUsedAt,
+		LastUsedIP:   key.LastUsedIP,
+		RotatedAt:    key.RotatedAt,
+		RevokedAt:    key.RevokedAt,
+		CreatedAt:    key.CreatedAt,
+	}
+}
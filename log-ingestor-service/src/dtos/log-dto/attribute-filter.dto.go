@@ -0,0 +1,17 @@
+package logdto
+
+// Attribute filter operators accepted by SearchLogs
+const (
+	AttributeEquals = "eq"
+	AttributePrefix = "prefix"
+	AttributeExists = "exists"
+)
+
+// AttributeFilter restricts a log search on one attribute. For eq and prefix the
+// attribute must match any of Values; for exists, Present selects logs with or without it.
+type AttributeFilter struct {
+	Key     string
+	Op      string
+	Values  []string
+	Present bool
+}
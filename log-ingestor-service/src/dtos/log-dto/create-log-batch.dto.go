@@ -0,0 +1,22 @@
+package logdto
+
+const (
+	BatchItemAccepted = "accepted"
+	BatchItemRejected = "rejected"
+)
+
+// LogBatchItemResult reports what happened to one entry of a batch, by position.
+// Line is the entry's line number in line-oriented payloads.
+type LogBatchItemResult struct {
+	Index  int     `json:"index"`
+	Line   int     `json:"line,omitempty"`
+	Status string  `json:"status" example:"accepted"`
+	ID     *uint64 `json:"id,omitempty"`
+	Error  string  `json:"error,omitempty"`
+}
+
+type CreateLogBatchResponse struct {
+	Accepted int                  `json:"accepted"`
+	Rejected int                  `json:"rejected"`
+	Results  []LogBatchItemResult `json:"results"`
+}
@@ -1,6 +1,10 @@
 package logdto
 
-import "time"
+import (
+	"time"
+
+	logDataentity "github.com/yatender-pareek/log-ingestor-service/src/models/log-data-model"
+)
 
 type CreateLogRequest struct {
 	Timestamp     time.Time `json:"timestamp" validate:"required"`
@@ -9,4 +13,11 @@ type CreateLogRequest struct {
 	Action        string
 	FileName      *string `json:"fileName"`
 	DatabaseQuery *string `json:"databaseQuery"`
+	// Attributes carries any further event fields, e.g. {"userAgent": "curl/8.5", "httpStatus": 403}.
+	// Numbers and booleans are stored as text, nested objects and arrays as JSON.
+	Attributes logDataentity.Attributes `json:"attributes,omitempty" swaggertype:"object,string"`
+	// Source names the emitting system; it defaults to the X-Log-Source header
+	Source string `json:"source" validate:"max=255"`
+	// Tenant defaults to the tenant of the credential, then to the X-Tenant header
+	Tenant string `json:"tenant" validate:"max=64"`
 }
@@ -0,0 +1,26 @@
+package tenantdto
+
+// CreateTenantRequest names a new tenant
+type CreateTenantRequest struct {
+	Name string `json:"name" binding:"required" example:"payments"`
+}
+
+// UpdateUserRequest moves a user to another tenant and/or changes their role
+type UpdateUserRequest struct {
+	Tenant *string `json:"tenant" example:"payments"`
+	Role   *string `json:"role" example:"analyst"`
+}
+
+// UserResponse is a user without their password hash
+type UserResponse struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Tenant   string `json:"tenant"`
+	Role     string `json:"role"`
+}
+
+// RevokeSessionsResponse tells how many open sessions were revoked
+type RevokeSessionsResponse struct {
+	Revoked int64 `json:"revoked"`
+}
@@ -0,0 +1,149 @@
+// Package eventformats parses ArcSight CEF and IBM LEEF event lines and maps them onto logs
+package eventformats
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	FormatCEF  = "cef"
+	FormatLEEF = "leef"
+)
+
+// Event is a parsed CEF or LEEF line: its header fields by name and its extension key-values
+type Event struct {
+	Format     string
+	Header     map[string]string
+	Extensions map[string]string
+}
+
+var cefHeaderFields = []string{"version", "deviceVendor", "deviceProduct", "deviceVersion", "signatureId", "name", "severity"}
+
+// ParseLine parses a CEF or LEEF line. A syslog header before "CEF:" or "LEEF:" is ignored;
+// the marker found first decides the format, so a LEEF event carrying "CEF:" in an
+// attribute is still read as LEEF.
+func ParseLine(line string) (Event, error) {
+	line = strings.TrimRight(line, "\r\n")
+	cef, leef := strings.Index(line, "CEF:"), strings.Index(line, "LEEF:")
+	switch {
+	case cef >= 0 && (leef < 0 || cef < leef):
+		return parseCEF(line[cef+len("CEF:"):])
+	case leef >= 0:
+		return parseLEEF(line[leef+len("LEEF:"):])
+	}
+	return Event{}, fmt.Errorf("not a CEF or LEEF event")
+}
+
+// parseCEF parses "Version|Vendor|Product|Version|SignatureID|Name|Severity|Extension".
+// Header fields escape '|' and '\' with a backslash.
+func parseCEF(rest string) (Event, error) {
+	fields, extension, err := splitHeader(rest, len(cefHeaderFields))
+	if err != nil {
+		return Event{}, fmt.Errorf("invalid CEF header: %v", err)
+	}
+	if fields[0] != "0" && fields[0] != "1" {
+		return Event{}, fmt.Errorf("unsupported CEF version %q", fields[0])
+	}
+	header := make(map[string]string, len(fields))
+	for i, name := range cefHeaderFields {
+		header[name] = fields[i]
+	}
+	extensions, err := parseCEFExtension(extension)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{Format: FormatCEF, Header: header, Extensions: extensions}, nil
+}
+
+// splitHeader splits count pipe-separated header fields, unescaping \| and \\,
+// and returns them together with the rest of the line
+func splitHeader(rest string, count int) ([]string, string, error) {
+	fields := make([]string, 0, count)
+	var field strings.Builder
+	for i := 0; i < len(rest); i++ {
+		switch c := rest[i]; {
+		case c == '\\' && i+1 < len(rest) && (rest[i+1] == '|' || rest[i+1] == '\\'):
+			i++
+			field.WriteByte(rest[i])
+		case c == '|':
+			fields = append(fields, field.String())
+			field.Reset()
+			if len(fields) == count {
+				return fields, rest[i+1:], nil
+			}
+		default:
+			field.WriteByte(c)
+		}
+	}
+	return nil, "", fmt.Errorf("expected %d fields, found %d", count, len(fields))
+}
+
+// parseCEFExtension parses space separated key=value pairs. Values may contain spaces:
+// a value runs until the space before the next unescaped key=. Values escape '=', '\',
+// newlines (\n) and carriage returns (\r).
+func parseCEFExtension(extension string) (map[string]string, error) {
+	extensions := make(map[string]string)
+	extension = strings.TrimSpace(extension)
+	if extension == "" {
+		return extensions, nil
+	}
+
+	// Positions of every unescaped '='
+	var equals []int
+	for i := 0; i < len(extension); i++ {
+		if extension[i] == '\\' {
+			i++
+			continue
+		}
+		if extension[i] == '=' {
+			equals = append(equals, i)
+		}
+	}
+	if len(equals) == 0 {
+		return nil, fmt.Errorf("invalid CEF extension: no key=value pairs")
+	}
+
+	keyStart := 0
+	for n, eq := range equals {
+		key := extension[keyStart:eq]
+		if key == "" || strings.ContainsAny(key, " \\") {
+			return nil, fmt.Errorf("invalid CEF extension key %q", key)
+		}
+		valueEnd := len(extension)
+		nextKeyStart := len(extension)
+		if n+1 < len(equals) {
+			nextKeyStart = strings.LastIndexByte(extension[:equals[n+1]], ' ') + 1
+			if nextKeyStart <= eq {
+				return nil, fmt.Errorf("invalid CEF extension near %q", extension[eq+1:equals[n+1]])
+			}
+			valueEnd = nextKeyStart - 1
+		}
+		extensions[key] = unescapeCEFValue(strings.TrimRight(extension[eq+1:valueEnd], " "))
+		keyStart = nextKeyStart
+	}
+	return extensions, nil
+}
+
+func unescapeCEFValue(value string) string {
+	if !strings.Contains(value, "\\") {
+		return value
+	}
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' && i+1 < len(value) {
+			i++
+			switch value[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			default:
+				b.WriteByte(value[i])
+			}
+			continue
+		}
+		b.WriteByte(value[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,182 @@
+package eventformats
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    Event
+		wantErr bool
+	}{
+		{
+			name: "CEF with syslog header and escapes",
+			line: `<13>Jan 02 10:00:00 fw01 CEF:0|Acme|Fire\|wall|1.0|100|Login failed|5|suser=alice src=10.0.0.1 msg=bad password a\=b rt=1704189600000` + "\r\n",
+			want: Event{
+				Format: FormatCEF,
+				Header: map[string]string{
+					"version": "0", "deviceVendor": "Acme", "deviceProduct": "Fire|wall", "deviceVersion": "1.0",
+					"signatureId": "100", "name": "Login failed", "severity": "5",
+				},
+				Extensions: map[string]string{"suser": "alice", "src": "10.0.0.1", "msg": "bad password a=b", "rt": "1704189600000"},
+			},
+		},
+		{
+			name: "CEF without extension",
+			line: "CEF:1|Acme|IDS|2|7|Scan|3|",
+			want: Event{
+				Format: FormatCEF,
+				Header: map[string]string{
+					"version": "1", "deviceVendor": "Acme", "deviceProduct": "IDS", "deviceVersion": "2",
+					"signatureId": "7", "name": "Scan", "severity": "3",
+				},
+				Extensions: map[string]string{},
+			},
+		},
+		{
+			name: "LEEF 1.0 with tab separated attributes",
+			line: "LEEF:1.0|IBM|QRadar|7.4|LoginFailure|usrName=bob\tsrc=10.0.0.2\tdevTime=Jan 02 2024 10:00:00",
+			want: Event{
+				Format:     FormatLEEF,
+				Header:     map[string]string{"version": "1.0", "vendor": "IBM", "product": "QRadar", "productVersion": "7.4", "eventId": "LoginFailure"},
+				Extensions: map[string]string{"usrName": "bob", "src": "10.0.0.2", "devTime": "Jan 02 2024 10:00:00"},
+			},
+		},
+		{
+			name: "LEEF 2.0 with a hex delimiter",
+			line: "LEEF:2.0|IBM|QRadar|7.4|Login|x5E|usrName=bob^src=10.0.0.2",
+			want: Event{
+				Format:     FormatLEEF,
+				Header:     map[string]string{"version": "2.0", "vendor": "IBM", "product": "QRadar", "productVersion": "7.4", "eventId": "Login"},
+				Extensions: map[string]string{"usrName": "bob", "src": "10.0.0.2"},
+			},
+		},
+		{
+			name: "LEEF carrying CEF: in an attribute stays LEEF",
+			line: "<13>host LEEF:1.0|IBM|QRadar|7.4|Fwd|msg=original CEF:0|x|y|1|2|n|3|",
+			want: Event{
+				Format:     FormatLEEF,
+				Header:     map[string]string{"version": "1.0", "vendor": "IBM", "product": "QRadar", "productVersion": "7.4", "eventId": "Fwd"},
+				Extensions: map[string]string{"msg": "original CEF:0|x|y|1|2|n|3|"},
+			},
+		},
+		{
+			name: "CEF carrying LEEF: in an extension stays CEF",
+			line: "CEF:0|Acme|Relay|1|9|Fwd|1|msg=LEEF:1.0|a|b|c|d|",
+			want: Event{
+				Format: FormatCEF,
+				Header: map[string]string{
+					"version": "0", "deviceVendor": "Acme", "deviceProduct": "Relay", "deviceVersion": "1",
+					"signatureId": "9", "name": "Fwd", "severity": "1",
+				},
+				Extensions: map[string]string{"msg": "LEEF:1.0|a|b|c|d|"},
+			},
+		},
+		{name: "neither format", line: "<13>plain syslog", wantErr: true},
+		{name: "unsupported CEF version", line: "CEF:2|a|b|c|d|e|f|", wantErr: true},
+		{name: "truncated CEF header", line: "CEF:0|Acme|IDS", wantErr: true},
+		{name: "CEF extension without pairs", line: "CEF:0|a|b|c|d|e|f|garbage", wantErr: true},
+		{name: "unsupported LEEF version", line: "LEEF:3.0|a|b|c|d|x=y", wantErr: true},
+		{name: "LEEF attribute without value", line: "LEEF:1.0|a|b|c|d|novalue", wantErr: true},
+		{name: "invalid LEEF delimiter", line: "LEEF:2.0|a|b|c|d|xZZ|x=y", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLine(tt.line)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got  %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToLog(t *testing.T) {
+	now := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name          string
+		line          string
+		wantUser      string
+		wantIP        string
+		wantAction    string
+		wantFile      string
+		wantSource    string
+		wantTimestamp time.Time
+		wantAttrs     map[string]string
+	}{
+		{
+			name:          "CEF fields, epoch timestamp and header attributes",
+			line:          "CEF:0|Acme|IDS|1.0|100|Login failed|5|suser=alice src=10.0.0.1 fname=/etc/passwd rt=1704189600000 cs1=extra",
+			wantUser:      "alice",
+			wantIP:        "10.0.0.1",
+			wantAction:    "Login failed",
+			wantFile:      "/etc/passwd",
+			wantSource:    "Acme IDS",
+			wantTimestamp: time.UnixMilli(1704189600000),
+			wantAttrs: map[string]string{
+				"cs1": "extra", "cef.version": "0", "cef.deviceVendor": "Acme", "cef.deviceProduct": "IDS",
+				"cef.deviceVersion": "1.0", "cef.signatureId": "100", "cef.name": "Login failed", "cef.severity": "5",
+			},
+		},
+		{
+			name:          "LEEF action attribute and timestamp without a year",
+			line:          "LEEF:1.0|IBM|QRadar|7.4|LoginFailure|usrName=bob\tact=login_failed\tdevTime=Jan 02 09:30:00",
+			wantUser:      "bob",
+			wantAction:    "login_failed",
+			wantSource:    "IBM QRadar",
+			wantTimestamp: time.Date(2024, 1, 2, 9, 30, 0, 0, time.UTC),
+			wantAttrs: map[string]string{
+				"leef.version": "1.0", "leef.vendor": "IBM", "leef.product": "QRadar",
+				"leef.productVersion": "7.4", "leef.eventId": "LoginFailure",
+			},
+		},
+		{
+			name:          "unparseable timestamp falls back to now and is kept",
+			line:          "LEEF:1.0|IBM|QRadar|7.4|Ev|devTime=yesterday",
+			wantAction:    "Ev",
+			wantSource:    "IBM QRadar",
+			wantTimestamp: now,
+			wantAttrs: map[string]string{
+				"devTime": "yesterday", "leef.version": "1.0", "leef.vendor": "IBM", "leef.product": "QRadar",
+				"leef.productVersion": "7.4", "leef.eventId": "Ev",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := ParseLine(tt.line)
+			if err != nil {
+				t.Fatalf("ParseLine: %v", err)
+			}
+			got := ToLog(event, now)
+			if got.UserID != tt.wantUser || got.IPAddress != tt.wantIP || got.Action != tt.wantAction || got.Source != tt.wantSource {
+				t.Fatalf("got user %q ip %q action %q source %q", got.UserID, got.IPAddress, got.Action, got.Source)
+			}
+			var file string
+			if got.FileName != nil {
+				file = *got.FileName
+			}
+			if file != tt.wantFile {
+				t.Fatalf("got file %q, want %q", file, tt.wantFile)
+			}
+			if !got.Timestamp.Equal(tt.wantTimestamp) {
+				t.Fatalf("got timestamp %s, want %s", got.Timestamp, tt.wantTimestamp)
+			}
+			if !reflect.DeepEqual(map[string]string(got.Attributes), tt.wantAttrs) {
+				t.Fatalf("got attributes %v\nwant %v", got.Attributes, tt.wantAttrs)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package eventformats
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var leefHeaderFields = []string{"version", "vendor", "product", "productVersion", "eventId"}
+
+// parseLEEF parses "Version|Vendor|Product|Version|EventID|" followed by attributes.
+// LEEF 1.0 separates attributes with tabs; LEEF 2.0 adds a header field naming the
+// delimiter as a character or a hex code such as x09 or 0x5E.
+func parseLEEF(rest string) (Event, error) {
+	fields, attributes, err := splitHeader(rest, len(leefHeaderFields))
+	if err != nil {
+		return Event{}, fmt.Errorf("invalid LEEF header: %v", err)
+	}
+	header := make(map[string]string, len(fields))
+	for i, name := range leefHeaderFields {
+		header[name] = fields[i]
+	}
+
+	delimiter := "\t"
+	switch fields[0] {
+	case "1.0":
+	case "2.0":
+		end := strings.IndexByte(attributes, '|')
+		if end < 0 {
+			return Event{}, fmt.Errorf("invalid LEEF header: missing delimiter field")
+		}
+		if delimiter, err = leefDelimiter(attributes[:end]); err != nil {
+			return Event{}, err
+		}
+		attributes = attributes[end+1:]
+	default:
+		return Event{}, fmt.Errorf("unsupported LEEF version %q", fields[0])
+	}
+
+	extensions := make(map[string]string)
+	for _, pair := range strings.Split(attributes, delimiter) {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return Event{}, fmt.Errorf("invalid LEEF attribute %q", pair)
+		}
+		extensions[key] = value
+	}
+	return Event{Format: FormatLEEF, Header: header, Extensions: extensions}, nil
+}
+
+func leefDelimiter(field string) (string, error) {
+	switch {
+	case field == "":
+		return "\t", nil
+	case len(field) == 1:
+		return field, nil
+	case strings.HasPrefix(field, "x") || strings.HasPrefix(field, "0x"):
+		code, err := strconv.ParseUint(strings.TrimPrefix(strings.TrimPrefix(field, "0"), "x"), 16, 8)
+		if err == nil {
+			return string(rune(code)), nil
+		}
+	}
+	return "", fmt.Errorf("invalid LEEF delimiter %q", field)
+}
@@ -0,0 +1,115 @@
+package eventformats
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	logdto "github.com/yatender-pareek/log-ingestor-service/src/dtos/log-dto"
+	logDataentity "github.com/yatender-pareek/log-ingestor-service/src/models/log-data-model"
+)
+
+// fieldKeys lists, per format and log field, the extension keys tried in order
+var fieldKeys = map[string]map[string][]string{
+	FormatCEF: {
+		"userId":    {"suser", "duser"},
+		"ipAddress": {"src", "dvc"},
+		"action":    {"act"},
+		"fileName":  {"fname", "filePath"},
+		"timestamp": {"rt", "end", "start"},
+	},
+	FormatLEEF: {
+		"userId":    {"usrName", "suser"},
+		"ipAddress": {"src"},
+		"action":    {"act", "action"},
+		"fileName":  {"fname", "fileName"},
+		"timestamp": {"devTime"},
+	},
+}
+
+// timestampLayouts are the common CEF rt and LEEF devTime formats besides epoch milliseconds
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"Jan 02 2006 15:04:05.000 MST",
+	"Jan 02 2006 15:04:05 MST",
+	"Jan 02 2006 15:04:05.000",
+	"Jan 02 2006 15:04:05",
+	"Jan 02 15:04:05",
+}
+
+// ToLog maps an event onto a log request. Extensions used for a log field are consumed;
+// the rest are kept as attributes together with the header fields, which are prefixed
+// with the format name (cef.deviceVendor, leef.eventId, ...). Without a timestamp
+// extension the event is stamped with now; without an action, with the event name or ID.
+// The device vendor and product become the source.
+func ToLog(event Event, now time.Time) logdto.CreateLogRequest {
+	remaining := make(map[string]string, len(event.Extensions))
+	for key, value := range event.Extensions {
+		remaining[key] = value
+	}
+	take := func(field string) string {
+		for _, key := range fieldKeys[event.Format][field] {
+			if value, ok := remaining[key]; ok && value != "" {
+				delete(remaining, key)
+				return value
+			}
+		}
+		return ""
+	}
+
+	logDto := logdto.CreateLogRequest{
+		Timestamp: now,
+		UserID:    take("userId"),
+		IPAddress: take("ipAddress"),
+		Action:    take("action"),
+	}
+	if event.Format == FormatCEF {
+		logDto.Source = strings.TrimSpace(event.Header["deviceVendor"] + " " + event.Header["deviceProduct"])
+		if logDto.Action == "" {
+			logDto.Action = event.Header["name"]
+		}
+	} else {
+		logDto.Source = strings.TrimSpace(event.Header["vendor"] + " " + event.Header["product"])
+		if logDto.Action == "" {
+			logDto.Action = event.Header["eventId"]
+		}
+	}
+	if fileName := take("fileName"); fileName != "" {
+		logDto.FileName = &fileName
+	}
+	for _, key := range fieldKeys[event.Format]["timestamp"] {
+		if timestamp, ok := parseTimestamp(remaining[key], now); ok {
+			logDto.Timestamp = timestamp
+			delete(remaining, key)
+			break
+		}
+	}
+
+	attributes := logDataentity.Attributes(remaining)
+	for name, value := range event.Header {
+		attributes[event.Format+"."+name] = value
+	}
+	logDto.Attributes = attributes
+	return logDto
+}
+
+func parseTimestamp(value string, now time.Time) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, false
+	}
+	if millis, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.UnixMilli(millis), true
+	}
+	for _, layout := range timestampLayouts {
+		timestamp, err := time.ParseInLocation(layout, value, now.Location())
+		if err != nil {
+			continue
+		}
+		if timestamp.Year() == 0 {
+			timestamp = timestamp.AddDate(now.Year(), 0, 0)
+		}
+		return timestamp, true
+	}
+	return time.Time{}, false
+}
@@ -8,10 +8,20 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	authcontroller "github.com/yatender-pareek/identity/src/controllers/auth-controller"
+	auditservice "github.com/yatender-pareek/identity/src/services/audit-service"
+	lockoutservice "github.com/yatender-pareek/identity/src/services/lockout-service"
+	tenantservice "github.com/yatender-pareek/identity/src/services/tenant-service"
+	tokenservice "github.com/yatender-pareek/identity/src/services/token-service"
 	mysqlconfig "github.com/yatender-pareek/log-ingestor-service/src/config/my-sql-config"
 	"github.com/yatender-pareek/log-ingestor-service/src/config/swagger"
 	"github.com/yatender-pareek/log-ingestor-service/src/middleware"
+	logDataentity "github.com/yatender-pareek/log-ingestor-service/src/models/log-data-model"
+	otlpreceiver "github.com/yatender-pareek/log-ingestor-service/src/otlp-receiver"
 	"github.com/yatender-pareek/log-ingestor-service/src/routes"
+	logingestorservice "github.com/yatender-pareek/log-ingestor-service/src/services/log-ingestor-service"
+	outboxservice "github.com/yatender-pareek/log-ingestor-service/src/services/outbox-service"
+	sysloglistener "github.com/yatender-pareek/log-ingestor-service/src/syslog-listener"
 )
 
 // @title Log Ingestor Service API
@@ -22,6 +32,10 @@ import (
 // @in header
 // @name Authorization
 // @description Type "Bearer" followed by a space and JWT token
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @description API key created with POST /api/api-keys
 func main() {
 	if err := godotenv.Load(); err != nil {
 		// log.Println(".env Not found")
@@ -31,10 +45,35 @@ func main() {
 		log.Fatalf("Failed to initialize container: %v", err)
 	}
 
+	if err := tenantservice.Bootstrap(mysqlconfig.GetDB(), &logDataentity.LogData{}); err != nil {
+		log.Fatalf("Failed to set up tenants: %v", err)
+	}
+
+	if err := outboxservice.StartPruner(mysqlconfig.GetDB()); err != nil {
+		log.Fatalf("Failed to start outbox pruning: %v", err)
+	}
+
+	lockoutservice.StartPruner()
+
+	if err := auditservice.Start("log-ingestor-service", logingestorservice.NewLogIngestorService().StoreAuditEvents); err != nil {
+		log.Fatalf("Failed to start audit events: %v", err)
+	}
+
+	if err := sysloglistener.Start(logingestorservice.NewLogIngestorService()); err != nil {
+		log.Fatalf("Failed to start syslog listener: %v", err)
+	}
+
+	if err := otlpreceiver.Init(); err != nil {
+		log.Fatalf("Invalid OTLP mapping: %v", err)
+	}
+
 	ratelimiter := middleware.NewRateLimiter(2, 5)
 
 	r := gin.Default()
 	swagger.SetupSwagger(r)
+	if tokenservice.CanIssue() {
+		r.GET("/.well-known/jwks.json", authcontroller.JWKS)
+	}
 
 	r.GET("/", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"message": "Welcome to the Log-Ingestor-Service!"})
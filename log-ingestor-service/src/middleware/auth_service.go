@@ -3,60 +3,63 @@ package middleware
 import (
 	"log"
 	"net/http"
-	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
-	authcontroller "github.com/yatender-pareek/log-ingestor-service/src/controllers/auth-controller"
+	identitymiddleware "github.com/yatender-pareek/identity/src/middleware"
+	auditservice "github.com/yatender-pareek/identity/src/services/audit-service"
+	"github.com/yatender-pareek/identity/src/tenancy"
+	apikeyservice "github.com/yatender-pareek/log-ingestor-service/src/services/api-key-service"
 )
 
-// AuthMiddleware validates JWT tokens
+// AuthMiddleware validates API keys, then JWT tokens through the shared identity middleware
 func AuthMiddleware() gin.HandlerFunc {
-	secretKey := authcontroller.JWTSecretKey()
+	apiKeys := apikeyservice.NewAPIKeyService()
+	authenticateToken := identitymiddleware.TokenAuthenticator()
 
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
-			c.Abort()
+		if apiKey := c.GetHeader("X-API-Key"); apiKey != "" {
+			authenticateAPIKey(c, apiKeys, apiKey)
 			return
 		}
 
-		// Expect "Bearer <token>"
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header"})
-			c.Abort()
+		tokenString, ok := identitymiddleware.BearerToken(c)
+		if !ok {
 			return
 		}
-
-		tokenString := parts[1]
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				log.Printf("Invalid signing method: %v", token.Header["alg"])
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return secretKey, nil
-		})
-
-		if err != nil {
-			log.Printf("Token parsing error: %v", err)
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token: " + err.Error()})
-			c.Abort()
-			return
-		}
-
-		if !token.Valid {
-			log.Printf("Token invalid: %v", token.Claims)
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
+		if apikeyservice.IsAPIKey(tokenString) {
+			authenticateAPIKey(c, apiKeys, tokenString)
 			return
 		}
+		authenticateToken(c, tokenString)
+	}
+}
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			c.Set("username", claims["username"])
-		}
-
-		c.Next()
+// authenticateAPIKey lets the request act for the key's tenant with the permissions of its scopes
+func authenticateAPIKey(c *gin.Context, apiKeys *apikeyservice.APIKeyService, credential string) {
+	key, err := apiKeys.Authenticate(credential, c.ClientIP())
+	switch err {
+	case nil:
+	case apikeyservice.ErrInvalidAPIKey, apikeyservice.ErrAPIKeyRevoked, apikeyservice.ErrAPIKeyExpired:
+		auditservice.RecordRequest(c, auditservice.ActionAPIKeyInvalid, "reason", err.Error())
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		c.Abort()
+		return
+	case apikeyservice.ErrAPIKeyBlocked:
+		auditservice.RecordRequest(c, auditservice.ActionAPIKeyInvalid, "reason", err.Error())
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		c.Abort()
+		return
+	default:
+		log.Printf("API key lookup error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		c.Abort()
+		return
 	}
+
+	tenancy.SetCaller(c, tenancy.Caller{
+		Username:    "apikey:" + key.Prefix,
+		Tenant:      key.Tenant,
+		Permissions: apikeyservice.Permissions(key),
+	})
+	c.Next()
 }
@@ -0,0 +1,48 @@
+package apikeyentity
+
+import (
+	"strings"
+	"time"
+)
+
+// APIKey lets a machine such as a log shipper authenticate without a user's password.
+// Only a hash of the secret is stored; Prefix identifies the key in lists and logs.
+// Scopes and AllowedCIDRs are comma-separated; no CIDRs means any address.
+type APIKey struct {
+	ID           uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name         string     `json:"name" gorm:"type:varchar(100);not null"`
+	Prefix       string     `json:"prefix" gorm:"type:varchar(16);uniqueIndex;not null"`
+	SecretHash   string     `json:"-" gorm:"type:char(64);not null"`
+	Tenant       string     `json:"tenant" gorm:"type:varchar(64);not null;index"`
+	Scopes       string     `json:"-" gorm:"type:varchar(255);not null"`
+	AllowedCIDRs string     `json:"-" gorm:"type:text"`
+	CreatedBy    string     `json:"createdBy" gorm:"type:varchar(255);not null"`
+	ExpiresAt    *time.Time `json:"expiresAt"`
+	LastUsedAt   *time.Time `json:"lastUsedAt"`
+	LastUsedIP   string     `json:"lastUsedIp" gorm:"type:varchar(45)"`
+	RotatedAt    *time.Time `json:"rotatedAt"`
+	RevokedAt    *time.Time `json:"revokedAt"`
+	CreatedAt    time.Time  `json:"createdAt" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time  `json:"-" gorm:"autoUpdateTime"`
+}
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// ScopeList returns the key's scopes
+func (k *APIKey) ScopeList() []string {
+	return splitList(k.Scopes)
+}
+
+// CIDRList returns the networks the key may be used from
+func (k *APIKey) CIDRList() []string {
+	return splitList(k.AllowedCIDRs)
+}
+
+func splitList(value string) []string {
+	if value == "" {
+		return []string{}
+	}
+	return strings.Split(value, ",")
+}
@@ -4,15 +4,19 @@ package models
 import (
 	"fmt"
 
+	identitymodels "github.com/yatender-pareek/identity/src/models"
+	apikeyentity "github.com/yatender-pareek/log-ingestor-service/src/models/api-key-model"
 	logDataentity "github.com/yatender-pareek/log-ingestor-service/src/models/log-data-model"
-	userentity "github.com/yatender-pareek/log-ingestor-service/src/models/user-model"
+	outboxentity "github.com/yatender-pareek/log-ingestor-service/src/models/outbox-model"
 )
 
 func GetAllModels() []interface{} {
 	models := []interface{}{
 		&logDataentity.LogData{},
-		&userentity.User{},
+		&outboxentity.OutboxEvent{},
+		&apikeyentity.APIKey{},
 	}
+	models = append(models, identitymodels.GetAllModels()...)
 	fmt.Printf("Models: %+v\n", models)
 	return models
 }
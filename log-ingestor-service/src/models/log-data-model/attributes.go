@@ -0,0 +1,111 @@
+package logDataentity
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Attributes holds event fields that have no dedicated LogData column, stored as a JSON object
+type Attributes map[string]string
+
+// Limits on the attributes of a single log
+const (
+	MaxAttributes          = 128
+	MaxAttributeKeyLength  = 128
+	MaxAttributeValueBytes = 4096
+)
+
+// ValidKey reports whether key may be used as an attribute name. Quotes, backslashes,
+// brackets and whitespace are refused so every key works in JSON paths and search filters.
+func ValidKey(key string) bool {
+	if key == "" || len(key) > MaxAttributeKeyLength {
+		return false
+	}
+	return !strings.ContainsFunc(key, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsControl(r) || strings.ContainsRune(`"\[]`, r)
+	})
+}
+
+// Validate checks the number of attributes and the form of their keys and values
+func (a Attributes) Validate() error {
+	if len(a) > MaxAttributes {
+		return fmt.Errorf("at most %d attributes are allowed", MaxAttributes)
+	}
+	for key, value := range a {
+		if !ValidKey(key) {
+			return fmt.Errorf("invalid attribute key %q: keys hold up to %d characters without quotes, backslashes, brackets or spaces", key, MaxAttributeKeyLength)
+		}
+		if len(value) > MaxAttributeValueBytes {
+			return fmt.Errorf("attribute %s exceeds %d bytes", key, MaxAttributeValueBytes)
+		}
+	}
+	return nil
+}
+
+// UnmarshalJSON accepts any JSON object: numbers and booleans are kept as their text,
+// nested objects and arrays as compact JSON and null values are dropped
+func (a *Attributes) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("attributes must be a JSON object")
+	}
+	if raw == nil {
+		*a = nil
+		return nil
+	}
+	attributes := make(Attributes, len(raw))
+	for key, value := range raw {
+		value = bytes.TrimSpace(value)
+		switch {
+		case string(value) == "null":
+			continue
+		case value[0] == '"':
+			var text string
+			if err := json.Unmarshal(value, &text); err != nil {
+				return err
+			}
+			attributes[key] = text
+		case value[0] == '{' || value[0] == '[':
+			var compact bytes.Buffer
+			if err := json.Compact(&compact, value); err != nil {
+				return err
+			}
+			attributes[key] = compact.String()
+		default:
+			attributes[key] = string(value)
+		}
+	}
+	*a = attributes
+	return nil
+}
+
+func (a Attributes) Value() (driver.Value, error) {
+	if len(a) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (a *Attributes) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Attributes", value)
+	}
+	return json.Unmarshal(data, a)
+}
@@ -2,16 +2,24 @@ package logDataentity
 
 import (
 	"time"
+
+	"github.com/yatender-pareek/identity/src/tenancy"
 )
 
+// DefaultTenant owns logs ingested without a tenant
+const DefaultTenant = tenancy.DefaultTenant
+
 type LogData struct {
-	ID            uint64    `json:"id" gorm:"primaryKey;autoIncrement"`
-	Timestamp     time.Time `json:"timestamp" gorm:"not null"`
-	UserID        string    `json:"userId" gorm:"not null;type:varchar(255)"`
-	IPAddress     string    `json:"ipAddress" gorm:"not null;type:varchar(45)"`
-	Action        string    `json:"action" gorm:"not null;type:varchar(255)"`
-	FileName      *string   `json:"fileName" gorm:"type:varchar(255)"`
-	DatabaseQuery *string   `json:"databaseQuery" gorm:"type:text"`
-	CreatedAt     time.Time `json:"-" gorm:"autoCreateTime"`
-	UpdatedAt     time.Time `json:"-" gorm:"autoUpdateTime"`
+	ID            uint64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	Timestamp     time.Time  `json:"timestamp" gorm:"not null;index"`
+	UserID        string     `json:"userId" gorm:"not null;type:varchar(255)"`
+	IPAddress     string     `json:"ipAddress" gorm:"not null;type:varchar(45)"`
+	Action        string     `json:"action" gorm:"not null;type:varchar(255)"`
+	FileName      *string    `json:"fileName" gorm:"type:varchar(255)"`
+	DatabaseQuery *string    `json:"databaseQuery" gorm:"type:text"`
+	Attributes    Attributes `json:"attributes,omitempty" gorm:"type:json"`
+	Source        string     `json:"source" gorm:"type:varchar(255);index"`
+	Tenant        string     `json:"tenant" gorm:"not null;type:varchar(64);default:default;index"`
+	CreatedAt     time.Time  `json:"-" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time  `json:"-" gorm:"autoUpdateTime"`
 }
@@ -0,0 +1,22 @@
+package outboxentity
+
+import (
+	"time"
+)
+
+// EventLogIngested is published for every stored log_data row
+const EventLogIngested = "log.ingested"
+
+// OutboxEvent is written in the same transaction as the change it announces,
+// so an event exists if and only if the change was committed
+type OutboxEvent struct {
+	ID          uint64    `json:"id" gorm:"primaryKey;autoIncrement"`
+	EventType   string    `json:"eventType" gorm:"not null;type:varchar(64);index"`
+	AggregateID uint64    `json:"aggregateId" gorm:"not null"`
+	Payload     string    `json:"payload" gorm:"not null;type:text"`
+	CreatedAt   time.Time `json:"createdAt" gorm:"autoCreateTime;index"`
+}
+
+func (OutboxEvent) TableName() string {
+	return "outbox_events"
+}
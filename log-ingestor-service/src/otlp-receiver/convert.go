@@ -0,0 +1,166 @@
+package otlpreceiver
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	logdto "github.com/yatender-pareek/log-ingestor-service/src/dtos/log-dto"
+	logDataentity "github.com/yatender-pareek/log-ingestor-service/src/models/log-data-model"
+	collectorlogs "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+)
+
+const (
+	resourcePrefix = "resource."
+	// otelPrefix namespaces the attributes made from a record's body, severity and scope,
+	// so they never replace record attributes of the same name
+	otelPrefix = "otel."
+)
+
+// flatRecord is a log record with its body, attributes and resource attributes as strings
+type flatRecord struct {
+	timestamp  time.Time
+	body       string
+	severity   string
+	attributes map[string]string
+	resource   map[string]string
+}
+
+// Convert turns every log record of an export request into a log request, in request order.
+// Attributes a field was taken from are consumed; the remaining record attributes,
+// the resource attributes (prefixed with "resource."), the body, severity and
+// instrumentation scope (otel.body, otel.severity and otel.scope) are kept as attributes.
+func Convert(request *collectorlogs.ExportLogsServiceRequest, mapping Mapping, peer string, now time.Time) []logdto.CreateLogRequest {
+	var logs []logdto.CreateLogRequest
+	for _, resourceLogs := range request.GetResourceLogs() {
+		resource := flatten(resourceLogs.GetResource().GetAttributes())
+		for _, scopeLogs := range resourceLogs.GetScopeLogs() {
+			scope := scopeLogs.GetScope()
+			for _, record := range scopeLogs.GetLogRecords() {
+				flat := flatRecord{
+					timestamp:  now,
+					body:       stringify(record.GetBody()),
+					severity:   record.GetSeverityText(),
+					attributes: flatten(record.GetAttributes()),
+					resource:   resource,
+				}
+				if nanos := record.GetTimeUnixNano(); nanos > 0 {
+					flat.timestamp = time.Unix(0, int64(nanos))
+				} else if nanos := record.GetObservedTimeUnixNano(); nanos > 0 {
+					flat.timestamp = time.Unix(0, int64(nanos))
+				}
+				logs = append(logs, toLog(&flat, mapping, peer, scope.GetName()))
+			}
+		}
+	}
+	return logs
+}
+
+func toLog(record *flatRecord, mapping Mapping, peer, scope string) logdto.CreateLogRequest {
+	consumed := make(map[string]bool)
+	take := func(field string) string {
+		value, key := mapping.resolve(field, record, peer)
+		if key != "" {
+			consumed[key] = true
+		}
+		return value
+	}
+
+	logDto := logdto.CreateLogRequest{
+		Timestamp: record.timestamp,
+		UserID:    take("userId"),
+		IPAddress: take("ipAddress"),
+		Action:    take("action"),
+		Source:    take("source"),
+	}
+	if fileName := take("fileName"); fileName != "" {
+		logDto.FileName = &fileName
+	}
+	if databaseQuery := take("databaseQuery"); databaseQuery != "" {
+		logDto.DatabaseQuery = &databaseQuery
+	}
+
+	attributes := logDataentity.Attributes{}
+	for key, value := range record.attributes {
+		if !consumed[key] {
+			attributes[key] = value
+		}
+	}
+	for key, value := range record.resource {
+		if !consumed[resourcePrefix+key] {
+			attributes[resourcePrefix+key] = value
+		}
+	}
+	for key, value := range map[string]string{"body": record.body, "severity": record.severity, "scope": scope} {
+		if _, taken := attributes[otelPrefix+key]; value != "" && !taken {
+			attributes[otelPrefix+key] = value
+		}
+	}
+	logDto.Attributes = attributes
+	return logDto
+}
+
+// RecordCount returns the number of log records in an export request
+func RecordCount(request *collectorlogs.ExportLogsServiceRequest) int {
+	count := 0
+	for _, resourceLogs := range request.GetResourceLogs() {
+		for _, scopeLogs := range resourceLogs.GetScopeLogs() {
+			count += len(scopeLogs.GetLogRecords())
+		}
+	}
+	return count
+}
+
+func flatten(attributes []*commonv1.KeyValue) map[string]string {
+	flat := make(map[string]string, len(attributes))
+	for _, attribute := range attributes {
+		flat[attribute.GetKey()] = stringify(attribute.GetValue())
+	}
+	return flat
+}
+
+// stringify renders scalars as text and arrays and maps as JSON
+func stringify(value *commonv1.AnyValue) string {
+	switch v := value.GetValue().(type) {
+	case *commonv1.AnyValue_StringValue:
+		return v.StringValue
+	case *commonv1.AnyValue_BoolValue:
+		return strconv.FormatBool(v.BoolValue)
+	case *commonv1.AnyValue_IntValue:
+		return strconv.FormatInt(v.IntValue, 10)
+	case *commonv1.AnyValue_DoubleValue:
+		return strconv.FormatFloat(v.DoubleValue, 'g', -1, 64)
+	case *commonv1.AnyValue_BytesValue:
+		return base64.StdEncoding.EncodeToString(v.BytesValue)
+	case *commonv1.AnyValue_ArrayValue, *commonv1.AnyValue_KvlistValue:
+		data, _ := json.Marshal(native(value))
+		return string(data)
+	}
+	return ""
+}
+
+func native(value *commonv1.AnyValue) interface{} {
+	switch v := value.GetValue().(type) {
+	case *commonv1.AnyValue_BoolValue:
+		return v.BoolValue
+	case *commonv1.AnyValue_IntValue:
+		return v.IntValue
+	case *commonv1.AnyValue_DoubleValue:
+		return v.DoubleValue
+	case *commonv1.AnyValue_ArrayValue:
+		values := make([]interface{}, 0, len(v.ArrayValue.GetValues()))
+		for _, item := range v.ArrayValue.GetValues() {
+			values = append(values, native(item))
+		}
+		return values
+	case *commonv1.AnyValue_KvlistValue:
+		values := make(map[string]interface{}, len(v.KvlistValue.GetValues()))
+		for _, item := range v.KvlistValue.GetValues() {
+			values[item.GetKey()] = native(item.GetValue())
+		}
+		return values
+	}
+	return stringify(value)
+}
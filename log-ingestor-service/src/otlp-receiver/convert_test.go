@@ -0,0 +1,169 @@
+package otlpreceiver
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	collectorlogs "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcev1 "go.opentelemetry.io/proto/otlp/resource/v1"
+)
+
+func stringValue(value string) *commonv1.AnyValue {
+	return &commonv1.AnyValue{Value: &commonv1.AnyValue_StringValue{StringValue: value}}
+}
+
+func keyValues(pairs ...string) []*commonv1.KeyValue {
+	var attributes []*commonv1.KeyValue
+	for i := 0; i+1 < len(pairs); i += 2 {
+		attributes = append(attributes, &commonv1.KeyValue{Key: pairs[i], Value: stringValue(pairs[i+1])})
+	}
+	return attributes
+}
+
+func exportRequest(resource []*commonv1.KeyValue, scope string, records ...*logsv1.LogRecord) *collectorlogs.ExportLogsServiceRequest {
+	return &collectorlogs.ExportLogsServiceRequest{ResourceLogs: []*logsv1.ResourceLogs{{
+		Resource: &resourcev1.Resource{Attributes: resource},
+		ScopeLogs: []*logsv1.ScopeLogs{{
+			Scope:      &commonv1.InstrumentationScope{Name: scope},
+			LogRecords: records,
+		}},
+	}}}
+}
+
+func TestConvert(t *testing.T) {
+	now := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	recordTime := time.Date(2024, 1, 2, 11, 0, 0, 0, time.UTC)
+	observed := time.Date(2024, 1, 2, 11, 30, 0, 0, time.UTC)
+	mapping, err := ParseMapping("")
+	if err != nil {
+		t.Fatalf("ParseMapping: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		resource      []*commonv1.KeyValue
+		record        *logsv1.LogRecord
+		wantUser      string
+		wantIP        string
+		wantAction    string
+		wantSource    string
+		wantTimestamp time.Time
+		wantAttrs     map[string]string
+	}{
+		{
+			name:     "mapped attributes are consumed, the rest kept",
+			resource: keyValues("service.name", "checkout", "host.name", "web01"),
+			record: &logsv1.LogRecord{
+				TimeUnixNano: uint64(recordTime.UnixNano()),
+				SeverityText: "WARN",
+				Body:         stringValue("login failed"),
+				Attributes:   keyValues("enduser.id", "alice", "client.address", "10.0.0.1:52000", "event.name", "login_failed", "http.method", "POST"),
+			},
+			wantUser:      "alice",
+			wantIP:        "10.0.0.1",
+			wantAction:    "login_failed",
+			wantSource:    "checkout",
+			wantTimestamp: recordTime,
+			wantAttrs: map[string]string{
+				"http.method": "POST", "resource.host.name": "web01",
+				"otel.body": "login failed", "otel.severity": "WARN", "otel.scope": "auth",
+			},
+		},
+		{
+			name: "body, severity and scope never replace record attributes",
+			record: &logsv1.LogRecord{
+				ObservedTimeUnixNano: uint64(observed.UnixNano()),
+				SeverityText:         "INFO",
+				Body:                 stringValue("text"),
+				Attributes:           keyValues("body", "own body", "severity", "own severity", "scope", "own scope", "otel.body", "own otel body"),
+			},
+			wantIP:        "192.0.2.7",
+			wantAction:    "text",
+			wantTimestamp: observed,
+			wantAttrs: map[string]string{
+				"body": "own body", "severity": "own severity", "scope": "own scope",
+				"otel.body": "own otel body", "otel.severity": "INFO", "otel.scope": "auth",
+			},
+		},
+		{
+			name:          "records without a time are stamped on receipt",
+			record:        &logsv1.LogRecord{Attributes: keyValues("user.name", "bob", "client.address", "not-an-ip")},
+			wantUser:      "bob",
+			wantIP:        "192.0.2.7",
+			wantTimestamp: now,
+			wantAttrs:     map[string]string{"client.address": "not-an-ip", "otel.scope": "auth"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := Convert(exportRequest(tt.resource, "auth", tt.record), mapping, "192.0.2.7", now)
+			if len(logs) != 1 {
+				t.Fatalf("expected 1 log, got %d", len(logs))
+			}
+			got := logs[0]
+			if got.UserID != tt.wantUser || got.IPAddress != tt.wantIP || got.Action != tt.wantAction || got.Source != tt.wantSource {
+				t.Fatalf("got user %q ip %q action %q source %q", got.UserID, got.IPAddress, got.Action, got.Source)
+			}
+			if !got.Timestamp.Equal(tt.wantTimestamp) {
+				t.Fatalf("got timestamp %s, want %s", got.Timestamp, tt.wantTimestamp)
+			}
+			if !reflect.DeepEqual(map[string]string(got.Attributes), tt.wantAttrs) {
+				t.Fatalf("got attributes %v\nwant %v", got.Attributes, tt.wantAttrs)
+			}
+		})
+	}
+}
+
+func TestStringify(t *testing.T) {
+	tests := []struct {
+		name  string
+		value *commonv1.AnyValue
+		want  string
+	}{
+		{"string", stringValue("x"), "x"},
+		{"int", &commonv1.AnyValue{Value: &commonv1.AnyValue_IntValue{IntValue: 42}}, "42"},
+		{"bool", &commonv1.AnyValue{Value: &commonv1.AnyValue_BoolValue{BoolValue: true}}, "true"},
+		{"bytes", &commonv1.AnyValue{Value: &commonv1.AnyValue_BytesValue{BytesValue: []byte("hi")}}, "aGk="},
+		{"array", &commonv1.AnyValue{Value: &commonv1.AnyValue_ArrayValue{ArrayValue: &commonv1.ArrayValue{
+			Values: []*commonv1.AnyValue{stringValue("a"), {Value: &commonv1.AnyValue_IntValue{IntValue: 1}}},
+		}}}, `["a",1]`},
+		{"map", &commonv1.AnyValue{Value: &commonv1.AnyValue_KvlistValue{KvlistValue: &commonv1.KeyValueList{
+			Values: keyValues("k", "v"),
+		}}}, `{"k":"v"}`},
+		{"empty", nil, ""},
+	}
+	for _, tt := range tests {
+		if got := stringify(tt.value); got != tt.want {
+			t.Errorf("stringify(%s) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRecordCount(t *testing.T) {
+	request := exportRequest(nil, "a", &logsv1.LogRecord{}, &logsv1.LogRecord{})
+	request.ResourceLogs = append(request.ResourceLogs, exportRequest(nil, "b", &logsv1.LogRecord{}).ResourceLogs...)
+	if got := RecordCount(request); got != 3 {
+		t.Fatalf("RecordCount = %d, want 3", got)
+	}
+}
+
+func TestParseMapping(t *testing.T) {
+	mapping, err := ParseMapping("userId=$severity|user.name")
+	if err != nil {
+		t.Fatalf("ParseMapping: %v", err)
+	}
+	if want := []string{"$severity", "user.name"}; !reflect.DeepEqual(mapping["userId"], want) {
+		t.Fatalf("userId sources = %v, want %v", mapping["userId"], want)
+	}
+	if len(mapping["action"]) == 0 {
+		t.Fatal("fields left out should keep their default sources")
+	}
+	for _, spec := range []string{"user=x", "userId=$nope", "userId=a||b"} {
+		if _, err := ParseMapping(spec); err == nil {
+			t.Errorf("ParseMapping(%q) should fail", spec)
+		}
+	}
+}
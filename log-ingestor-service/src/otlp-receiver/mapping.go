@@ -0,0 +1,113 @@
+// Package otlpreceiver converts OpenTelemetry OTLP log records into logs
+package otlpreceiver
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// DefaultMapping fills each log field from the first source with a value
+const DefaultMapping = "userId=enduser.id|user.id|user.name," +
+	"ipAddress=client.address|source.address|net.peer.ip|$peer," +
+	"action=event.name|$body,fileName=file.path|file.name,databaseQuery=db.query.text|db.statement," +
+	"source=service.name"
+
+var mappedFields = map[string]bool{
+	"userId": true, "ipAddress": true, "action": true, "fileName": true, "databaseQuery": true, "source": true,
+}
+
+var configured Mapping
+
+// Init reads the field mapping from OTLP_MAPPING
+func Init() error {
+	mapping, err := ParseMapping(os.Getenv("OTLP_MAPPING"))
+	if err != nil {
+		return err
+	}
+	configured = mapping
+	return nil
+}
+
+// Configured returns the mapping read by Init, or the default mapping
+func Configured() Mapping {
+	if configured == nil {
+		configured, _ = ParseMapping("")
+	}
+	return configured
+}
+
+// Mapping lists, per log field, the sources to take its value from in order of preference.
+// A source is an attribute key, looked up on the log record and then on its resource, or
+// one of $body, $severity (the severity text) and $peer (the address of the sender).
+type Mapping map[string][]string
+
+// ParseMapping reads a mapping such as "userId=enduser.id|user.name,action=$body".
+// Fields left out keep their default sources.
+func ParseMapping(spec string) (Mapping, error) {
+	mapping := Mapping{}
+	for _, source := range []string{DefaultMapping, spec} {
+		for _, entry := range strings.Split(source, ",") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+			field, sources, ok := strings.Cut(entry, "=")
+			if !ok || !mappedFields[field] {
+				return nil, fmt.Errorf("invalid OTLP mapping entry %q", entry)
+			}
+			var parsed []string
+			for _, s := range strings.Split(sources, "|") {
+				s = strings.TrimSpace(s)
+				if s == "" || (strings.HasPrefix(s, "$") && s != "$body" && s != "$severity" && s != "$peer") {
+					return nil, fmt.Errorf("unknown OTLP source %q for %s", s, field)
+				}
+				parsed = append(parsed, s)
+			}
+			mapping[field] = parsed
+		}
+	}
+	return mapping, nil
+}
+
+// resolve returns the first non-empty value for field and the attribute key it came
+// from, if any. ipAddress only accepts valid IPs.
+func (m Mapping) resolve(field string, record *flatRecord, peer string) (string, string) {
+	for _, source := range m[field] {
+		var value, key string
+		switch source {
+		case "$body":
+			value = record.body
+		case "$severity":
+			value = record.severity
+		case "$peer":
+			value = peer
+		default:
+			if v, ok := record.attributes[source]; ok {
+				value, key = v, source
+			} else if v, ok := record.resource[source]; ok {
+				value, key = v, resourcePrefix+source
+			}
+		}
+		if value == "" {
+			continue
+		}
+		if field == "ipAddress" {
+			value = hostOnly(value)
+			if net.ParseIP(value) == nil {
+				continue
+			}
+		}
+		return value, key
+	}
+	return "", ""
+}
+
+// hostOnly strips a port from addresses such as 10.0.0.1:443 or [::1]:443
+func hostOnly(address string) string {
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		return host
+	}
+	return address
+}
@@ -2,15 +2,66 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
+	authcontroller "github.com/yatender-pareek/identity/src/controllers/auth-controller"
+	lockoutcontroller "github.com/yatender-pareek/identity/src/controllers/lockout-controller"
+	mfacontroller "github.com/yatender-pareek/identity/src/controllers/mfa-controller"
+	tokenservice "github.com/yatender-pareek/identity/src/services/token-service"
+	"github.com/yatender-pareek/identity/src/tenancy"
+	apikeycontroller "github.com/yatender-pareek/log-ingestor-service/src/controllers/api-key-controller"
 	controllers "github.com/yatender-pareek/log-ingestor-service/src/controllers/log-controller"
+	syslogcontroller "github.com/yatender-pareek/log-ingestor-service/src/controllers/syslog-controller"
+	tenantcontroller "github.com/yatender-pareek/log-ingestor-service/src/controllers/tenant-controller"
 )
 
 func SetupProtectedRoutes(r *gin.RouterGroup) *gin.RouterGroup {
-	r.POST("/logs", controllers.CreateLog)
-	r.GET("/logs", controllers.GetAllLogs)
-	r.GET("/logs/search", controllers.SearchLogs)
-	r.GET("/logs/:logId", controllers.GetLogByID)
-	r.DELETE("/logs/:logId", controllers.DeletelogByID)
+	r.POST("/logout", authcontroller.Logout)
+	// MFA is part of logging in, so it is managed on the token issuer
+	if tokenservice.CanIssue() {
+		r.GET("/mfa", mfacontroller.GetMFAStatus)
+		r.POST("/mfa/totp", mfacontroller.EnrollTOTP)
+		r.POST("/mfa/totp/confirm", mfacontroller.ConfirmTOTP)
+		r.DELETE("/mfa/totp", mfacontroller.DisableTOTP)
+		r.POST("/mfa/recovery-codes", mfacontroller.RegenerateRecoveryCodes)
+		r.GET("/mfa/policy", tenancy.Require(tenancy.PermManageUsers), mfacontroller.GetMFAPolicy)
+		r.PUT("/mfa/policy", tenancy.Require(tenancy.PermManageUsers), mfacontroller.SetMFAPolicy)
+	}
+
+	ingest := r.Group("", tenancy.Require(tenancy.PermIngest))
+	ingest.POST("/logs", controllers.CreateLog)
+	ingest.POST("/logs/batch", controllers.CreateLogBatch)
+	ingest.POST("/logs/events", controllers.CreateLogsFromEvents)
+	ingest.POST("/v1/logs", controllers.ExportOTLPLogs)
+
+	read := r.Group("", tenancy.Require(tenancy.PermRead))
+	read.GET("/logs", controllers.GetAllLogs)
+	read.GET("/logs/search", controllers.SearchLogs)
+	read.GET("/logs/:logId", controllers.GetLogByID)
+
+	r.DELETE("/logs/:logId", tenancy.Require(tenancy.PermDelete), controllers.DeletelogByID)
+
+	keys := r.Group("", tenancy.Require(tenancy.PermManageKeys))
+	keys.GET("/api-keys", apikeycontroller.GetAPIKeys)
+	keys.POST("/api-keys", apikeycontroller.CreateAPIKey)
+	keys.POST("/api-keys/:keyId/rotate", apikeycontroller.RotateAPIKey)
+	keys.DELETE("/api-keys/:keyId", apikeycontroller.RevokeAPIKey)
+
+	users := r.Group("", tenancy.Require(tenancy.PermManageUsers))
+	users.GET("/users", tenantcontroller.GetUsers)
+	users.PATCH("/users/:username", tenantcontroller.UpdateUser)
+	users.DELETE("/users/:username/sessions", tenantcontroller.RevokeUserSessions)
+	if tokenservice.CanIssue() {
+		users.DELETE("/users/:username/mfa", mfacontroller.ResetUserMFA)
+		users.GET("/login-lockouts", lockoutcontroller.GetLoginLockouts)
+		users.DELETE("/users/:username/lockout", lockoutcontroller.UnlockUser)
+	}
+
+	admin := r.Group("", tenancy.RequirePlatformAdmin())
+	admin.GET("/syslog/stats", syslogcontroller.GetSyslogStats)
+	admin.GET("/tenants", tenantcontroller.GetTenants)
+	admin.POST("/tenants", tenantcontroller.CreateTenant)
+	if tokenservice.CanIssue() {
+		admin.DELETE("/login-lockouts/ips/:ip", lockoutcontroller.UnlockIP)
+	}
 
 	return r
 }
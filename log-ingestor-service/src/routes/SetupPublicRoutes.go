@@ -2,11 +2,23 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
-	authcontroller "github.com/yatender-pareek/log-ingestor-service/src/controllers/auth-controller"
+	authcontroller "github.com/yatender-pareek/identity/src/controllers/auth-controller"
+	oidcservice "github.com/yatender-pareek/identity/src/services/oidc-service"
+	tokenservice "github.com/yatender-pareek/identity/src/services/token-service"
 )
 
 func SetupPublicRoutes(r *gin.RouterGroup) *gin.RouterGroup {
 	r.POST("/register", authcontroller.Register)
-	r.POST("/login", authcontroller.Login)
+	// Only the token issuer logs users in; services verifying against its JWKS leave it to the issuer
+	if tokenservice.CanIssue() {
+		r.POST("/login", authcontroller.Login)
+		r.POST("/token/refresh", authcontroller.RefreshToken)
+		r.POST("/login/mfa", authcontroller.MFALogin)
+		r.POST("/login/mfa/enroll", authcontroller.MFALoginEnroll)
+		if oidcservice.Enabled() {
+			r.GET("/oidc/login", authcontroller.OIDCLogin)
+			r.GET("/oidc/callback", authcontroller.OIDCCallback)
+		}
+	}
 	return r
 }
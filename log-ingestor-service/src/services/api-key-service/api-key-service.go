@@ -0,0 +1,325 @@
+package apikeyservice
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	tenantentity "github.com/yatender-pareek/identity/src/models/tenant-model"
+	tenantservice "github.com/yatender-pareek/identity/src/services/tenant-service"
+	"github.com/yatender-pareek/identity/src/tenancy"
+	mysqlconfig "github.com/yatender-pareek/log-ingestor-service/src/config/my-sql-config"
+	apikeyentity "github.com/yatender-pareek/log-ingestor-service/src/models/api-key-model"
+	"gorm.io/gorm"
+)
+
+// KeyPrefix starts every API key, so keys are recognisable in headers and secret scanners
+const KeyPrefix = "lis_"
+
+const (
+	ScopeLogsWrite = "logs:write"
+	ScopeLogsRead  = "logs:read"
+	// ScopeAuditWrite lets a service ship its audit events, whose sources no other credential may use,
+	// under the tenant of each event's user
+	ScopeAuditWrite = "audit:write"
+)
+
+// PermIngestAudit lets a caller ingest logs under the reserved audit sources. Only API keys with the
+// audit:write scope hold it; roles, including platform admin, never do.
+const PermIngestAudit tenancy.Permission = "ingest_audit"
+
+// scopePermissions is what each scope lets a key do
+var scopePermissions = map[string][]tenancy.Permission{
+	ScopeLogsWrite:  {tenancy.PermIngest},
+	ScopeLogsRead:   {tenancy.PermRead},
+	ScopeAuditWrite: {tenancy.PermIngest, PermIngestAudit},
+}
+
+// lastUsedInterval limits how often using a key writes its last-used time
+const lastUsedInterval = time.Minute
+
+var (
+	ErrUnknownAPIKey = errors.New("API key does not exist")
+	ErrInvalidScope  = fmt.Errorf("scopes must be among %s, %s and %s", ScopeLogsWrite, ScopeLogsRead, ScopeAuditWrite)
+	ErrAuditScope    = fmt.Errorf("only platform admins can manage keys with the %s scope", ScopeAuditWrite)
+	ErrInvalidCIDR   = errors.New("allowedCidrs must hold networks such as 10.0.0.0/8 or single addresses")
+	ErrExpiryInPast  = errors.New("expiresAt must be in the future")
+	ErrInvalidAPIKey = errors.New("invalid API key")
+	ErrAPIKeyRevoked = errors.New("API key has been revoked")
+	ErrAPIKeyExpired = errors.New("API key has expired")
+	ErrAPIKeyBlocked = errors.New("API key may not be used from this address")
+)
+
+var secretEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// IsAPIKey reports whether a credential looks like an API key rather than a JWT
+func IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, KeyPrefix)
+}
+
+// Permissions returns what the key's scopes allow
+func Permissions(key apikeyentity.APIKey) []tenancy.Permission {
+	permissions := []tenancy.Permission{}
+	for _, scope := range key.ScopeList() {
+		permissions = append(permissions, scopePermissions[scope]...)
+	}
+	return permissions
+}
+
+// CanIngestAudit reports whether the caller may ingest logs under the reserved audit sources.
+// It looks at the caller's own permissions rather than Can, so no role grants it.
+func CanIngestAudit(caller tenancy.Caller) bool {
+	return slices.Contains(caller.Permissions, PermIngestAudit)
+}
+
+type APIKeyService struct {
+	tenant string
+}
+
+func NewAPIKeyService() *APIKeyService {
+	return &APIKeyService{}
+}
+
+// ForTenant returns a service limited to the API keys of tenant; an empty tenant covers all tenants.
+// Keys with the audit:write scope can only be created or rotated through a service covering all tenants.
+func (s *APIKeyService) ForTenant(tenant string) *APIKeyService {
+	return &APIKeyService{tenant: tenant}
+}
+
+func (s *APIKeyService) db() *gorm.DB {
+	return tenancy.Scoped(mysqlconfig.GetDB(), s.tenant)
+}
+
+// CreateAPIKey issues a key for tenant and returns it together with the full key,
+// which cannot be recovered later
+func (s *APIKeyService) CreateAPIKey(name string, scopes, cidrs []string, expiresAt *time.Time, tenant, createdBy string) (apikeyentity.APIKey, string, error) {
+	scopeList, err := normalizeScopes(scopes)
+	if err != nil {
+		return apikeyentity.APIKey{}, "", err
+	}
+	if s.tenant != "" && slices.Contains(scopeList, ScopeAuditWrite) {
+		return apikeyentity.APIKey{}, "", ErrAuditScope
+	}
+	cidrList, err := normalizeCIDRs(cidrs)
+	if err != nil {
+		return apikeyentity.APIKey{}, "", err
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return apikeyentity.APIKey{}, "", ErrExpiryInPast
+	}
+	if err := mysqlconfig.GetDB().Where("name = ?", tenant).First(&tenantentity.Tenant{}).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return apikeyentity.APIKey{}, "", tenantservice.ErrUnknownTenant
+		}
+		return apikeyentity.APIKey{}, "", fmt.Errorf("failed to look up tenant: %v", err)
+	}
+
+	prefix, secret, err := newCredentials()
+	if err != nil {
+		return apikeyentity.APIKey{}, "", err
+	}
+	key := apikeyentity.APIKey{
+		Name:         name,
+		Prefix:       prefix,
+		SecretHash:   hashSecret(secret),
+		Tenant:       tenant,
+		Scopes:       strings.Join(scopeList, ","),
+		AllowedCIDRs: strings.Join(cidrList, ","),
+		CreatedBy:    createdBy,
+		ExpiresAt:    expiresAt,
+	}
+	if err := s.db().Create(&key).Error; err != nil {
+		return apikeyentity.APIKey{}, "", fmt.Errorf("failed to create API key: %v", err)
+	}
+	return key, formatKey(prefix, secret), nil
+}
+
+func (s *APIKeyService) ListAPIKeys() ([]apikeyentity.APIKey, error) {
+	var keys []apikeyentity.APIKey
+	if err := s.db().Order("id").Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve API keys: %v", err)
+	}
+	return keys, nil
+}
+
+// RotateAPIKey replaces the key's secret, keeping its prefix and settings; the old secret stops working at once
+func (s *APIKeyService) RotateAPIKey(id uint64) (apikeyentity.APIKey, string, error) {
+	key, err := s.find(id)
+	if err != nil {
+		return apikeyentity.APIKey{}, "", err
+	}
+	if key.RevokedAt != nil {
+		return apikeyentity.APIKey{}, "", ErrAPIKeyRevoked
+	}
+	if s.tenant != "" && slices.Contains(key.ScopeList(), ScopeAuditWrite) {
+		return apikeyentity.APIKey{}, "", ErrAuditScope
+	}
+	_, secret, err := newCredentials()
+	if err != nil {
+		return apikeyentity.APIKey{}, "", err
+	}
+	now := time.Now()
+	if err := s.db().Model(&key).Updates(map[string]interface{}{
+		"secret_hash": hashSecret(secret),
+		"rotated_at":  now,
+	}).Error; err != nil {
+		return apikeyentity.APIKey{}, "", fmt.Errorf("failed to rotate API key: %v", err)
+	}
+	key.RotatedAt = &now
+	return key, formatKey(key.Prefix, secret), nil
+}
+
+// RevokeAPIKey disables the key for good; revoking it again changes nothing
+func (s *APIKeyService) RevokeAPIKey(id uint64) (apikeyentity.APIKey, error) {
+	key, err := s.find(id)
+	if err != nil || key.RevokedAt != nil {
+		return key, err
+	}
+	now := time.Now()
+	if err := s.db().Model(&key).Update("revoked_at", now).Error; err != nil {
+		return apikeyentity.APIKey{}, fmt.Errorf("failed to revoke API key: %v", err)
+	}
+	key.RevokedAt = &now
+	return key, nil
+}
+
+func (s *APIKeyService) find(id uint64) (apikeyentity.APIKey, error) {
+	var key apikeyentity.APIKey
+	if err := s.db().First(&key, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return apikeyentity.APIKey{}, ErrUnknownAPIKey
+		}
+		return apikeyentity.APIKey{}, err
+	}
+	return key, nil
+}
+
+// Authenticate returns the key a credential belongs to if it may be used now from clientIP,
+// and records the use
+func (s *APIKeyService) Authenticate(credential, clientIP string) (apikeyentity.APIKey, error) {
+	prefix, secret, ok := parseKey(credential)
+	if !ok {
+		return apikeyentity.APIKey{}, ErrInvalidAPIKey
+	}
+	var key apikeyentity.APIKey
+	if err := mysqlconfig.GetDB().Where("prefix = ?", prefix).First(&key).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return apikeyentity.APIKey{}, ErrInvalidAPIKey
+		}
+		return apikeyentity.APIKey{}, err
+	}
+	if subtle.ConstantTimeCompare([]byte(hashSecret(secret)), []byte(key.SecretHash)) != 1 {
+		return apikeyentity.APIKey{}, ErrInvalidAPIKey
+	}
+	now := time.Now()
+	if key.RevokedAt != nil {
+		return apikeyentity.APIKey{}, ErrAPIKeyRevoked
+	}
+	if key.ExpiresAt != nil && !now.Before(*key.ExpiresAt) {
+		return apikeyentity.APIKey{}, ErrAPIKeyExpired
+	}
+	if !allowedFrom(key.CIDRList(), clientIP) {
+		return apikeyentity.APIKey{}, ErrAPIKeyBlocked
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedInterval || key.LastUsedIP != clientIP {
+		if err := mysqlconfig.GetDB().Model(&key).UpdateColumns(map[string]interface{}{
+			"last_used_at": now,
+			"last_used_ip": clientIP,
+		}).Error; err != nil {
+			log.Printf("Failed to record use of API key %s: %v", key.Prefix, err)
+		}
+	}
+	return key, nil
+}
+
+func normalizeScopes(scopes []string) ([]string, error) {
+	var list []string
+	seen := map[string]bool{}
+	for _, scope := range scopes {
+		if _, ok := scopePermissions[scope]; !ok {
+			return nil, ErrInvalidScope
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			list = append(list, scope)
+		}
+	}
+	if len(list) == 0 {
+		return nil, ErrInvalidScope
+	}
+	return list, nil
+}
+
+// normalizeCIDRs parses the networks, turning single addresses into /32 or /128 networks
+func normalizeCIDRs(cidrs []string) ([]string, error) {
+	list := make([]string, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if ip := net.ParseIP(cidr); ip != nil {
+			if ip.To4() != nil {
+				cidr += "/32"
+			} else {
+				cidr += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, ErrInvalidCIDR
+		}
+		list = append(list, network.String())
+	}
+	return list, nil
+}
+
+func allowedFrom(cidrs []string, clientIP string) bool {
+	if len(cidrs) == 0 {
+		return true
+	}
+	ip := net.ParseIP(clientIP)
+	if ip == nil {
+		return false
+	}
+	for _, cidr := range cidrs {
+		if _, network, err := net.ParseCIDR(cidr); err == nil && network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// newCredentials returns a random 12 hex digit prefix and a 256-bit secret
+func newCredentials() (string, string, error) {
+	buffer := make([]byte, 6+32)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", "", fmt.Errorf("failed to generate API key: %v", err)
+	}
+	return hex.EncodeToString(buffer[:6]), strings.ToLower(secretEncoding.EncodeToString(buffer[6:])), nil
+}
+
+func formatKey(prefix, secret string) string {
+	return KeyPrefix + prefix + "_" + secret
+}
+
+func parseKey(credential string) (string, string, bool) {
+	parts := strings.SplitN(strings.TrimPrefix(credential, KeyPrefix), "_", 2)
+	if !IsAPIKey(credential) || len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// hashSecret hashes a secret; the secrets are random, so a fast hash suffices
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,38 @@
+package logingestorservice
+
+import (
+	"log"
+
+	auditservice "github.com/yatender-pareek/identity/src/services/audit-service"
+	logdto "github.com/yatender-pareek/log-ingestor-service/src/dtos/log-dto"
+	logDataentity "github.com/yatender-pareek/log-ingestor-service/src/models/log-data-model"
+)
+
+// StoreAuditEvents ingests the service's own audit events like any other logs, so that they
+// are published to the outbox and analyzed by the detection rules
+func (s *LogIngestorService) StoreAuditEvents(events []auditservice.Event) error {
+	dtos := make([]logdto.CreateLogRequest, 0, len(events))
+	for _, event := range events {
+		dto := AuditLogRequest(event)
+		if err := s.ValidateLog(dto); err != nil {
+			log.Printf("Dropping invalid %s audit event: %v", event.Action, err)
+			continue
+		}
+		dtos = append(dtos, dto)
+	}
+	_, err := s.CreateLogs(dtos)
+	return err
+}
+
+// AuditLogRequest maps an audit event onto a log
+func AuditLogRequest(event auditservice.Event) logdto.CreateLogRequest {
+	return logdto.CreateLogRequest{
+		Timestamp:  event.Timestamp,
+		UserID:     event.UserID,
+		IPAddress:  event.IPAddress,
+		Action:     event.Action,
+		Attributes: logDataentity.Attributes(event.Attributes),
+		Source:     event.Source,
+		Tenant:     event.Tenant,
+	}
+}
@@ -3,44 +3,118 @@ package logingestorservice
 import (
 	"fmt"
 	"net"
+	"strings"
 	"time"
 
+	"github.com/yatender-pareek/identity/src/tenancy"
 	mysqlconfig "github.com/yatender-pareek/log-ingestor-service/src/config/my-sql-config"
 	logdto "github.com/yatender-pareek/log-ingestor-service/src/dtos/log-dto"
 	logDataentity "github.com/yatender-pareek/log-ingestor-service/src/models/log-data-model"
+	outboxentity "github.com/yatender-pareek/log-ingestor-service/src/models/outbox-model"
+	outboxservice "github.com/yatender-pareek/log-ingestor-service/src/services/outbox-service"
 	"gorm.io/gorm"
 )
 
 type LogIngestorService struct {
+	tenant string
 }
 
 func NewLogIngestorService() *LogIngestorService {
 	return &LogIngestorService{}
 }
 
-func (s *LogIngestorService) CreateLog(dto logdto.CreateLogRequest) (*logDataentity.LogData, error) {
+// ForTenant returns a service whose every read, write and delete is limited to tenant.
+// An empty tenant, as for platform admins and background ingestion, covers all tenants.
+func (s *LogIngestorService) ForTenant(tenant string) *LogIngestorService {
+	return &LogIngestorService{tenant: tenant}
+}
+
+func (s *LogIngestorService) db() *gorm.DB {
+	return tenancy.Scoped(mysqlconfig.GetDB(), s.tenant)
+}
+
+// ValidateLog checks what struct validation cannot express
+func (s *LogIngestorService) ValidateLog(dto logdto.CreateLogRequest) error {
 	if net.ParseIP(dto.IPAddress) == nil {
-		return nil, fmt.Errorf("invalid IP address format")
+		return fmt.Errorf("invalid IP address format")
 	}
+	return dto.Attributes.Validate()
+}
 
-	logEntry := &logDataentity.LogData{
+func (s *LogIngestorService) newLogEntry(dto logdto.CreateLogRequest) *logDataentity.LogData {
+	tenant := dto.Tenant
+	if tenant == "" {
+		tenant = s.tenant
+	}
+	if tenant == "" {
+		tenant = logDataentity.DefaultTenant
+	}
+	return &logDataentity.LogData{
 		Timestamp:     dto.Timestamp,
 		UserID:        dto.UserID,
 		IPAddress:     dto.IPAddress,
 		Action:        dto.Action,
 		FileName:      dto.FileName,
 		DatabaseQuery: dto.DatabaseQuery,
+		Attributes:    dto.Attributes,
+		Source:        dto.Source,
+		Tenant:        tenant,
 	}
+}
 
-	if err := mysqlconfig.GetDB().Create(logEntry).Error; err != nil {
-		return nil, fmt.Errorf("failed to save log: %v", err)
+func (s *LogIngestorService) CreateLog(dto logdto.CreateLogRequest) (*logDataentity.LogData, error) {
+	if err := s.ValidateLog(dto); err != nil {
+		return nil, err
+	}
+
+	logEntry := s.newLogEntry(dto)
+
+	err := s.db().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(logEntry).Error; err != nil {
+			return fmt.Errorf("failed to save log: %v", err)
+		}
+		return outboxservice.Publish(tx, outboxentity.EventLogIngested, logEntry.ID, logEntry)
+	})
+	if err != nil {
+		return nil, err
 	}
 	return logEntry, nil
 }
 
+// CreateLogs stores already validated logs and their outbox events in one transaction,
+// returning the stored logs in input order
+func (s *LogIngestorService) CreateLogs(dtos []logdto.CreateLogRequest) ([]logDataentity.LogData, error) {
+	logs := make([]logDataentity.LogData, len(dtos))
+	for i, dto := range dtos {
+		logs[i] = *s.newLogEntry(dto)
+	}
+	if len(logs) == 0 {
+		return logs, nil
+	}
+
+	err := s.db().Transaction(func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(&logs, 500).Error; err != nil {
+			return fmt.Errorf("failed to save logs: %v", err)
+		}
+		events := make([]outboxentity.OutboxEvent, len(logs))
+		for i := range logs {
+			event, err := outboxservice.NewEvent(outboxentity.EventLogIngested, logs[i].ID, logs[i])
+			if err != nil {
+				return err
+			}
+			events[i] = event
+		}
+		return outboxservice.PublishAll(tx, events)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
 func (s *LogIngestorService) GetAllLogs() ([]logDataentity.LogData, error) {
 	var logs []logDataentity.LogData
-	if err := mysqlconfig.GetDB().Find(&logs).Error; err != nil {
+	if err := s.db().Find(&logs).Error; err != nil {
 		return nil, fmt.Errorf("failed to retrieve logs: %v", err)
 	}
 	return logs, nil
@@ -48,7 +122,7 @@ func (s *LogIngestorService) GetAllLogs() ([]logDataentity.LogData, error) {
 
 func (s *LogIngestorService) GetLogByID(id uint64) (*logDataentity.LogData, error) {
 	var logEntry logDataentity.LogData
-	if err := mysqlconfig.GetDB().First(&logEntry, id).Error; err != nil {
+	if err := s.db().First(&logEntry, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, fmt.Errorf("log not found")
 		}
@@ -58,7 +132,7 @@ func (s *LogIngestorService) GetLogByID(id uint64) (*logDataentity.LogData, erro
 }
 
 func (s *LogIngestorService) DeleteLogByID(id uint64) error {
-	result := mysqlconfig.GetDB().Where("id = ?", id).Delete(&logDataentity.LogData{})
+	result := s.db().Where("id = ?", id).Delete(&logDataentity.LogData{})
 	if result.Error != nil {
 		return result.Error
 	}
@@ -68,8 +142,8 @@ func (s *LogIngestorService) DeleteLogByID(id uint64) error {
 	return nil
 }
 
-func (s *LogIngestorService) SearchLogs(startTime, endTime *time.Time, source, userID *string) ([]logDataentity.LogData, error) {
-	query := mysqlconfig.GetDB().Model(&logDataentity.LogData{})
+func (s *LogIngestorService) SearchLogs(startTime, endTime *time.Time, source, ipAddress, tenant, userID *string, attributes []logdto.AttributeFilter) ([]logDataentity.LogData, error) {
+	query := s.db().Model(&logDataentity.LogData{})
 	if startTime != nil {
 		query = query.Where("timestamp >= ?", *startTime)
 	}
@@ -77,11 +151,20 @@ func (s *LogIngestorService) SearchLogs(startTime, endTime *time.Time, source, u
 		query = query.Where("timestamp <= ?", *endTime)
 	}
 	if source != nil {
-		query = query.Where("ip_address = ?", *source)
+		query = query.Where("source = ?", *source)
+	}
+	if ipAddress != nil {
+		query = query.Where("ip_address = ?", *ipAddress)
+	}
+	if tenant != nil {
+		query = query.Where("tenant = ?", *tenant)
 	}
 	if userID != nil {
 		query = query.Where("user_id = ?", *userID)
 	}
+	for _, filter := range attributes {
+		query = whereAttribute(query, filter)
+	}
 
 	var logs []logDataentity.LogData
 	if err := query.Find(&logs).Error; err != nil {
@@ -89,3 +172,31 @@ func (s *LogIngestorService) SearchLogs(startTime, endTime *time.Time, source, u
 	}
 	return logs, nil
 }
+
+// whereAttribute adds one attribute filter. Keys are validated by the caller, so they
+// can be quoted into a JSON path as is.
+func whereAttribute(query *gorm.DB, filter logdto.AttributeFilter) *gorm.DB {
+	path := `$."` + filter.Key + `"`
+	switch filter.Op {
+	case logdto.AttributeExists:
+		if filter.Present {
+			return query.Where("JSON_CONTAINS_PATH(attributes, 'one', ?)", path)
+		}
+		return query.Where("(attributes IS NULL OR NOT JSON_CONTAINS_PATH(attributes, 'one', ?))", path)
+	case logdto.AttributePrefix:
+		conditions := make([]string, len(filter.Values))
+		args := make([]interface{}, 0, 2*len(filter.Values))
+		for i, prefix := range filter.Values {
+			conditions[i] = "JSON_UNQUOTE(JSON_EXTRACT(attributes, ?)) LIKE ?"
+			args = append(args, path, escapeLike(prefix)+"%")
+		}
+		return query.Where("("+strings.Join(conditions, " OR ")+")", args...)
+	default:
+		return query.Where("JSON_UNQUOTE(JSON_EXTRACT(attributes, ?)) IN ?", path, filter.Values)
+	}
+}
+
+// escapeLike makes LIKE treat the wildcards in value literally
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
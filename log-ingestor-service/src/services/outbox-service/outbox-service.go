@@ -0,0 +1,72 @@
+package outboxservice
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	outboxentity "github.com/yatender-pareek/log-ingestor-service/src/models/outbox-model"
+	"gorm.io/gorm"
+)
+
+// NewEvent encodes payload into an event ready to be published
+func NewEvent(eventType string, aggregateID uint64, payload interface{}) (outboxentity.OutboxEvent, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return outboxentity.OutboxEvent{}, fmt.Errorf("failed to encode %s event: %v", eventType, err)
+	}
+	return outboxentity.OutboxEvent{
+		EventType:   eventType,
+		AggregateID: aggregateID,
+		Payload:     string(data),
+	}, nil
+}
+
+// Publish records an event inside tx; it is delivered once tx commits
+func Publish(tx *gorm.DB, eventType string, aggregateID uint64, payload interface{}) error {
+	event, err := NewEvent(eventType, aggregateID, payload)
+	if err != nil {
+		return err
+	}
+	return PublishAll(tx, []outboxentity.OutboxEvent{event})
+}
+
+// PublishAll records several events inside tx in the given order
+func PublishAll(tx *gorm.DB, events []outboxentity.OutboxEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	if err := tx.CreateInBatches(&events, 500).Error; err != nil {
+		return fmt.Errorf("failed to publish %s events: %v", events[0].EventType, err)
+	}
+	return nil
+}
+
+// StartPruner periodically deletes events older than OUTBOX_RETENTION (default 168h).
+// Consumers that fall further behind than the retention miss the pruned events.
+func StartPruner(db *gorm.DB) error {
+	retention := 7 * 24 * time.Hour
+	if raw := os.Getenv("OUTBOX_RETENTION"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed <= 0 {
+			return fmt.Errorf("invalid OUTBOX_RETENTION %q", raw)
+		}
+		retention = parsed
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			result := db.Where("created_at < ?", time.Now().Add(-retention)).Delete(&outboxentity.OutboxEvent{})
+			if result.Error != nil {
+				log.Printf("Failed to prune outbox events: %v", result.Error)
+			} else if result.RowsAffected > 0 {
+				log.Printf("Pruned %d outbox events", result.RowsAffected)
+			}
+		}
+	}()
+	return nil
+}
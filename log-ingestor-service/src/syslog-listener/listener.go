@@ -0,0 +1,266 @@
+package sysloglistener
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	auditservice "github.com/yatender-pareek/identity/src/services/audit-service"
+	logdto "github.com/yatender-pareek/log-ingestor-service/src/dtos/log-dto"
+	logingestorservice "github.com/yatender-pareek/log-ingestor-service/src/services/log-ingestor-service"
+)
+
+const maxMessageSize = 64 * 1024
+
+// Stats counts what happened to received messages since startup
+type Stats struct {
+	Received      uint64 `json:"received"`
+	ParseErrors   uint64 `json:"parseErrors"`
+	Invalid       uint64 `json:"invalid"`
+	Dropped       uint64 `json:"dropped"`
+	Stored        uint64 `json:"stored"`
+	StoreErrors   uint64 `json:"storeErrors"`
+	QueueLength   int    `json:"queueLength"`
+	QueueCapacity int    `json:"queueCapacity"`
+}
+
+type counters struct {
+	received, parseErrors, invalid, dropped, stored, storeErrors atomic.Uint64
+}
+
+// Listener parses messages from its sockets and hands them to workers through a
+// bounded queue. A full queue blocks TCP connections, which pushes back on senders
+// through TCP flow control, and drops UDP datagrams, which cannot be pushed back.
+type Listener struct {
+	service   *logingestorservice.LogIngestorService
+	mapping   Mapping
+	tenant    string
+	queue     chan logdto.CreateLogRequest
+	batchSize int
+	counters  counters
+}
+
+var listener *Listener
+
+// Start opens the listeners configured by SYSLOG_UDP_ADDR and SYSLOG_TCP_ADDR.
+// With neither set syslog ingestion stays off. Messages are stored under SYSLOG_TENANT.
+func Start(service *logingestorservice.LogIngestorService) error {
+	udpAddr, tcpAddr := os.Getenv("SYSLOG_UDP_ADDR"), os.Getenv("SYSLOG_TCP_ADDR")
+	if udpAddr == "" && tcpAddr == "" {
+		return nil
+	}
+
+	mapping, err := ParseMapping(os.Getenv("SYSLOG_MAPPING"))
+	if err != nil {
+		return err
+	}
+	settings := map[string]int{"SYSLOG_QUEUE_SIZE": 10000, "SYSLOG_WORKERS": 2, "SYSLOG_BATCH_SIZE": 500}
+	for name := range settings {
+		if raw := os.Getenv(name); raw != "" {
+			value, err := strconv.Atoi(raw)
+			if err != nil || value <= 0 {
+				return fmt.Errorf("invalid %s %q", name, raw)
+			}
+			settings[name] = value
+		}
+	}
+
+	l := &Listener{
+		service:   service,
+		mapping:   mapping,
+		tenant:    os.Getenv("SYSLOG_TENANT"),
+		queue:     make(chan logdto.CreateLogRequest, settings["SYSLOG_QUEUE_SIZE"]),
+		batchSize: settings["SYSLOG_BATCH_SIZE"],
+	}
+	if udpAddr != "" {
+		conn, err := net.ListenPacket("udp", udpAddr)
+		if err != nil {
+			return fmt.Errorf("failed to listen for syslog on udp %s: %v", udpAddr, err)
+		}
+		go l.serveUDP(conn)
+		log.Println("Listening for syslog on udp", udpAddr)
+	}
+	if tcpAddr != "" {
+		ln, err := net.Listen("tcp", tcpAddr)
+		if err != nil {
+			return fmt.Errorf("failed to listen for syslog on tcp %s: %v", tcpAddr, err)
+		}
+		go l.serveTCP(ln)
+		log.Println("Listening for syslog on tcp", tcpAddr)
+	}
+	for i := 0; i < settings["SYSLOG_WORKERS"]; i++ {
+		go l.work()
+	}
+	listener = l
+	return nil
+}
+
+// GetStats returns the counters of the running listener; ok is false when syslog is off
+func GetStats() (Stats, bool) {
+	if listener == nil {
+		return Stats{}, false
+	}
+	c := &listener.counters
+	return Stats{
+		Received:      c.received.Load(),
+		ParseErrors:   c.parseErrors.Load(),
+		Invalid:       c.invalid.Load(),
+		Dropped:       c.dropped.Load(),
+		Stored:        c.stored.Load(),
+		StoreErrors:   c.storeErrors.Load(),
+		QueueLength:   len(listener.queue),
+		QueueCapacity: cap(listener.queue),
+	}, true
+}
+
+func (l *Listener) serveUDP(conn net.PacketConn) {
+	buf := make([]byte, maxMessageSize)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			log.Printf("Syslog udp read failed: %v", err)
+			continue
+		}
+		peer := ""
+		if udpAddr, ok := addr.(*net.UDPAddr); ok {
+			peer = udpAddr.IP.String()
+		}
+		l.handle(string(buf[:n]), peer, false)
+	}
+}
+
+func (l *Listener) serveTCP(ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			log.Printf("Syslog tcp accept failed: %v", err)
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		go l.serveConn(conn)
+	}
+}
+
+// serveConn reads octet-counted frames ("LEN SP MSG", RFC 6587) and falls back to
+// newline-delimited messages for senders that don't count octets
+func (l *Listener) serveConn(conn net.Conn) {
+	defer conn.Close()
+	peer := ""
+	if tcpAddr, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
+		peer = tcpAddr.IP.String()
+	}
+	reader := bufio.NewReaderSize(conn, maxMessageSize)
+	for {
+		raw, err := readFrame(reader)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				log.Printf("Syslog tcp connection from %s closed: %v", peer, err)
+			}
+			return
+		}
+		if raw != "" {
+			l.handle(raw, peer, true)
+		}
+	}
+}
+
+func readFrame(reader *bufio.Reader) (string, error) {
+	first, err := reader.Peek(1)
+	if err != nil {
+		return "", err
+	}
+	if first[0] < '0' || first[0] > '9' {
+		line, err := reader.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return "", err
+		}
+		return line, nil
+	}
+
+	prefix, err := reader.ReadString(' ')
+	if err != nil {
+		return "", err
+	}
+	length, err := strconv.Atoi(prefix[:len(prefix)-1])
+	if err != nil || length <= 0 || length > maxMessageSize {
+		return "", fmt.Errorf("invalid octet count %q", prefix)
+	}
+	frame := make([]byte, length)
+	if _, err := io.ReadFull(reader, frame); err != nil {
+		return "", err
+	}
+	return string(frame), nil
+}
+
+// handle maps a message onto a log and queues it; block selects backpressure over dropping
+func (l *Listener) handle(raw, peer string, block bool) {
+	l.counters.received.Add(1)
+	now := time.Now()
+	msg, err := Parse(raw, now)
+	if err != nil {
+		l.counters.parseErrors.Add(1)
+		return
+	}
+
+	logDto := logdto.CreateLogRequest{
+		Timestamp: msg.Timestamp,
+		UserID:    l.mapping.Resolve("userId", &msg, peer),
+		IPAddress: l.mapping.Resolve("ipAddress", &msg, peer),
+		Action:    l.mapping.Resolve("action", &msg, peer),
+		Source:    l.mapping.Resolve("source", &msg, peer),
+		Tenant:    l.tenant,
+	}
+	if logDto.Timestamp.IsZero() {
+		logDto.Timestamp = now
+	}
+	if fileName := l.mapping.Resolve("fileName", &msg, peer); fileName != "" {
+		logDto.FileName = &fileName
+	}
+	if databaseQuery := l.mapping.Resolve("databaseQuery", &msg, peer); databaseQuery != "" {
+		logDto.DatabaseQuery = &databaseQuery
+	}
+	// Audit sources are reserved for the services' own events; a sender cannot claim one
+	if err := l.service.ValidateLog(logDto); err != nil || auditservice.IsAuditSource(logDto.Source) {
+		l.counters.invalid.Add(1)
+		return
+	}
+
+	if block {
+		l.queue <- logDto
+		return
+	}
+	select {
+	case l.queue <- logDto:
+	default:
+		l.counters.dropped.Add(1)
+	}
+}
+
+// work stores queued logs in batches of up to batchSize
+func (l *Listener) work() {
+	for first := range l.queue {
+		batch := []logdto.CreateLogRequest{first}
+	drain:
+		for len(batch) < l.batchSize {
+			select {
+			case next := <-l.queue:
+				batch = append(batch, next)
+			default:
+				break drain
+			}
+		}
+		if _, err := l.service.CreateLogs(batch); err != nil {
+			l.counters.storeErrors.Add(uint64(len(batch)))
+			log.Printf("Failed to store %d syslog messages: %v", len(batch), err)
+			continue
+		}
+		l.counters.stored.Add(uint64(len(batch)))
+	}
+}
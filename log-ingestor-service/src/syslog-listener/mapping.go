@@ -0,0 +1,108 @@
+package sysloglistener
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultMapping fills each log field from the first source with a value
+const DefaultMapping = "userId=sd:user|sd:uid|app_name,ipAddress=sd:src|hostname|peer," +
+	"action=msg_id|app_name,fileName=sd:file,databaseQuery=sd:query,source=hostname|peer"
+
+var mappedFields = map[string]bool{
+	"userId": true, "ipAddress": true, "action": true, "fileName": true, "databaseQuery": true, "source": true,
+}
+
+var plainSources = map[string]bool{
+	"hostname": true, "app_name": true, "proc_id": true, "msg_id": true,
+	"message": true, "facility": true, "severity": true, "peer": true,
+}
+
+// Mapping lists, per log field, the message parts to take its value from in order of preference.
+// A source is one of hostname, app_name, proc_id, msg_id, message, facility, severity, peer
+// (the sender's address), sd:PARAM (a parameter of any structured data element) or
+// sd:SD-ID:PARAM (a parameter of one element).
+type Mapping map[string][]string
+
+// ParseMapping reads a mapping such as "userId=sd:user|app_name,action=msg_id".
+// Fields left out keep their default sources.
+func ParseMapping(spec string) (Mapping, error) {
+	mapping := Mapping{}
+	for _, source := range []string{DefaultMapping, spec} {
+		for _, entry := range strings.Split(source, ",") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+			field, sources, ok := strings.Cut(entry, "=")
+			if !ok || !mappedFields[field] {
+				return nil, fmt.Errorf("invalid syslog mapping entry %q", entry)
+			}
+			var parsed []string
+			for _, s := range strings.Split(sources, "|") {
+				s = strings.TrimSpace(s)
+				if !plainSources[s] && (!strings.HasPrefix(s, "sd:") || len(s) == 3) {
+					return nil, fmt.Errorf("unknown syslog source %q for %s", s, field)
+				}
+				parsed = append(parsed, s)
+			}
+			mapping[field] = parsed
+		}
+	}
+	return mapping, nil
+}
+
+// Resolve returns the first non-empty value for field. ipAddress only accepts valid IPs.
+func (m Mapping) Resolve(field string, msg *Message, peer string) string {
+	for _, source := range m[field] {
+		value := sourceValue(source, msg, peer)
+		if value == "" {
+			continue
+		}
+		if field == "ipAddress" && net.ParseIP(value) == nil {
+			continue
+		}
+		return value
+	}
+	return ""
+}
+
+func sourceValue(source string, msg *Message, peer string) string {
+	switch source {
+	case "hostname":
+		return msg.Hostname
+	case "app_name":
+		return msg.AppName
+	case "proc_id":
+		return msg.ProcID
+	case "msg_id":
+		return msg.MsgID
+	case "message":
+		return msg.Text
+	case "facility":
+		return strconv.Itoa(msg.Facility)
+	case "severity":
+		return strconv.Itoa(msg.Severity)
+	case "peer":
+		return peer
+	}
+
+	param := strings.TrimPrefix(source, "sd:")
+	if separator := strings.LastIndexByte(param, ':'); separator >= 0 {
+		return msg.StructuredData[param[:separator]][param[separator+1:]]
+	}
+	ids := make([]string, 0, len(msg.StructuredData))
+	for id := range msg.StructuredData {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if value, ok := msg.StructuredData[id][param]; ok {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,180 @@
+// Package sysloglistener receives RFC 5424 and RFC 3164 syslog messages over UDP and TCP
+// and stores them as logs through the log ingestor service
+package sysloglistener
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Message is a parsed syslog message. Fields a message doesn't carry are empty;
+// Timestamp is the zero time when the message has none.
+type Message struct {
+	Facility       int
+	Severity       int
+	Timestamp      time.Time
+	Hostname       string
+	AppName        string
+	ProcID         string
+	MsgID          string
+	StructuredData map[string]map[string]string
+	Text           string
+}
+
+// Parse detects the syslog format from the header and parses the message
+func Parse(raw string, now time.Time) (Message, error) {
+	raw = strings.TrimRight(raw, "\r\n\x00")
+	priority, rest, err := parsePriority(raw)
+	if err != nil {
+		return Message{}, err
+	}
+	msg := Message{Facility: priority / 8, Severity: priority % 8}
+	if strings.HasPrefix(rest, "1 ") {
+		err = parseRFC5424(&msg, rest[2:])
+	} else {
+		err = parseRFC3164(&msg, rest, now)
+	}
+	return msg, err
+}
+
+func parsePriority(raw string) (int, string, error) {
+	if !strings.HasPrefix(raw, "<") {
+		return 0, "", fmt.Errorf("missing priority")
+	}
+	end := strings.IndexByte(raw, '>')
+	if end < 2 || end > 4 {
+		return 0, "", fmt.Errorf("invalid priority")
+	}
+	priority, err := strconv.Atoi(raw[1:end])
+	if err != nil || priority < 0 || priority > 191 {
+		return 0, "", fmt.Errorf("invalid priority %q", raw[1:end])
+	}
+	return priority, raw[end+1:], nil
+}
+
+// parseRFC5424 parses TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA [MSG]
+func parseRFC5424(msg *Message, rest string) error {
+	fields := make([]string, 5)
+	for i := range fields {
+		space := strings.IndexByte(rest, ' ')
+		if space < 0 {
+			return fmt.Errorf("truncated RFC 5424 header")
+		}
+		fields[i], rest = rest[:space], rest[space+1:]
+	}
+	if fields[0] != "-" {
+		timestamp, err := time.Parse(time.RFC3339Nano, fields[0])
+		if err != nil {
+			return fmt.Errorf("invalid RFC 5424 timestamp %q", fields[0])
+		}
+		msg.Timestamp = timestamp
+	}
+	msg.Hostname = nilValue(fields[1])
+	msg.AppName = nilValue(fields[2])
+	msg.ProcID = nilValue(fields[3])
+	msg.MsgID = nilValue(fields[4])
+
+	data, text, err := parseStructuredData(rest)
+	if err != nil {
+		return err
+	}
+	msg.StructuredData = data
+	msg.Text = strings.TrimPrefix(text, "\xEF\xBB\xBF")
+	return nil
+}
+
+func nilValue(field string) string {
+	if field == "-" {
+		return ""
+	}
+	return field
+}
+
+// parseStructuredData parses "-" or a sequence of [SD-ID PARAM="VALUE" ...] elements
+// and returns what follows them as the free-form message
+func parseStructuredData(rest string) (map[string]map[string]string, string, error) {
+	if strings.HasPrefix(rest, "-") {
+		return nil, strings.TrimPrefix(rest[1:], " "), nil
+	}
+	data := make(map[string]map[string]string)
+	for strings.HasPrefix(rest, "[") {
+		i := 1
+		for i < len(rest) && rest[i] != ' ' && rest[i] != ']' {
+			i++
+		}
+		if i == len(rest) {
+			return nil, "", fmt.Errorf("unterminated structured data")
+		}
+		id := rest[1:i]
+		params := make(map[string]string)
+		for rest[i] == ' ' {
+			i++
+			eq := strings.IndexByte(rest[i:], '=')
+			if eq < 0 || i+eq+1 >= len(rest) || rest[i+eq+1] != '"' {
+				return nil, "", fmt.Errorf("invalid structured data parameter in %s", id)
+			}
+			name := rest[i : i+eq]
+			i += eq + 2
+			var value strings.Builder
+			for ; i < len(rest) && rest[i] != '"'; i++ {
+				if rest[i] == '\\' && i+1 < len(rest) && strings.IndexByte(`"\]`, rest[i+1]) >= 0 {
+					i++
+				}
+				value.WriteByte(rest[i])
+			}
+			if i == len(rest) {
+				return nil, "", fmt.Errorf("unterminated structured data value in %s", id)
+			}
+			params[name] = value.String()
+			i++
+			if i == len(rest) {
+				return nil, "", fmt.Errorf("unterminated structured data element %s", id)
+			}
+		}
+		if rest[i] != ']' {
+			return nil, "", fmt.Errorf("invalid structured data element %s", id)
+		}
+		data[id] = params
+		rest = rest[i+1:]
+	}
+	if rest != "" && !strings.HasPrefix(rest, " ") {
+		return nil, "", fmt.Errorf("invalid structured data")
+	}
+	return data, strings.TrimPrefix(rest, " "), nil
+}
+
+// parseRFC3164 parses the BSD format "Mmm dd hh:mm:ss HOSTNAME TAG[PID]: MSG".
+// The timestamp has no year or zone, so the receiver's are assumed.
+func parseRFC3164(msg *Message, rest string, now time.Time) error {
+	if len(rest) >= 16 && rest[15] == ' ' {
+		if timestamp, err := time.ParseInLocation(time.Stamp, rest[:15], now.Location()); err == nil {
+			timestamp = timestamp.AddDate(now.Year(), 0, 0)
+			// A December message received in January belongs to the previous year
+			if timestamp.After(now.Add(24 * time.Hour)) {
+				timestamp = timestamp.AddDate(-1, 0, 0)
+			}
+			msg.Timestamp = timestamp
+			rest = rest[16:]
+		}
+	}
+
+	// The hostname is optional; a tag ends with ':' or '['
+	if space := strings.IndexByte(rest, ' '); space > 0 && !strings.ContainsAny(rest[:space], ":[") {
+		msg.Hostname, rest = rest[:space], rest[space+1:]
+	}
+	tagEnd := strings.IndexAny(rest, ":[ ")
+	if tagEnd > 0 && (rest[tagEnd] == ':' || rest[tagEnd] == '[') {
+		msg.AppName = rest[:tagEnd]
+		rest = rest[tagEnd:]
+		if rest[0] == '[' {
+			if closing := strings.IndexByte(rest, ']'); closing > 0 {
+				msg.ProcID, rest = rest[1:closing], rest[closing+1:]
+			}
+		}
+		rest = strings.TrimPrefix(rest, ":")
+	}
+	msg.Text = strings.TrimPrefix(rest, " ")
+	return nil
+}
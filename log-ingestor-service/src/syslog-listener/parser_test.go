@@ -0,0 +1,142 @@
+package sysloglistener
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	now := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		raw     string
+		want    Message
+		wantErr bool
+	}{
+		{
+			name: "RFC 5424 with structured data",
+			raw:  `<34>1 2024-01-02T10:00:00.5Z web01 sshd 42 login_failed [auth@1 user="alice" src="10.0.0.1"] bad password` + "\n",
+			want: Message{
+				Facility: 4, Severity: 2,
+				Timestamp: time.Date(2024, 1, 2, 10, 0, 0, 500000000, time.UTC),
+				Hostname:  "web01", AppName: "sshd", ProcID: "42", MsgID: "login_failed",
+				StructuredData: map[string]map[string]string{"auth@1": {"user": "alice", "src": "10.0.0.1"}},
+				Text:           "bad password",
+			},
+		},
+		{
+			name: "RFC 5424 with nil values, escapes and a BOM",
+			raw:  `<13>1 - - - - - [a@1 q="say \"hi\" \] \\"][b@1 x="y"] ` + "\xEF\xBB\xBFhello",
+			want: Message{
+				Facility: 1, Severity: 5,
+				StructuredData: map[string]map[string]string{"a@1": {"q": `say "hi" ] \`}, "b@1": {"x": "y"}},
+				Text:           "hello",
+			},
+		},
+		{
+			name: "RFC 5424 without structured data",
+			raw:  "<165>1 2024-01-02T10:00:00Z host app - ID47 - message text",
+			want: Message{
+				Facility: 20, Severity: 5,
+				Timestamp: time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC),
+				Hostname:  "host", AppName: "app", MsgID: "ID47",
+				Text: "message text",
+			},
+		},
+		{
+			name: "RFC 3164 with hostname and pid",
+			raw:  "<38>Jan  2 09:15:00 web01 sshd[123]: Accepted password for alice",
+			want: Message{
+				Facility: 4, Severity: 6,
+				Timestamp: time.Date(2024, 1, 2, 9, 15, 0, 0, time.UTC),
+				Hostname:  "web01", AppName: "sshd", ProcID: "123",
+				Text: "Accepted password for alice",
+			},
+		},
+		{
+			name: "RFC 3164 without hostname",
+			raw:  "<38>Jan  2 09:15:00 cron: job done",
+			want: Message{
+				Facility: 4, Severity: 6,
+				Timestamp: time.Date(2024, 1, 2, 9, 15, 0, 0, time.UTC),
+				AppName:   "cron",
+				Text:      "job done",
+			},
+		},
+		{
+			name: "RFC 3164 from December received in January",
+			raw:  "<38>Dec 31 23:59:00 web01 app: late",
+			want: Message{
+				Facility: 4, Severity: 6,
+				Timestamp: time.Date(2023, 12, 31, 23, 59, 0, 0, time.UTC),
+				Hostname:  "web01", AppName: "app",
+				Text: "late",
+			},
+		},
+		{
+			name: "RFC 3164 without timestamp or tag",
+			raw:  "<13>web01 disk full",
+			want: Message{Facility: 1, Severity: 5, Hostname: "web01", Text: "disk full"},
+		},
+		{name: "missing priority", raw: "hello", wantErr: true},
+		{name: "priority out of range", raw: "<192>1 - - - - - -", wantErr: true},
+		{name: "truncated RFC 5424 header", raw: "<13>1 2024-01-02T10:00:00Z host", wantErr: true},
+		{name: "invalid RFC 5424 timestamp", raw: "<13>1 yesterday host app - - -", wantErr: true},
+		{name: "unterminated structured data", raw: `<13>1 - - - - - [a@1 q="x"`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.raw, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got  %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMappingResolve(t *testing.T) {
+	mapping, err := ParseMapping("action=sd:meta:event|msg_id")
+	if err != nil {
+		t.Fatalf("ParseMapping: %v", err)
+	}
+	msg := &Message{
+		Hostname: "not-an-ip",
+		AppName:  "sshd",
+		MsgID:    "login",
+		StructuredData: map[string]map[string]string{
+			"auth@1": {"user": "alice"},
+			"meta":   {"event": "login_failed"},
+		},
+	}
+	tests := []struct {
+		field string
+		want  string
+	}{
+		{"userId", "alice"},
+		{"ipAddress", "10.0.0.9"},
+		{"action", "login_failed"},
+		{"source", "not-an-ip"},
+		{"fileName", ""},
+	}
+	for _, tt := range tests {
+		if got := mapping.Resolve(tt.field, msg, "10.0.0.9"); got != tt.want {
+			t.Errorf("Resolve(%s) = %q, want %q", tt.field, got, tt.want)
+		}
+	}
+
+	for _, spec := range []string{"user=app_name", "userId=nowhere", "userId=sd:"} {
+		if _, err := ParseMapping(spec); err == nil {
+			t.Errorf("ParseMapping(%q) should fail", spec)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// Package auditshipper sends the service's audit events to the log ingestor, which stores them like any
+// other logs so the detection rules analyze them
+package auditshipper
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	auditservice "github.com/yatender-pareek/identity/src/services/audit-service"
+)
+
+// logEntry is an entry of the log ingestor's POST /logs/batch
+type logEntry struct {
+	Timestamp  time.Time         `json:"timestamp"`
+	UserID     string            `json:"userId"`
+	IPAddress  string            `json:"ipAddress"`
+	Action     string            `json:"action"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Source     string            `json:"source"`
+	Tenant     string            `json:"tenant"`
+}
+
+type shipper struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+// Start ships audit events to the batch endpoint AUDIT_INGEST_URL with the API key AUDIT_INGEST_API_KEY,
+// which needs the audit:write scope that lets it use the reserved audit sources; only platform admins can
+// create such keys. Events are stored under the user's tenant, like the log ingestor's own, or under
+// AUDIT_TENANT when set. Without both settings audit events are not recorded.
+func Start() error {
+	url, apiKey := os.Getenv("AUDIT_INGEST_URL"), os.Getenv("AUDIT_INGEST_API_KEY")
+	if url == "" || apiKey == "" {
+		log.Println("Audit events are not shipped: AUDIT_INGEST_URL and AUDIT_INGEST_API_KEY are not both set")
+		return nil
+	}
+	s := &shipper{url: url, apiKey: apiKey, client: &http.Client{Timeout: 10 * time.Second}}
+	return auditservice.Start("threat-analyzer-service", s.ship)
+}
+
+// ship posts a batch of events; entries the ingestor rejects are logged, not retried
+func (s *shipper) ship(events []auditservice.Event) error {
+	entries := make([]logEntry, len(events))
+	for i, event := range events {
+		entries[i] = logEntry{
+			Timestamp:  event.Timestamp,
+			UserID:     event.UserID,
+			IPAddress:  event.IPAddress,
+			Action:     event.Action,
+			Attributes: event.Attributes,
+			Source:     event.Source,
+			Tenant:     event.Tenant,
+		}
+	}
+	body, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit events: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.url+"?mode=partial", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", s.apiKey)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach the log ingestor: %v", err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusCreated:
+		return nil
+	case http.StatusMultiStatus:
+		log.Printf("The log ingestor rejected some audit events")
+		return nil
+	default:
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("log ingestor answered %s: %s", resp.Status, bytes.TrimSpace(detail))
+	}
+}
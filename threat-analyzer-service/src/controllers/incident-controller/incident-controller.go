@@ -0,0 +1,189 @@
+package incidentcontroller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	auditservice "github.com/yatender-pareek/identity/src/services/audit-service"
+	"github.com/yatender-pareek/identity/src/tenancy"
+	incidentdto "github.com/yatender-pareek/threat-analyzer-service/src/dto/incident-dto"
+	incidentservice "github.com/yatender-pareek/threat-analyzer-service/src/services/incident-service"
+	"gorm.io/gorm"
+)
+
+var incidentService = incidentservice.NewIncidentService()
+
+// incidentServiceFor limits the incident service to the caller's tenant
+func incidentServiceFor(c *gin.Context) *incidentservice.IncidentService {
+	return incidentService.ForTenant(tenancy.CallerOf(c).ScopeTenant())
+}
+
+// GetAllIncidents godoc
+// @Summary Retrieve all incidents
+// @Description Fetches all incidents, most recently active first
+// @Tags Incidents
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} incidententity.Incident "List of incidents"
+// @Failure 403 {object} map[string]string "Role lacks the read permission"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /api/incidents [get]
+func GetAllIncidents(c *gin.Context) {
+	incidents, err := incidentServiceFor(c).GetAllIncidents()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, incidents)
+}
+
+// GetIncidentByID godoc
+// @Summary Retrieve a specific incident
+// @Description Fetches an incident by its ID together with its threats
+// @Tags Incidents
+// @Produce json
+// @Security BearerAuth
+// @Param incidentId path int true "Incident ID"
+// @Success 200 {object} incidentservice.IncidentDetails "Incident details"
+// @Failure 400 {object} map[string]string "Invalid incident ID"
+// @Failure 403 {object} map[string]string "Role lacks the read permission"
+// @Failure 404 {object} map[string]string "Incident not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /api/incidents/{incidentId} [get]
+func GetIncidentByID(c *gin.Context) {
+	incidentID, err := strconv.ParseUint(c.Param("incidentId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "error in parse incident id"})
+		return
+	}
+	incident, err := incidentServiceFor(c).GetIncidentByID(incidentID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, incident)
+}
+
+// CreateIncident godoc
+// @Summary Create an incident
+// @Description Opens a manual incident and moves the given threats into it
+// @Tags Incidents
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body incidentdto.CreateIncidentRequest true "Incident title and threats"
+// @Success 201 {object} incidentservice.IncidentDetails "Created incident"
+// @Failure 400 {object} map[string]string "Invalid request"
+// @Failure 403 {object} map[string]string "Role lacks the analyze permission"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /api/incidents [post]
+func CreateIncident(c *gin.Context) {
+	var req incidentdto.CreateIncidentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	incident, err := incidentServiceFor(c).CreateIncident(req.Title, req.ThreatIDs)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, incident)
+}
+
+// UpdateIncident godoc
+// @Summary Update an incident
+// @Description Renames an incident and/or moves more threats into it
+// @Tags Incidents
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param incidentId path int true "Incident ID"
+// @Param request body incidentdto.UpdateIncidentRequest true "Fields to update"
+// @Success 200 {object} incidentservice.IncidentDetails "Updated incident"
+// @Failure 400 {object} map[string]string "Invalid request"
+// @Failure 403 {object} map[string]string "Role lacks the analyze permission"
+// @Failure 404 {object} map[string]string "Incident not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /api/incidents/{incidentId} [patch]
+func UpdateIncident(c *gin.Context) {
+	incidentID, err := strconv.ParseUint(c.Param("incidentId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "error in parse incident id"})
+		return
+	}
+	var req incidentdto.UpdateIncidentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	incident, err := incidentServiceFor(c).UpdateIncident(incidentID, req.Title, req.ThreatIDs)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, incident)
+}
+
+// DeleteIncidentByID godoc
+// @Summary Delete a specific incident
+// @Description Deletes an incident and its threats; the threats' evidence, comments and history are kept as for DELETE /api/threats/{threatId}
+// @Tags Incidents
+// @Produce json
+// @Security BearerAuth
+// @Param incidentId path int true "Incident ID"
+// @Success 204 {object} nil "incident deleted successfully"
+// @Failure 403 {object} map[string]string "Role lacks the delete permission"
+// @Failure 404 {object} map[string]string "incident not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /api/incidents/{incidentId} [delete]
+func DeleteIncidentByID(c *gin.Context) {
+	incidentID, err := strconv.ParseUint(c.Param("incidentId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "error in parse incident id"})
+		return
+	}
+	if err := incidentServiceFor(c).DeleteIncidentByID(incidentID, c.GetString("username")); err != nil {
+		respondError(c, err)
+		return
+	}
+	auditservice.RecordRequest(c, auditservice.ActionIncidentDeleted, "target", c.Param("incidentId"))
+	c.Status(http.StatusNoContent)
+}
+
+// SearchIncidents godoc
+// @Summary Search incidents
+// @Description Searches incidents by rule, user, tenant, severity or activity time range
+// @Tags Incidents
+// @Produce json
+// @Security BearerAuth
+// @Param ruleId query string false "Rule ID"
+// @Param user query string false "User ID"
+// @Param tenant query string false "Tenant"
+// @Param severity query string false "Severity"
+// @Param startTime query string false "Active at or after (RFC3339)" format:"date-time"
+// @Param endTime query string false "Active at or before (RFC3339)" format:"date-time"
+// @Success 200 {array} incidententity.Incident "List of matching incidents"
+// @Failure 403 {object} map[string]string "Role lacks the read permission"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /api/incidents/search [get]
+func SearchIncidents(c *gin.Context) {
+	incidents, err := incidentServiceFor(c).SearchIncidents(c.Query("ruleId"), c.Query("user"), c.Query("tenant"), c.Query("severity"), c.Query("startTime"), c.Query("endTime"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, incidents)
+}
+
+func respondError(c *gin.Context, err error) {
+	switch err {
+	case gorm.ErrRecordNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Incident not found"})
+	case incidentservice.ErrUnknownThreat, incidentservice.ErrMixedTenants:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,50 @@
+package rulecontroller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	ruleengine "github.com/yatender-pareek/threat-analyzer-service/src/rule-engine"
+)
+
+// GetRules godoc
+// @Summary List detection rules
+// @Description Returns the detection rules currently loaded by the rule engine
+// @Tags Rules
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} ruleengine.Rule "Loaded rules"
+// @Failure 403 {object} map[string]string "Role lacks the read permission"
+// @Failure 500 {object} map[string]string "Rules not loaded"
+// @Router /api/rules [get]
+func GetRules(c *gin.Context) {
+	rules, err := ruleengine.GetRules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rules)
+}
+
+// ReloadRules godoc
+// @Summary Reload detection rules
+// @Description Re-reads the built-in rules and the rule files in THREAT_RULES_DIR. Platform admins only.
+// @Tags Rules
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} ruleengine.Rule "Reloaded rules"
+// @Failure 400 {object} map[string]string "Invalid rule file"
+// @Failure 403 {object} map[string]string "Platform admin role required"
+// @Failure 500 {object} map[string]string "Rules not loaded"
+// @Router /api/rules/reload [post]
+func ReloadRules(c *gin.Context) {
+	if err := ruleengine.Reload(); err != nil {
+		status := http.StatusBadRequest
+		if err == ruleengine.ErrNotInitialized {
+			status = http.StatusInternalServerError
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	GetRules(c)
+}
@@ -7,33 +7,38 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	auditservice "github.com/yatender-pareek/identity/src/services/audit-service"
+	"github.com/yatender-pareek/identity/src/tenancy"
 	threatanalyzerresquest "github.com/yatender-pareek/threat-analyzer-service/src/dto/threat-analyzer-resquest"
 	services "github.com/yatender-pareek/threat-analyzer-service/src/services/threat-service"
+	"github.com/yatender-pareek/threat-analyzer-service/src/utility"
 	"gorm.io/gorm"
 )
 
-// AnalyzeThreatRequest represents the request body for the analyze endpoint
-type AnalyzeThreatRequest struct {
-	Start time.Time `json:"start" binding:"required" example:"2025-03-26T00:00:00Z" format:"date-time"`
-	End   time.Time `json:"end" binding:"required" example:"2025-03-27T00:00:00Z" format:"date-time"`
-}
-
 var threatService *services.ThreatService
 
 func InitController() {
 	threatService = services.NewThreatService()
 }
 
+// threatServiceFor limits the threat service to the caller's tenant
+func threatServiceFor(c *gin.Context) *services.ThreatService {
+	return threatService.ForTenant(tenancy.CallerOf(c).ScopeTenant())
+}
+
 // AnalyzeThreats godoc
 // @Summary Analyze logs for threats
-// @Description Analyzes logs within the specified time range and detects threats
+// @Description Analyzes logs within the specified time range and detects threats. Each rule also reads the history it needs before startTime.
+// @Description Without startTime and endTime only logs stored since each rule's checkpoint are analyzed; with only one of them the other defaults to today.
+// @Description Callers other than platform admins analyze only their tenant's logs, and such runs do not advance the checkpoints.
 // @Tags Threats
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param request body AnalyzeThreatRequest true "Start and end time for log analysis"
-// @Success 200 {array} threatentity.Threat "List of detected threats"
+// @Param request body threatanalyzerresquest.AnalyzeThreatRequest true "Start and end time for log analysis"
+// @Success 200 {object} threatanalyzerresquest.AnalyzeThreatResponse "New and already known threat counts"
 // @Failure 400 {object} map[string]string "Invalid request"
+// @Failure 403 {object} map[string]string "Role lacks the analyze permission"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /api/threats/analyze [post]
 func AnalyzeThreats(c *gin.Context) {
@@ -43,6 +48,16 @@ func AnalyzeThreats(c *gin.Context) {
 		return
 	}
 
+	if req.StartTime == nil && req.EndTime == nil {
+		threatsResult, err := threatServiceFor(c).AnalyzeNewLogs()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, analyzeResponse(threatsResult))
+		return
+	}
+
 	now := time.Now()
 	defaultStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 	defaultEnd := time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 0, now.Location())
@@ -62,18 +77,83 @@ func AnalyzeThreats(c *gin.Context) {
 		return
 	}
 
-	threatsResult, err := threatService.AnalyzeThreats(req.StartTime, req.EndTime)
+	threatsResult, err := threatServiceFor(c).AnalyzeThreats(startTime, endTime)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, analyzeResponse(threatsResult))
+}
+
+func analyzeResponse(result utility.AnalysisResult) threatanalyzerresquest.AnalyzeThreatResponse {
+	response := threatanalyzerresquest.AnalyzeThreatResponse{
+		Message:      "No threats detected",
+		NewThreats:   result.NewThreats,
+		KnownThreats: result.KnownThreats,
+	}
+	if result.NewThreats > 0 || result.KnownThreats > 0 {
+		response.Message = fmt.Sprintf("%d new threats detected, %d already known", result.NewThreats, result.KnownThreats)
+	}
+	return response
+}
+
+// GetCheckpoints godoc
+// @Summary List analysis checkpoints
+// @Description Returns the last log each rule has analyzed incrementally. Platform admins only.
+// @Tags Threats
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} checkpointentity.AnalysisCheckpoint "Checkpoints"
+// @Failure 403 {object} map[string]string "Platform admin role required"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /api/threats/checkpoints [get]
+func GetCheckpoints(c *gin.Context) {
+	checkpoints, err := threatService.GetCheckpoints()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	c.JSON(http.StatusOK, checkpoints)
+}
 
-	if threatsResult == 0 {
-		c.JSON(http.StatusOK, gin.H{"message": "No threats detected"})
+// ResetCheckpoints godoc
+// @Summary Reset or rewind analysis checkpoints
+// @Description Rewinds the checkpoint of one rule (or all rules) to a log ID or timestamp. With neither, checkpoints are cleared and the next analysis re-reads all history. Platform admins only.
+// @Tags Threats
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body threatanalyzerresquest.ResetCheckpointRequest false "Rule and position to rewind to"
+// @Success 200 {array} checkpointentity.AnalysisCheckpoint "Checkpoints after the reset"
+// @Failure 400 {object} map[string]string "Invalid request"
+// @Failure 403 {object} map[string]string "Platform admin role required"
+// @Failure 404 {object} map[string]string "Rule not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /api/threats/checkpoints/reset [post]
+func ResetCheckpoints(c *gin.Context) {
+	var req threatanalyzerresquest.ResetCheckpointRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.LogID != nil && req.Timestamp != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "provide either logId or timestamp, not both"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Threat %d detected!!", threatsResult)})
+	checkpoints, err := threatService.ResetCheckpoints(req.RuleID, req.LogID, req.Timestamp)
+	if err != nil {
+		if err == services.ErrUnknownRule {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Rule not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, checkpoints)
 }
 
 // GetAllThreats godoc
@@ -83,10 +163,11 @@ func AnalyzeThreats(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Success 200 {array} threatentity.Threat "List of threats"
+// @Failure 403 {object} map[string]string "Role lacks the read permission"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /api/threats [get]
 func GetAllThreats(c *gin.Context) {
-	threats, err := threatService.GetAllThreats()
+	threats, err := threatServiceFor(c).GetAllThreats()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -97,12 +178,14 @@ func GetAllThreats(c *gin.Context) {
 
 // GetThreatByID godoc
 // @Summary Retrieve a specific threat
-// @Description Fetches a threat by its ID
+// @Description Fetches a threat by its ID with its evidence log IDs, comments and history in chronological order
 // @Tags Threats
 // @Produce json
 // @Security BearerAuth
 // @Param threatId path int true "Threat ID"
+// @Param expand query string false "Set to logs to include each evidence log row inline"
 // @Success 200 {object} threatentity.Threat "Threat details"
+// @Failure 403 {object} map[string]string "Role lacks the read permission"
 // @Failure 404 {object} map[string]string "Threat not found"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /api/threats/{threatId} [get]
@@ -113,7 +196,7 @@ func GetThreatByID(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "error in parse threat id"})
 		return
 	}
-	threat, err := threatService.GetThreatByID(threatID)
+	threat, err := threatServiceFor(c).GetThreatByID(threatID, c.Query("expand") == "logs")
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Threat not found"})
@@ -126,14 +209,60 @@ func GetThreatByID(c *gin.Context) {
 	c.JSON(http.StatusOK, threat)
 }
 
+// UpdateThreat godoc
+// @Summary Triage a threat
+// @Description Changes a threat's status, assignee or resolution and/or adds an analyst comment. Every change is recorded in the threat's history.
+// @Description Statuses are open, acknowledged, investigating, resolved and false_positive; the last two need a resolution. An assigneeId of 0 unassigns the threat.
+// @Tags Threats
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param threatId path int true "Threat ID"
+// @Param request body threatanalyzerresquest.UpdateThreatRequest true "Triage changes"
+// @Success 200 {object} threatentity.Threat "Updated threat with comments and history"
+// @Failure 400 {object} map[string]string "Invalid request"
+// @Failure 403 {object} map[string]string "Role lacks the analyze permission"
+// @Failure 404 {object} map[string]string "Threat not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /api/threats/{threatId} [patch]
+func UpdateThreat(c *gin.Context) {
+	threatIDStr := c.Param("threatId")
+	threatID, err := strconv.ParseUint(threatIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "error in parse threat id"})
+		return
+	}
+	var req threatanalyzerresquest.UpdateThreatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	threat, err := threatServiceFor(c).UpdateThreat(threatID, req, c.GetString("username"))
+	if err != nil {
+		switch err {
+		case gorm.ErrRecordNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Threat not found"})
+		case services.ErrUnknownAssignee, services.ErrResolutionRequired:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, threat)
+}
+
 // DeletethreatByID godoc
 // @Summary Delete a specific threat
-// @Description Deletes a threat by its ID
+// @Description Deletes a threat by its ID. The threat is hidden from every endpoint but its evidence,
+// @Description comments and history are kept, the deletion recorded in the history; later detections don't restore it.
 // @Tags Threats
 // @Produce json
 // @Security BearerAuth
 // @Param threatId path int true "threat ID"
 // @Success 204 {object} nil "threat deleted successfully"
+// @Failure 403 {object} map[string]string "Role lacks the delete permission"
 // @Failure 404 {object} map[string]string "threat not found"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /api/threats/{threatId} [delete]
@@ -144,7 +273,7 @@ func DeletethreatByID(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "error in parse threat id"})
 		return
 	}
-	err = threatService.DeleteThreatByID(threatID)
+	err = threatServiceFor(c).DeleteThreatByID(threatID, c.GetString("username"))
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "threat not found"})
@@ -153,29 +282,39 @@ func DeletethreatByID(c *gin.Context) {
 		}
 		return
 	}
-
+	auditservice.RecordRequest(c, auditservice.ActionThreatDeleted, "target", threatIDStr)
+	c.Status(http.StatusNoContent)
 }
 
 // SearchThreats godoc
 // @Summary Search threats
-// @Description Searches threats by type, user, or time range
+// @Description Searches threats by type, user, source, tenant, triage status, assignee, or time range
 // @Tags Threats
 // @Produce json
 // @Security BearerAuth
 // @Param type query string false "Threat type"
 // @Param user query string false "User ID"
+// @Param source query string false "Source system of the log"
+// @Param tenant query string false "Tenant"
+// @Param status query string false "Triage status" Enums(open, acknowledged, investigating, resolved, false_positive)
+// @Param assigneeId query int false "ID of the assigned user"
 // @Param startTime query string false "Start time (RFC3339)" format:"date-time"
 // @Param endTime query string false "End time (RFC3339)" format:"date-time"
 // @Success 200 {array} threatentity.Threat "List of matching threats"
+// @Failure 403 {object} map[string]string "Role lacks the read permission"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /api/threats/search [get]
 func SearchThreats(c *gin.Context) {
 	threatType := c.Query("type")
 	userID := c.Query("user")
+	source := c.Query("source")
+	tenant := c.Query("tenant")
+	status := c.Query("status")
+	assigneeID := c.Query("assigneeId")
 	startTime := c.Query("startTime")
 	endTime := c.Query("endTime")
 
-	threats, err := threatService.SearchThreats(threatType, userID, startTime, endTime)
+	threats, err := threatServiceFor(c).SearchThreats(threatType, userID, source, tenant, status, assigneeID, startTime, endTime)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -15,53 +15,53 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/login": {
-            "post": {
-                "description": "Authenticates a user and returns a JWT token using query parameters",
-                "consumes": [
-                    "application/json"
-                ],
+        "/.well-known/jwks.json": {
+            "get": {
+                "description": "Public keys of the token issuer (RFC 7517). Tokens name their key in the kid header; during a key rotation\nboth the new and the retiring keys are listed. Services verifying tokens fetch and cache this set.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Auth"
                 ],
-                "summary": "User login",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "User username",
-                        "name": "username",
-                        "in": "query",
-                        "required": true
-                    },
+                "summary": "JSON Web Key Set",
+                "responses": {
+                    "200": {
+                        "description": "Signing keys",
+                        "schema": {
+                            "$ref": "#/definitions/tokenservice.JWKS"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/incidents": {
+            "get": {
+                "security": [
                     {
-                        "type": "string",
-                        "description": "User password",
-                        "name": "password",
-                        "in": "query",
-                        "required": true
+                        "BearerAuth": []
                     }
                 ],
+                "description": "Fetches all incidents, most recently active first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Incidents"
+                ],
+                "summary": "Retrieve all incidents",
                 "responses": {
                     "200": {
-                        "description": "Login success with JWT token",
-                        "schema": {
-                            "$ref": "#/definitions/authdto.LoginResponseDTO"
-                        }
-                    },
-                    "400": {
-                        "description": "error: Invalid input",
+                        "description": "List of incidents",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/incidententity.Incident"
                             }
                         }
                     },
-                    "401": {
-                        "description": "error: Invalid credentials",
+                    "403": {
+                        "description": "Role lacks the read permission",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -70,7 +70,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "error: Server error",
+                        "description": "Internal server error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -79,11 +79,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/api/register": {
+            },
             "post": {
-                "description": "Creates a new user account with username, password, and email",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Opens a manual incident and moves the given threats into it",
                 "consumes": [
                     "application/json"
                 ],
@@ -91,29 +94,29 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "Incidents"
                 ],
-                "summary": "Register a new user",
+                "summary": "Create an incident",
                 "parameters": [
                     {
-                        "description": "User registration details",
-                        "name": "user",
+                        "description": "Incident title and threats",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/authdto.RegisterRequestDTO"
+                            "$ref": "#/definitions/incidentdto.CreateIncidentRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Registration success",
+                    "201": {
+                        "description": "Created incident",
                         "schema": {
-                            "$ref": "#/definitions/authdto.RegisterResponseDTO"
+                            "$ref": "#/definitions/incidentservice.IncidentDetails"
                         }
                     },
                     "400": {
-                        "description": "error: Invalid input",
+                        "description": "Invalid request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -121,8 +124,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "409": {
-                        "description": "error: Username or email already exists",
+                    "403": {
+                        "description": "Role lacks the analyze permission",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -131,7 +134,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "error: Server error",
+                        "description": "Internal server error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -142,28 +145,75 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/threats": {
+        "/api/incidents/search": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Fetches all detected threats from the database",
+                "description": "Searches incidents by rule, user, tenant, severity or activity time range",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Threats"
+                    "Incidents"
+                ],
+                "summary": "Search incidents",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Rule ID",
+                        "name": "ruleId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tenant",
+                        "name": "tenant",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Severity",
+                        "name": "severity",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Active at or after (RFC3339)",
+                        "name": "startTime",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Active at or before (RFC3339)",
+                        "name": "endTime",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Retrieve all threats",
                 "responses": {
                     "200": {
-                        "description": "List of threats",
+                        "description": "List of matching incidents",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/threatentity.Threat"
+                                "$ref": "#/definitions/incidententity.Incident"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Role lacks the read permission",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
                             }
                         }
                     },
@@ -179,47 +229,39 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/threats/analyze": {
-            "post": {
+        "/api/incidents/{incidentId}": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Analyzes logs within the specified time range and detects threats",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Fetches an incident by its ID together with its threats",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Threats"
+                    "Incidents"
                 ],
-                "summary": "Analyze logs for threats",
+                "summary": "Retrieve a specific incident",
                 "parameters": [
                     {
-                        "description": "Start and end time for log analysis",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/controllers.AnalyzeThreatRequest"
-                        }
+                        "type": "integer",
+                        "description": "Incident ID",
+                        "name": "incidentId",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "List of detected threats",
+                        "description": "Incident details",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/threatentity.Threat"
-                            }
+                            "$ref": "#/definitions/incidentservice.IncidentDetails"
                         }
                     },
                     "400": {
-                        "description": "Invalid request",
+                        "description": "Invalid incident ID",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -227,66 +269,21 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "403": {
+                        "description": "Role lacks the read permission",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
                                 "type": "string"
                             }
                         }
-                    }
-                }
-            }
-        },
-        "/api/threats/search": {
-            "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Searches threats by type, user, or time range",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Threats"
-                ],
-                "summary": "Search threats",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Threat type",
-                        "name": "type",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "User ID",
-                        "name": "user",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Start time (RFC3339)",
-                        "name": "startTime",
-                        "in": "query"
                     },
-                    {
-                        "type": "string",
-                        "description": "End time (RFC3339)",
-                        "name": "endTime",
-                        "in": "query"
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "List of matching threats",
+                    "404": {
+                        "description": "Incident not found",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/threatentity.Threat"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
                             }
                         }
                     },
@@ -300,41 +297,45 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/api/threats/{threatId}": {
-            "get": {
+            },
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Fetches a threat by its ID",
+                "description": "Deletes an incident and its threats; the threats' evidence, comments and history are kept as for DELETE /api/threats/{threatId}",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Threats"
+                    "Incidents"
                 ],
-                "summary": "Retrieve a specific threat",
+                "summary": "Delete a specific incident",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Threat ID",
-                        "name": "threatId",
+                        "description": "Incident ID",
+                        "name": "incidentId",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Threat details",
+                    "204": {
+                        "description": "incident deleted successfully"
+                    },
+                    "403": {
+                        "description": "Role lacks the delete permission",
                         "schema": {
-                            "$ref": "#/definitions/threatentity.Threat"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
                     "404": {
-                        "description": "Threat not found",
+                        "description": "incident not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -353,35 +354,68 @@ const docTemplate = `{
                     }
                 }
             },
-            "delete": {
+            "patch": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Deletes a threat by its ID",
+                "description": "Renames an incident and/or moves more threats into it",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Threats"
+                    "Incidents"
                 ],
-                "summary": "Delete a specific threat",
+                "summary": "Update an incident",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "threat ID",
-                        "name": "threatId",
+                        "description": "Incident ID",
+                        "name": "incidentId",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Fields to update",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/incidentdto.UpdateIncidentRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "threat deleted successfully"
+                    "200": {
+                        "description": "Updated incident",
+                        "schema": {
+                            "$ref": "#/definitions/incidentservice.IncidentDetails"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Role lacks the analyze permission",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
                     },
                     "404": {
-                        "description": "threat not found",
+                        "description": "Incident not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -400,90 +434,2508 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "authdto.LoginResponseDTO": {
+        },
+        "/api/login": {
+            "post": {
+                "description": "Authenticates a user using query parameters and opens a session: returns a short-lived JWT access token\nand a refresh token for POST /token/refresh. Users with MFA, or whose role requires it, instead get 202 with\na short-lived challenge token to exchange for the tokens at POST /login/mfa.\nRepeated failures lock out the username and the client address for a growing time (429 with Retry-After).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "User login",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User username",
+                        "name": "username",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User password",
+                        "name": "password",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Login success with JWT token",
+                        "schema": {
+                            "$ref": "#/definitions/authdto.LoginResponseDTO"
+                        }
+                    },
+                    "202": {
+                        "description": "Password accepted; a second factor is required",
+                        "schema": {
+                            "$ref": "#/definitions/authdto.MFAChallengeResponseDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "error: Invalid input",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "error: Invalid credentials",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "error: Too many failed logins for the username or from the address",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: Server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/login-lockouts": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists the usernames with recent failed logins, with their failure count, lockout end and latest client address.\nTenant admins see their tenant's users; platform admins also see unknown usernames and client addresses.\nRequires the manage_users permission.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tenants"
+                ],
+                "summary": "List failed logins and lockouts",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/lockoutentity.LoginThrottle"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "error: Role lacks the manage_users permission",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: Server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/login-lockouts/ips/{ip}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lifts the login lockout of a client address and forgets its failed logins. Platform admins only.",
+                "tags": [
+                    "Tenants"
+                ],
+                "summary": "Unlock a client address",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Client address",
+                        "name": "ip",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Unlocked"
+                    },
+                    "403": {
+                        "description": "error: Platform admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "error: No failed logins recorded",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: Server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/login/mfa": {
+            "post": {
+                "description": "Exchanges the challenge token of a password login and a code for the access and refresh tokens.\nA verify challenge accepts a TOTP code or an unused recovery code. An enroll challenge takes a TOTP code\nof the secret from POST /login/mfa/enroll, enables MFA and also returns the user's recovery codes.\nA challenge works once and fails after too many wrong codes; wrong codes also count towards the login lockout.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Second login step",
+                "parameters": [
+                    {
+                        "description": "Challenge token and code",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/authdto.MFALoginRequestDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Login success with JWT token",
+                        "schema": {
+                            "$ref": "#/definitions/authdto.LoginResponseDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "error: Invalid input, or enrollment not started",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "error: Invalid code, or challenge expired, used or failed too often",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: Server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/login/mfa/enroll": {
+            "post": {
+                "description": "For an enroll challenge: returns a new TOTP secret to add to an authenticator app. Complete the login with\none of the app's codes at POST /login/mfa. Calling it again replaces the secret.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Enroll in MFA during login",
+                "parameters": [
+                    {
+                        "description": "Challenge token",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/authdto.MFAEnrollChallengeRequestDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "TOTP secret",
+                        "schema": {
+                            "$ref": "#/definitions/mfadto.EnrollmentResponseDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "error: Invalid input",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "error: Challenge expired, used or failed too often",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "error: MFA is already enabled",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: Server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/logout": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revokes the presented access token and its session, so neither it nor the session's refresh token works any more",
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Log out",
+                "responses": {
+                    "204": {
+                        "description": "Logged out"
+                    },
+                    "400": {
+                        "description": "error: Not a login session",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "error: Invalid token",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: Server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/mfa": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Reports whether the caller has MFA enabled, whether their role requires it, and how many recovery codes remain",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "MFA"
+                ],
+                "summary": "MFA status",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/mfadto.StatusResponseDTO"
+                        }
+                    },
+                    "403": {
+                        "description": "error: Not a user account",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: Server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/mfa/policy": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists the roles of the caller's tenant that must use MFA. Platform admins may name another tenant.\nRequires the manage_users permission.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "MFA"
+                ],
+                "summary": "MFA policy",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tenant (platform admins only)",
+                        "name": "tenant",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/mfadto.PolicyResponseDTO"
+                        }
+                    },
+                    "403": {
+                        "description": "error: Role lacks the manage_users permission",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: Server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Sets the roles of the caller's tenant that must use MFA, replacing the previous list. Their users without MFA\nenroll at their next password login. Platform admins may name another tenant; only they may require MFA\nfor platform_admin. Requires the manage_users permission.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "MFA"
+                ],
+                "summary": "Require MFA per role",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tenant (platform admins only)",
+                        "name": "tenant",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Roles requiring MFA",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/mfadto.PolicyRequestDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/mfadto.PolicyResponseDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "error: Invalid input or role",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "error: Role lacks the manage_users permission, or change reserved to platform admins",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "error: Tenant not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: Server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/mfa/recovery-codes": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Invalidates the caller's recovery codes and returns new ones after checking a TOTP or recovery code",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "MFA"
+                ],
+                "summary": "Replace recovery codes",
+                "parameters": [
+                    {
+                        "description": "TOTP or recovery code",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/mfadto.CodeRequestDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/mfadto.RecoveryCodesResponseDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "error: Invalid input, or MFA not enabled",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "error: Invalid code",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: Server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/mfa/totp": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns a new TOTP secret for the caller to add to an authenticator app; MFA is enabled once\nPOST /mfa/totp/confirm accepts one of the app's codes. Calling it again replaces an unconfirmed secret.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "MFA"
+                ],
+                "summary": "Start TOTP enrollment",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/mfadto.EnrollmentResponseDTO"
+                        }
+                    },
+                    "403": {
+                        "description": "error: Not a user account",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "error: MFA is already enabled",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: Server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Removes the caller's authenticator and recovery codes after checking a TOTP or recovery code.\nRefused when the caller's role requires MFA.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "MFA"
+                ],
+                "summary": "Disable MFA",
+                "parameters": [
+                    {
+                        "description": "TOTP or recovery code",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/mfadto.CodeRequestDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "MFA disabled"
+                    },
+                    "400": {
+                        "description": "error: Invalid input, or MFA not enabled",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "error: Invalid code",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "error: Role requires MFA",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: Server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/mfa/totp/confirm": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Enables MFA once the code shows the authenticator app works, and returns the caller's recovery codes.\nThey are shown only once; each replaces a TOTP code one time.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "MFA"
+                ],
+                "summary": "Confirm TOTP enrollment",
+                "parameters": [
+                    {
+                        "description": "TOTP code",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/mfadto.CodeRequestDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/mfadto.RecoveryCodesResponseDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "error: Invalid input, or enrollment not started",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "error: Invalid code",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "error: MFA is already enabled",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: Server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/oidc/callback": {
+            "get": {
+                "description": "Redirect target of the identity provider. Validates the ID token, links the provider account to a local user\n(provisioning one on first login), sets the user's role from their provider groups and opens a session.\nUsers with MFA, or whose role requires it, instead get 202 with a challenge token for POST /login/mfa, as with Login.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Single sign-on callback",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization code",
+                        "name": "code",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "State of the login",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Login success with JWT token",
+                        "schema": {
+                            "$ref": "#/definitions/authdto.LoginResponseDTO"
+                        }
+                    },
+                    "202": {
+                        "description": "Provider login accepted; a second factor is required",
+                        "schema": {
+                            "$ref": "#/definitions/authdto.MFAChallengeResponseDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "error: Login expired, already completed or started in another browser",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "error: Provider refused the login or sent an invalid ID token",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "error: No group grants a role, or the linked account was removed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: Server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "502": {
+                        "description": "error: Identity provider unreachable or misconfigured",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/oidc/login": {
+            "get": {
+                "description": "Redirects the browser to the OpenID Connect provider (authorization code flow with PKCE).\nAfter signing in there, the provider sends the browser back to GET /oidc/callback.",
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Single sign-on login",
+                "responses": {
+                    "302": {
+                        "description": "Redirect to the identity provider"
+                    },
+                    "502": {
+                        "description": "error: Identity provider unreachable or misconfigured",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/register": {
+            "post": {
+                "description": "Creates a new user account with username, password, and email. Without a tenant the user joins the default tenant;\nnaming a tenant creates it with the user as its first member and admin, so it must not exist yet.\nUsers joining the default tenant get the viewer role until an admin assigns another.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Register a new user",
+                "parameters": [
+                    {
+                        "description": "User registration details",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/authdto.RegisterRequestDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Registration success",
+                        "schema": {
+                            "$ref": "#/definitions/authdto.RegisterResponseDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "error: Invalid input",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "error: Username, email or tenant already exists",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: Server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rules": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the detection rules currently loaded by the rule engine",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rules"
+                ],
+                "summary": "List detection rules",
+                "responses": {
+                    "200": {
+                        "description": "Loaded rules",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/ruleengine.Rule"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Role lacks the read permission",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Rules not loaded",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rules/reload": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Re-reads the built-in rules and the rule files in THREAT_RULES_DIR. Platform admins only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rules"
+                ],
+                "summary": "Reload detection rules",
+                "responses": {
+                    "200": {
+                        "description": "Reloaded rules",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/ruleengine.Rule"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid rule file",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Platform admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Rules not loaded",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/threats": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Fetches all detected threats from the database",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Threats"
+                ],
+                "summary": "Retrieve all threats",
+                "responses": {
+                    "200": {
+                        "description": "List of threats",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/threatentity.Threat"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Role lacks the read permission",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/threats/analyze": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Analyzes logs within the specified time range and detects threats. Each rule also reads the history it needs before startTime.\nWithout startTime and endTime only logs stored since each rule's checkpoint are analyzed; with only one of them the other defaults to today.\nCallers other than platform admins analyze only their tenant's logs, and such runs do not advance the checkpoints.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Threats"
+                ],
+                "summary": "Analyze logs for threats",
+                "parameters": [
+                    {
+                        "description": "Start and end time for log analysis",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/threatanalyzerresquest.AnalyzeThreatRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "New and already known threat counts",
+                        "schema": {
+                            "$ref": "#/definitions/threatanalyzerresquest.AnalyzeThreatResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Role lacks the analyze permission",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/threats/checkpoints": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the last log each rule has analyzed incrementally. Platform admins only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Threats"
+                ],
+                "summary": "List analysis checkpoints",
+                "responses": {
+                    "200": {
+                        "description": "Checkpoints",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/checkpointentity.AnalysisCheckpoint"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Platform admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/threats/checkpoints/reset": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Rewinds the checkpoint of one rule (or all rules) to a log ID or timestamp. With neither, checkpoints are cleared and the next analysis re-reads all history. Platform admins only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Threats"
+                ],
+                "summary": "Reset or rewind analysis checkpoints",
+                "parameters": [
+                    {
+                        "description": "Rule and position to rewind to",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/threatanalyzerresquest.ResetCheckpointRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Checkpoints after the reset",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/checkpointentity.AnalysisCheckpoint"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Platform admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Rule not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/threats/search": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Searches threats by type, user, source, tenant, triage status, assignee, or time range",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Threats"
+                ],
+                "summary": "Search threats",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Threat type",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Source system of the log",
+                        "name": "source",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tenant",
+                        "name": "tenant",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "open",
+                            "acknowledged",
+                            "investigating",
+                            "resolved",
+                            "false_positive"
+                        ],
+                        "type": "string",
+                        "description": "Triage status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the assigned user",
+                        "name": "assigneeId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start time (RFC3339)",
+                        "name": "startTime",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time (RFC3339)",
+                        "name": "endTime",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of matching threats",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/threatentity.Threat"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Role lacks the read permission",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/threats/{threatId}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Fetches a threat by its ID with its evidence log IDs, comments and history in chronological order",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Threats"
+                ],
+                "summary": "Retrieve a specific threat",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Threat ID",
+                        "name": "threatId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Set to logs to include each evidence log row inline",
+                        "name": "expand",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Threat details",
+                        "schema": {
+                            "$ref": "#/definitions/threatentity.Threat"
+                        }
+                    },
+                    "403": {
+                        "description": "Role lacks the read permission",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Threat not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Deletes a threat by its ID. The threat is hidden from every endpoint but its evidence,\ncomments and history are kept, the deletion recorded in the history; later detections don't restore it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Threats"
+                ],
+                "summary": "Delete a specific threat",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "threat ID",
+                        "name": "threatId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "threat deleted successfully"
+                    },
+                    "403": {
+                        "description": "Role lacks the delete permission",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "threat not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Changes a threat's status, assignee or resolution and/or adds an analyst comment. Every change is recorded in the threat's history.\nStatuses are open, acknowledged, investigating, resolved and false_positive; the last two need a resolution. An assigneeId of 0 unassigns the threat.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Threats"
+                ],
+                "summary": "Triage a threat",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Threat ID",
+                        "name": "threatId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Triage changes",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/threatanalyzerresquest.UpdateThreatRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated threat with comments and history",
+                        "schema": {
+                            "$ref": "#/definitions/threatentity.Threat"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Role lacks the analyze permission",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Threat not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/token/refresh": {
+            "post": {
+                "description": "Exchanges a refresh token for a new access token and refresh token; the presented refresh token stops working.\nThe new access token carries the user's current tenant and role. Reusing a refresh token revokes its session.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Refresh an access token",
+                "parameters": [
+                    {
+                        "description": "Refresh token",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/authdto.RefreshRequestDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "New token pair",
+                        "schema": {
+                            "$ref": "#/definitions/authdto.LoginResponseDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "error: Invalid input",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "error: Invalid, expired, revoked or reused refresh token",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: Server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{username}/lockout": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lifts the login lockout of a user and forgets their failed logins. Their client address stays locked\nif it is locked itself. Tenant admins may unlock their tenant's users except platform admins.",
+                "tags": [
+                    "Tenants"
+                ],
+                "summary": "Unlock a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Unlocked"
+                    },
+                    "403": {
+                        "description": "error: Role lacks the manage_users permission, or the user is a platform admin",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "error: User not found, or no failed logins recorded",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: Server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{username}/mfa": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Removes the authenticator and recovery codes of a user who lost them, so they can log in with their password\nand enroll again. Tenant admins may reset their tenant's users except platform admins.",
+                "tags": [
+                    "MFA"
+                ],
+                "summary": "Reset a user's MFA",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "MFA reset"
+                    },
+                    "400": {
+                        "description": "error: MFA not enabled",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "error: Role lacks the manage_users permission, or the user is a platform admin",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "error: User not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error: Server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "authdto.LoginResponseDTO": {
             "type": "object",
             "properties": {
+                "expiresIn": {
+                    "description": "ExpiresIn is the lifetime of Token in seconds",
+                    "type": "integer",
+                    "example": 900
+                },
+                "recoveryCodes": {
+                    "description": "RecoveryCodes are returned once, when a login enrolled the user in MFA",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "refreshToken": {
+                    "description": "RefreshToken obtains a new pair from POST /token/refresh; each refresh token works once",
+                    "type": "string"
+                },
                 "token": {
                     "type": "string"
+                },
+                "tokenType": {
+                    "type": "string",
+                    "example": "Bearer"
+                }
+            }
+        },
+        "authdto.MFAChallengeResponseDTO": {
+            "type": "object",
+            "properties": {
+                "challengeToken": {
+                    "description": "ChallengeToken is exchanged with a code at POST /login/mfa",
+                    "type": "string"
+                },
+                "expiresIn": {
+                    "description": "ExpiresIn is the lifetime of ChallengeToken in seconds",
+                    "type": "integer",
+                    "example": 300
+                },
+                "purpose": {
+                    "description": "Purpose is \"verify\" to enter a code of the user's authenticator, or \"enroll\" when their role\nrequires MFA they have not set up: POST /login/mfa/enroll first returns the secret to add",
+                    "type": "string",
+                    "example": "verify"
+                }
+            }
+        },
+        "authdto.MFAEnrollChallengeRequestDTO": {
+            "type": "object",
+            "required": [
+                "challengeToken"
+            ],
+            "properties": {
+                "challengeToken": {
+                    "type": "string"
+                }
+            }
+        },
+        "authdto.MFALoginRequestDTO": {
+            "type": "object",
+            "required": [
+                "challengeToken",
+                "code"
+            ],
+            "properties": {
+                "challengeToken": {
+                    "type": "string"
+                },
+                "code": {
+                    "description": "Code is a TOTP code or, for a verify challenge, a recovery code",
+                    "type": "string",
+                    "maxLength": 32
+                }
+            }
+        },
+        "authdto.RefreshRequestDTO": {
+            "type": "object",
+            "required": [
+                "refreshToken"
+            ],
+            "properties": {
+                "refreshToken": {
+                    "type": "string"
+                }
+            }
+        },
+        "authdto.RegisterRequestDTO": {
+            "type": "object",
+            "required": [
+                "email",
+                "password",
+                "username"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 8
+                },
+                "password": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 8
+                },
+                "tenant": {
+                    "description": "Tenant is a new tenant to create with the user as its first member; empty joins \"default\"",
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "username": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "minLength": 3
+                }
+            }
+        },
+        "authdto.RegisterResponseDTO": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "checkpointentity.AnalysisCheckpoint": {
+            "type": "object",
+            "properties": {
+                "lastLogId": {
+                    "type": "integer"
+                },
+                "lastTimestamp": {
+                    "type": "string"
+                },
+                "ruleId": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "evidenceentity.ThreatEvidence": {
+            "type": "object",
+            "properties": {
+                "log": {
+                    "$ref": "#/definitions/logDataentity.LogData"
+                },
+                "logId": {
+                    "type": "integer"
+                },
+                "logTimestamp": {
+                    "type": "string"
+                },
+                "threatId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "incidentdto.CreateIncidentRequest": {
+            "type": "object",
+            "required": [
+                "threatIds",
+                "title"
+            ],
+            "properties": {
+                "threatIds": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "incidentdto.UpdateIncidentRequest": {
+            "type": "object",
+            "properties": {
+                "threatIds": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "incidententity.Incident": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "firstSeen": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ipAddress": {
+                    "type": "string"
+                },
+                "lastSeen": {
+                    "type": "string"
+                },
+                "ruleId": {
+                    "type": "string"
+                },
+                "severity": {
+                    "type": "string"
+                },
+                "tenant": {
+                    "type": "string"
+                },
+                "threatCount": {
+                    "type": "integer"
+                },
+                "threatType": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "incidentservice.IncidentDetails": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "firstSeen": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ipAddress": {
+                    "type": "string"
+                },
+                "lastSeen": {
+                    "type": "string"
+                },
+                "ruleId": {
+                    "type": "string"
+                },
+                "severity": {
+                    "type": "string"
+                },
+                "tenant": {
+                    "type": "string"
+                },
+                "threatCount": {
+                    "type": "integer"
+                },
+                "threatType": {
+                    "type": "string"
+                },
+                "threats": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/threatentity.Threat"
+                    }
+                },
+                "title": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "lockoutentity.LoginThrottle": {
+            "type": "object",
+            "properties": {
+                "failures": {
+                    "description": "Failures counts failed logins since the last success, forgetting them after a quiet period",
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "lastFailureAt": {
+                    "type": "string"
+                },
+                "lastFailureIp": {
+                    "description": "LastFailureIP is the client address of the latest failure",
+                    "type": "string"
+                },
+                "lockedUntil": {
+                    "type": "string"
+                },
+                "subject": {
+                    "type": "string"
+                }
+            }
+        },
+        "logDataentity.Attributes": {
+            "type": "object",
+            "additionalProperties": {
+                "type": "string"
+            }
+        },
+        "logDataentity.LogData": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "attributes": {
+                    "$ref": "#/definitions/logDataentity.Attributes"
+                },
+                "databaseQuery": {
+                    "type": "string"
+                },
+                "fileName": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ipAddress": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "tenant": {
+                    "type": "string"
+                },
+                "timestamp": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "string"
                 }
             }
         },
-        "authdto.RegisterRequestDTO": {
+        "mfadto.CodeRequestDTO": {
             "type": "object",
             "required": [
-                "email",
-                "password",
-                "username"
+                "code"
             ],
             "properties": {
-                "email": {
+                "code": {
+                    "description": "Code is a TOTP code or, where a recovery code is accepted, a recovery code",
                     "type": "string",
-                    "maxLength": 100,
-                    "minLength": 8
+                    "maxLength": 32
+                }
+            }
+        },
+        "mfadto.EnrollmentResponseDTO": {
+            "type": "object",
+            "properties": {
+                "otpauthUri": {
+                    "description": "OtpauthURI is the secret as a key URI, usually shown as a QR code",
+                    "type": "string"
                 },
-                "password": {
+                "secret": {
                     "type": "string",
-                    "maxLength": 100,
-                    "minLength": 8
+                    "example": "JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"
+                }
+            }
+        },
+        "mfadto.PolicyRequestDTO": {
+            "type": "object",
+            "required": [
+                "roles"
+            ],
+            "properties": {
+                "roles": {
+                    "type": "array",
+                    "maxItems": 8,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "mfadto.PolicyResponseDTO": {
+            "type": "object",
+            "properties": {
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "username": {
-                    "type": "string",
-                    "maxLength": 50,
-                    "minLength": 3
+                "tenant": {
+                    "type": "string"
                 }
             }
         },
-        "authdto.RegisterResponseDTO": {
+        "mfadto.RecoveryCodesResponseDTO": {
             "type": "object",
             "properties": {
-                "message": {
+                "recoveryCodes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "mfadto.StatusResponseDTO": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "recoveryCodesLeft": {
+                    "type": "integer"
+                },
+                "required": {
+                    "description": "Required is set when the caller's role must use MFA",
+                    "type": "boolean"
+                }
+            }
+        },
+        "ruleengine.Condition": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "op": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "string"
+                },
+                "values": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "ruleengine.Emit": {
+            "type": "object",
+            "properties": {
+                "conditions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ruleengine.Condition"
+                    }
+                },
+                "mode": {
+                    "type": "string"
+                },
+                "steps": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "window": {
                     "type": "string"
                 }
             }
         },
-        "controllers.AnalyzeThreatRequest": {
+        "ruleengine.Rule": {
+            "type": "object",
+            "properties": {
+                "conditions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ruleengine.Condition"
+                    }
+                },
+                "description": {
+                    "type": "string"
+                },
+                "emit": {
+                    "$ref": "#/definitions/ruleengine.Emit"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "groupBy": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "lookback": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "severity": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "steps": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ruleengine.Step"
+                    }
+                },
+                "threatType": {
+                    "type": "string"
+                },
+                "threshold": {
+                    "$ref": "#/definitions/ruleengine.Threshold"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "within": {
+                    "type": "string"
+                }
+            }
+        },
+        "ruleengine.Step": {
+            "type": "object",
+            "properties": {
+                "conditions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ruleengine.Condition"
+                    }
+                },
+                "differsFromNext": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "minCount": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "sameAsNext": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "ruleengine.Threshold": {
+            "type": "object",
+            "properties": {
+                "bucket": {
+                    "type": "string"
+                },
+                "distinctField": {
+                    "type": "string"
+                },
+                "minCount": {
+                    "type": "integer"
+                }
+            }
+        },
+        "threatanalyzerresquest.AnalyzeThreatRequest": {
             "type": "object",
-            "required": [
-                "end",
-                "start"
-            ],
             "properties": {
-                "end": {
+                "endTime": {
                     "type": "string",
                     "format": "date-time",
                     "example": "2025-03-27T00:00:00Z"
                 },
-                "start": {
+                "startTime": {
                     "type": "string",
                     "format": "date-time",
                     "example": "2025-03-26T00:00:00Z"
                 }
             }
         },
+        "threatanalyzerresquest.AnalyzeThreatResponse": {
+            "type": "object",
+            "properties": {
+                "knownThreats": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "newThreats": {
+                    "type": "integer"
+                }
+            }
+        },
+        "threatanalyzerresquest.ResetCheckpointRequest": {
+            "type": "object",
+            "properties": {
+                "logId": {
+                    "type": "integer"
+                },
+                "ruleId": {
+                    "type": "string"
+                },
+                "timestamp": {
+                    "type": "string",
+                    "format": "date-time"
+                }
+            }
+        },
+        "threatanalyzerresquest.UpdateThreatRequest": {
+            "type": "object",
+            "properties": {
+                "assigneeId": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "comment": {
+                    "type": "string",
+                    "example": "Looking into the source IP"
+                },
+                "resolution": {
+                    "type": "string",
+                    "example": "Confirmed with the user, password reset"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "open",
+                        "acknowledged",
+                        "investigating",
+                        "resolved",
+                        "false_positive"
+                    ],
+                    "example": "investigating"
+                }
+            }
+        },
         "threatentity.Threat": {
             "type": "object",
             "properties": {
                 "action": {
                     "type": "string"
                 },
+                "assigneeId": {
+                    "type": "integer"
+                },
+                "comments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/triageentity.ThreatComment"
+                    }
+                },
                 "databaseQuery": {
                     "type": "string"
                 },
+                "evidence": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/evidenceentity.ThreatEvidence"
+                    }
+                },
                 "fileName": {
                     "type": "string"
                 },
+                "fingerprint": {
+                    "type": "string"
+                },
+                "history": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/triageentity.ThreatHistory"
+                    }
+                },
                 "id": {
                     "type": "integer"
                 },
+                "incidentId": {
+                    "type": "integer"
+                },
                 "ipAddress": {
                     "type": "string"
                 },
+                "resolution": {
+                    "type": "string"
+                },
+                "resolvedAt": {
+                    "type": "string"
+                },
+                "ruleId": {
+                    "type": "string"
+                },
                 "severity": {
                     "type": "string"
                 },
+                "source": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "tenant": {
+                    "type": "string"
+                },
                 "threatType": {
                     "type": "string"
                 },
@@ -494,6 +2946,100 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "tokenservice.JWK": {
+            "type": "object",
+            "properties": {
+                "alg": {
+                    "type": "string",
+                    "example": "ES256"
+                },
+                "crv": {
+                    "type": "string",
+                    "example": "P-256"
+                },
+                "e": {
+                    "type": "string"
+                },
+                "kid": {
+                    "type": "string",
+                    "example": "0ZcOCORZNYy-DWpqq30jZyJGHTN0d2HglBV3uiguA4I"
+                },
+                "kty": {
+                    "type": "string",
+                    "example": "EC"
+                },
+                "n": {
+                    "type": "string"
+                },
+                "use": {
+                    "type": "string",
+                    "example": "sig"
+                },
+                "x": {
+                    "type": "string"
+                },
+                "y": {
+                    "type": "string"
+                }
+            }
+        },
+        "tokenservice.JWKS": {
+            "type": "object",
+            "properties": {
+                "keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/tokenservice.JWK"
+                    }
+                }
+            }
+        },
+        "triageentity.ThreatComment": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "type": "string"
+                },
+                "body": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "threatId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "triageentity.ThreatHistory": {
+            "type": "object",
+            "properties": {
+                "changedBy": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "field": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "newValue": {
+                    "type": "string"
+                },
+                "oldValue": {
+                    "type": "string"
+                },
+                "threatId": {
+                    "type": "integer"
+                }
+            }
         }
     },
     "securityDefinitions": {
@@ -0,0 +1,11 @@
+package incidentdto
+
+type CreateIncidentRequest struct {
+	Title     string   `json:"title" binding:"required,max=255"`
+	ThreatIDs []uint64 `json:"threatIds" binding:"required,min=1"`
+}
+
+type UpdateIncidentRequest struct {
+	Title     *string  `json:"title" binding:"omitempty,max=255"`
+	ThreatIDs []uint64 `json:"threatIds" binding:"omitempty"`
+}
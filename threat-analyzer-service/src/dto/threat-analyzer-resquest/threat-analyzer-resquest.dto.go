@@ -3,8 +3,29 @@ package threatanalyzerresquest
 import "time"
 
 type AnalyzeThreatRequest struct {
-	StartTime *time.Time `json:"startTime" validate:"omitempty"`
-	EndTime   *time.Time `json:"endTime" validate:"omitempty"`
+	StartTime *time.Time `json:"startTime" validate:"omitempty" example:"2025-03-26T00:00:00Z" format:"date-time"`
+	EndTime   *time.Time `json:"endTime" validate:"omitempty" example:"2025-03-27T00:00:00Z" format:"date-time"`
+}
+
+type AnalyzeThreatResponse struct {
+	Message      string `json:"message"`
+	NewThreats   int    `json:"newThreats"`
+	KnownThreats int    `json:"knownThreats"`
+}
+
+type ResetCheckpointRequest struct {
+	RuleID    *string    `json:"ruleId" validate:"omitempty"`
+	LogID     *uint64    `json:"logId" validate:"omitempty"`
+	Timestamp *time.Time `json:"timestamp" validate:"omitempty" format:"date-time"`
+}
+
+// UpdateThreatRequest changes a threat's triage state. An assigneeId of 0 unassigns the threat;
+// moving to resolved or false_positive requires a resolution unless one is already recorded.
+type UpdateThreatRequest struct {
+	Status     *string `json:"status" binding:"omitempty,oneof=open acknowledged investigating resolved false_positive" example:"investigating"`
+	AssigneeID *uint   `json:"assigneeId" validate:"omitempty" example:"1"`
+	Resolution *string `json:"resolution" validate:"omitempty" example:"Confirmed with the user, password reset"`
+	Comment    *string `json:"comment" validate:"omitempty" example:"Looking into the source IP"`
 }
 
 type SearchThreatRequest struct {
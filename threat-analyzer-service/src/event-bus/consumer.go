@@ -0,0 +1,162 @@
+// Package eventbus consumes the outbox_events table written by the log ingestor.
+// Delivery is at least once: a consumer's offset only moves past a batch after its
+// handler succeeded, so handlers must tolerate seeing an event again.
+//
+// Outbox IDs are assigned when a row is inserted but only become visible when the
+// inserting transaction commits, so a lower ID can show up after a higher one. The
+// consumer never moves its offset past a missing ID until it has waited GapTimeout
+// for it; IDs that never show up (rolled back inserts) are skipped after that.
+package eventbus
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	outboxentity "github.com/yatender-pareek/threat-analyzer-service/src/models/outbox-model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Handler processes a batch of events in outbox order
+type Handler func(events []outboxentity.OutboxEvent) error
+
+// Consumer delivers events of the given types to its handler, retrying failed
+// batches with exponential backoff up to MaxBackoff
+type Consumer struct {
+	Name         string
+	EventTypes   []string
+	BatchSize    int
+	PollInterval time.Duration
+	MaxBackoff   time.Duration
+	GapTimeout   time.Duration
+	Handler      Handler
+
+	db *gorm.DB
+	// gapID is the first missing ID the consumer is waiting for, first seen at gapSince
+	gapID    uint64
+	gapSince time.Time
+}
+
+func NewConsumer(db *gorm.DB, name string, eventTypes []string, handler Handler) *Consumer {
+	return &Consumer{
+		Name:         name,
+		EventTypes:   eventTypes,
+		BatchSize:    1000,
+		PollInterval: time.Second,
+		MaxBackoff:   time.Minute,
+		GapTimeout:   30 * time.Second,
+		Handler:      handler,
+		db:           db,
+	}
+}
+
+// Start consumes events in the background until the process exits
+func (c *Consumer) Start() {
+	go c.run()
+}
+
+func (c *Consumer) run() {
+	backoff := c.PollInterval
+	for {
+		delivered, err := c.Poll()
+		switch {
+		case err != nil:
+			log.Printf("Consumer %s failed, retrying in %s: %v", c.Name, backoff, err)
+			time.Sleep(backoff)
+			if backoff *= 2; backoff > c.MaxBackoff {
+				backoff = c.MaxBackoff
+			}
+		case delivered < c.BatchSize:
+			backoff = c.PollInterval
+			time.Sleep(c.PollInterval)
+		default:
+			backoff = c.PollInterval
+		}
+	}
+}
+
+// Poll delivers the next batch after the consumer's offset and advances the offset
+// up to the first ID that is still missing. It returns how many events were read.
+func (c *Consumer) Poll() (int, error) {
+	offset, err := c.Offset()
+	if err != nil {
+		return 0, err
+	}
+
+	// All event types are read so missing IDs can be told apart from IDs of other types
+	var events []outboxentity.OutboxEvent
+	if err := c.db.Where("id > ?", offset).
+		Order("id").Limit(c.BatchSize).Find(&events).Error; err != nil {
+		return 0, fmt.Errorf("failed to load outbox events: %w", err)
+	}
+	events = c.contiguous(offset, events)
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	var matching []outboxentity.OutboxEvent
+	for _, event := range events {
+		if slices.Contains(c.EventTypes, event.EventType) {
+			matching = append(matching, event)
+		}
+	}
+	if len(matching) > 0 {
+		if err := c.Handler(matching); err != nil {
+			return 0, err
+		}
+	}
+	if err := c.commit(events[len(events)-1].ID); err != nil {
+		return 0, err
+	}
+	return len(events), nil
+}
+
+// contiguous cuts events (ordered by ID) at the first missing ID after offset that
+// has been missing for less than GapTimeout. A consumer that never ran starts at
+// whatever the oldest retained event is.
+func (c *Consumer) contiguous(offset uint64, events []outboxentity.OutboxEvent) []outboxentity.OutboxEvent {
+	expected := offset + 1
+	for i, event := range events {
+		if offset == 0 && i == 0 {
+			expected = event.ID + 1
+			continue
+		}
+		if event.ID == expected {
+			expected++
+			continue
+		}
+		if c.gapID != expected {
+			c.gapID, c.gapSince = expected, time.Now()
+		}
+		if time.Since(c.gapSince) < c.GapTimeout {
+			return events[:i]
+		}
+		log.Printf("Consumer %s skipping outbox IDs %d-%d, not committed after %s",
+			c.Name, expected, event.ID-1, c.GapTimeout)
+		expected = event.ID + 1
+	}
+	return events
+}
+
+// Offset returns the ID of the last event the consumer handled, 0 if it never ran
+func (c *Consumer) Offset() (uint64, error) {
+	var offset outboxentity.ConsumerOffset
+	err := c.db.Where("consumer = ?", c.Name).Limit(1).Find(&offset).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to read offset of consumer %s: %w", c.Name, err)
+	}
+	return offset.LastEventID, nil
+}
+
+func (c *Consumer) commit(lastEventID uint64) error {
+	offset := outboxentity.ConsumerOffset{Consumer: c.Name, LastEventID: lastEventID}
+	if err := c.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "consumer"}},
+		DoUpdates: clause.AssignmentColumns([]string{"last_event_id", "updated_at"}),
+	}).Create(&offset).Error; err != nil {
+		return fmt.Errorf("failed to save offset of consumer %s: %w", c.Name, err)
+	}
+	return nil
+}
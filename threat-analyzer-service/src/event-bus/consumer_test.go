@@ -0,0 +1,53 @@
+package eventbus
+
+import (
+	"testing"
+	"time"
+
+	outboxentity "github.com/yatender-pareek/threat-analyzer-service/src/models/outbox-model"
+)
+
+func eventsWithIDs(ids ...uint64) []outboxentity.OutboxEvent {
+	events := make([]outboxentity.OutboxEvent, len(ids))
+	for i, id := range ids {
+		events[i] = outboxentity.OutboxEvent{ID: id, EventType: outboxentity.EventLogIngested}
+	}
+	return events
+}
+
+func TestContiguous(t *testing.T) {
+	tests := []struct {
+		name       string
+		offset     uint64
+		ids        []uint64
+		gapTimeout time.Duration
+		want       int
+	}{
+		{"no gap", 10, []uint64{11, 12, 13}, time.Minute, 3},
+		{"gap after offset", 10, []uint64{12, 13}, time.Minute, 0},
+		{"gap inside batch", 10, []uint64{11, 12, 14, 15}, time.Minute, 2},
+		{"gap timed out", 10, []uint64{11, 13, 14}, 0, 3},
+		{"first run starts at oldest event", 0, []uint64{500, 501, 503}, time.Minute, 2},
+		{"empty", 10, nil, time.Minute, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Consumer{Name: "test", GapTimeout: tt.gapTimeout}
+			got := c.contiguous(tt.offset, eventsWithIDs(tt.ids...))
+			if len(got) != tt.want {
+				t.Fatalf("contiguous() kept %d events, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestContiguousWaitsForTheSameGap(t *testing.T) {
+	c := &Consumer{Name: "test", GapTimeout: time.Minute}
+	if got := c.contiguous(10, eventsWithIDs(12)); len(got) != 0 {
+		t.Fatalf("expected to wait for id 11, got %d events", len(got))
+	}
+	c.gapSince = time.Now().Add(-2 * time.Minute)
+	if got := c.contiguous(10, eventsWithIDs(12)); len(got) != 1 {
+		t.Fatalf("expected id 11 to be skipped after the timeout, got %d events", len(got))
+	}
+}
@@ -8,10 +8,21 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	authcontroller "github.com/yatender-pareek/identity/src/controllers/auth-controller"
+	identitymiddleware "github.com/yatender-pareek/identity/src/middleware"
+	lockoutservice "github.com/yatender-pareek/identity/src/services/lockout-service"
+	tenantservice "github.com/yatender-pareek/identity/src/services/tenant-service"
+	tokenservice "github.com/yatender-pareek/identity/src/services/token-service"
+	auditshipper "github.com/yatender-pareek/threat-analyzer-service/src/audit-shipper"
 	mysqlconfig "github.com/yatender-pareek/threat-analyzer-service/src/config/my-sql-config"
 	"github.com/yatender-pareek/threat-analyzer-service/src/config/swagger"
 	"github.com/yatender-pareek/threat-analyzer-service/src/middleware"
+	logDataentity "github.com/yatender-pareek/threat-analyzer-service/src/models/log-data-model"
+	threatentity "github.com/yatender-pareek/threat-analyzer-service/src/models/threat-model"
 	"github.com/yatender-pareek/threat-analyzer-service/src/routes"
+	ruleengine "github.com/yatender-pareek/threat-analyzer-service/src/rule-engine"
+	streamdetector "github.com/yatender-pareek/threat-analyzer-service/src/stream-detector"
+	"github.com/yatender-pareek/threat-analyzer-service/src/utility"
 )
 
 // @title Threat Analyzer Service API
@@ -32,10 +43,36 @@ func main() {
 		log.Fatalf("Failed to initialize container: %v", err)
 	}
 
+	if err := tenantservice.Bootstrap(mysqlconfig.GetDB(), &logDataentity.LogData{}, &threatentity.Threat{}); err != nil {
+		log.Fatalf("Failed to set up tenants: %v", err)
+	}
+
+	lockoutservice.StartPruner()
+
+	if err := auditshipper.Start(); err != nil {
+		log.Fatalf("Failed to start audit events: %v", err)
+	}
+
+	if err := ruleengine.Init(); err != nil {
+		log.Fatalf("Failed to load detection rules: %v", err)
+	}
+
+	// Threats stored before incidents existed are grouped on startup
+	if err := mysqlconfig.GetDB().Transaction(utility.AssignIncidents); err != nil {
+		log.Printf("Failed to group existing threats into incidents: %v", err)
+	}
+
+	if err := streamdetector.Start(mysqlconfig.GetDB()); err != nil {
+		log.Fatalf("Failed to start streaming detection: %v", err)
+	}
+
 	ratelimiter := middleware.NewRateLimiter(2, 5)
 
 	r := gin.Default()
 	swagger.SetupSwagger(r)
+	if tokenservice.CanIssue() {
+		r.GET("/.well-known/jwks.json", authcontroller.JWKS)
+	}
 	r.GET("/", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"message": "Welcome to the Threat-Analyzer-service!"})
 	})
@@ -46,7 +83,7 @@ func main() {
 		routes.SetupPublicRoutes(basepath)
 	}
 
-	basepath.Use(middleware.AuthMiddleware())
+	basepath.Use(identitymiddleware.AuthMiddleware())
 	{
 		routes.SetupProtectedRoutes(basepath)
 	}
@@ -1,62 +0,0 @@
-package middleware
-
-import (
-	"log"
-	"net/http"
-	"strings"
-
-	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
-	authcontroller "github.com/yatender-pareek/threat-analyzer-service/src/controllers/auth-controller"
-)
-
-// AuthMiddleware validates JWT tokens
-func AuthMiddleware() gin.HandlerFunc {
-	secretKey := authcontroller.JWTSecretKey()
-
-	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
-			c.Abort()
-			return
-		}
-
-		// Expect "Bearer <token>"
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header"})
-			c.Abort()
-			return
-		}
-
-		tokenString := parts[1]
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				log.Printf("Invalid signing method: %v", token.Header["alg"])
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return secretKey, nil
-		})
-
-		if err != nil {
-			log.Printf("Token parsing error: %v", err)
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token: " + err.Error()})
-			c.Abort()
-			return
-		}
-
-		if !token.Valid {
-			log.Printf("Token invalid: %v", token.Claims)
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
-			return
-		}
-
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			c.Set("username", claims["username"])
-		}
-
-		c.Next()
-	}
-}
@@ -0,0 +1,14 @@
+package checkpointentity
+
+import (
+	"time"
+)
+
+// AnalysisCheckpoint records how far incremental analysis has read log_data for a rule
+type AnalysisCheckpoint struct {
+	RuleID        string     `json:"ruleId" gorm:"primaryKey;type:varchar(255)"`
+	LastLogID     uint64     `json:"lastLogId" gorm:"not null"`
+	LastTimestamp *time.Time `json:"lastTimestamp"`
+	CreatedAt     time.Time  `json:"-" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time  `json:"updatedAt" gorm:"autoUpdateTime"`
+}
@@ -0,0 +1,21 @@
+package evidenceentity
+
+import (
+	"time"
+
+	logDataentity "github.com/yatender-pareek/threat-analyzer-service/src/models/log-data-model"
+)
+
+// ThreatEvidence links a threat to one of the log_data rows that triggered it
+type ThreatEvidence struct {
+	ID           uint64                 `json:"-" gorm:"primaryKey;autoIncrement"`
+	ThreatID     uint64                 `json:"threatId" gorm:"not null;uniqueIndex:idx_threat_evidence_threat_log"`
+	LogID        uint64                 `json:"logId" gorm:"not null;uniqueIndex:idx_threat_evidence_threat_log;index"`
+	LogTimestamp time.Time              `json:"logTimestamp" gorm:"not null"`
+	CreatedAt    time.Time              `json:"-" gorm:"autoCreateTime"`
+	Log          *logDataentity.LogData `json:"log,omitempty" gorm:"-"`
+}
+
+func (ThreatEvidence) TableName() string {
+	return "threat_evidence"
+}
@@ -4,15 +4,29 @@ package models
 import (
 	"fmt"
 
+	identitymodels "github.com/yatender-pareek/identity/src/models"
+	checkpointentity "github.com/yatender-pareek/threat-analyzer-service/src/models/checkpoint-model"
+	evidenceentity "github.com/yatender-pareek/threat-analyzer-service/src/models/evidence-model"
+	incidententity "github.com/yatender-pareek/threat-analyzer-service/src/models/incident-model"
 	logDataModel "github.com/yatender-pareek/threat-analyzer-service/src/models/log-data-model"
+	outboxentity "github.com/yatender-pareek/threat-analyzer-service/src/models/outbox-model"
 	threatentity "github.com/yatender-pareek/threat-analyzer-service/src/models/threat-model"
+	triageentity "github.com/yatender-pareek/threat-analyzer-service/src/models/triage-model"
 )
 
 func GetAllModels() []interface{} {
 	models := []interface{}{
 		&logDataModel.LogData{},
 		&threatentity.Threat{},
+		&checkpointentity.AnalysisCheckpoint{},
+		&evidenceentity.ThreatEvidence{},
+		&incidententity.Incident{},
+		&triageentity.ThreatComment{},
+		&triageentity.ThreatHistory{},
+		&outboxentity.OutboxEvent{},
+		&outboxentity.ConsumerOffset{},
 	}
+	models = append(models, identitymodels.GetAllModels()...)
 	fmt.Printf("Models: %+v\n", models)
 	return models
 }
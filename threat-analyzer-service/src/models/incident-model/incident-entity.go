@@ -0,0 +1,23 @@
+package incidententity
+
+import (
+	"time"
+)
+
+// Incident groups related threats of one rule and tenant that share a user or IP address and
+// happened close together. UserID and IPAddress are those of its first threat.
+type Incident struct {
+	ID          uint64    `json:"id" gorm:"primaryKey;autoIncrement"`
+	Title       string    `json:"title" gorm:"not null;type:varchar(255)"`
+	RuleID      string    `json:"ruleId" gorm:"type:varchar(255);index:idx_incident_rule_user"`
+	ThreatType  string    `json:"threatType" gorm:"type:varchar(255)"`
+	UserID      string    `json:"userId" gorm:"type:varchar(255);index:idx_incident_rule_user"`
+	Tenant      string    `json:"tenant" gorm:"not null;type:varchar(64);default:default;index"`
+	IPAddress   string    `json:"ipAddress" gorm:"type:varchar(255)"`
+	Severity    string    `json:"severity" gorm:"type:varchar(255)"`
+	FirstSeen   time.Time `json:"firstSeen" gorm:"not null"`
+	LastSeen    time.Time `json:"lastSeen" gorm:"not null;index"`
+	ThreatCount int       `json:"threatCount" gorm:"not null"`
+	CreatedAt   time.Time `json:"createdAt" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `json:"-" gorm:"autoUpdateTime"`
+}
@@ -0,0 +1,33 @@
+package outboxentity
+
+import (
+	"time"
+)
+
+// EventLogIngested is published by the log ingestor for every stored log_data row
+const EventLogIngested = "log.ingested"
+
+// OutboxEvent mirrors the log ingestor's outbox table
+type OutboxEvent struct {
+	ID          uint64    `json:"id" gorm:"primaryKey;autoIncrement"`
+	EventType   string    `json:"eventType" gorm:"not null;type:varchar(64);index"`
+	AggregateID uint64    `json:"aggregateId" gorm:"not null"`
+	Payload     string    `json:"payload" gorm:"not null;type:text"`
+	CreatedAt   time.Time `json:"createdAt" gorm:"autoCreateTime;index"`
+}
+
+func (OutboxEvent) TableName() string {
+	return "outbox_events"
+}
+
+// ConsumerOffset is the last outbox event a consumer has fully handled
+type ConsumerOffset struct {
+	Consumer    string    `json:"consumer" gorm:"primaryKey;type:varchar(64)"`
+	LastEventID uint64    `json:"lastEventId" gorm:"not null"`
+	CreatedAt   time.Time `json:"-" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `json:"updatedAt" gorm:"autoUpdateTime"`
+}
+
+func (ConsumerOffset) TableName() string {
+	return "outbox_consumer_offsets"
+}
@@ -2,18 +2,50 @@ package threatentity
 
 import (
 	"time"
+
+	evidenceentity "github.com/yatender-pareek/threat-analyzer-service/src/models/evidence-model"
+	triageentity "github.com/yatender-pareek/threat-analyzer-service/src/models/triage-model"
+	"gorm.io/gorm"
+)
+
+const (
+	StatusOpen          = "open"
+	StatusAcknowledged  = "acknowledged"
+	StatusInvestigating = "investigating"
+	StatusResolved      = "resolved"
+	StatusFalsePositive = "false_positive"
 )
 
+// IsClosedStatus reports whether a status ends triage and therefore needs a resolution reason
+func IsClosedStatus(status string) bool {
+	return status == StatusResolved || status == StatusFalsePositive
+}
+
 type Threat struct {
-	ID            uint64    `json:"id" gorm:"primaryKey;autoIncrement"`
-	Timestamp     time.Time `json:"timestamp" gorm:"not null"`
-	UserID        string    `json:"userId" gorm:"not null;type:varchar(255)"`
-	IPAddress     string    `json:"ipAddress" gorm:"not null;type:varchar(255)"`
-	Action        string    `json:"action" gorm:"not null;type:varchar(255)"`
-	FileName      *string   `json:"fileName" gorm:"type:varchar(255)"`
-	DatabaseQuery *string   `json:"databaseQuery" gorm:"type:text"`
-	CreatedAt     time.Time `json:"-" gorm:"autoCreateTime"`
-	UpdatedAt     time.Time `json:"-" gorm:"autoUpdateTime"`
-	ThreatType    string    `json:"threatType" gorm:"type:varchar(255)"`
-	Severity      string    `json:"severity" gorm:"type:varchar(255)"`
+	ID            uint64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	Timestamp     time.Time  `json:"timestamp" gorm:"not null"`
+	UserID        string     `json:"userId" gorm:"not null;type:varchar(255)"`
+	IPAddress     string     `json:"ipAddress" gorm:"not null;type:varchar(255)"`
+	Action        string     `json:"action" gorm:"not null;type:varchar(255)"`
+	FileName      *string    `json:"fileName" gorm:"type:varchar(255)"`
+	DatabaseQuery *string    `json:"databaseQuery" gorm:"type:text"`
+	Source        string     `json:"source" gorm:"type:varchar(255);index"`
+	Tenant        string     `json:"tenant" gorm:"not null;type:varchar(64);default:default;index"`
+	CreatedAt     time.Time  `json:"-" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time  `json:"-" gorm:"autoUpdateTime"`
+	ThreatType    string     `json:"threatType" gorm:"type:varchar(255)"`
+	Severity      string     `json:"severity" gorm:"type:varchar(255)"`
+	RuleID        string     `json:"ruleId" gorm:"type:varchar(255);index"`
+	Fingerprint   string     `json:"fingerprint" gorm:"type:varchar(64);uniqueIndex;default:null"`
+	IncidentID    *uint64    `json:"incidentId" gorm:"index"`
+	Status        string     `json:"status" gorm:"not null;type:varchar(32);default:open;index"`
+	AssigneeID    *uint      `json:"assigneeId" gorm:"index"`
+	Resolution    *string    `json:"resolution" gorm:"type:text"`
+	ResolvedAt    *time.Time `json:"resolvedAt"`
+	// DeletedAt hides deleted threats while keeping their triage trail; re-detections don't restore them
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	Evidence []evidenceentity.ThreatEvidence `json:"evidence,omitempty" gorm:"-"`
+	Comments []triageentity.ThreatComment    `json:"comments,omitempty" gorm:"-"`
+	History  []triageentity.ThreatHistory    `json:"history,omitempty" gorm:"-"`
 }
@@ -0,0 +1,33 @@
+package triageentity
+
+import (
+	"time"
+)
+
+// ThreatComment is an analyst note left on a threat during triage
+type ThreatComment struct {
+	ID        uint64    `json:"id" gorm:"primaryKey;autoIncrement"`
+	ThreatID  uint64    `json:"threatId" gorm:"not null;index"`
+	Author    string    `json:"author" gorm:"not null;type:varchar(255)"`
+	Body      string    `json:"body" gorm:"not null;type:text"`
+	CreatedAt time.Time `json:"createdAt" gorm:"autoCreateTime"`
+}
+
+func (ThreatComment) TableName() string {
+	return "threat_comments"
+}
+
+// ThreatHistory records one change of a threat's status, assignee or resolution, or its deletion
+type ThreatHistory struct {
+	ID        uint64    `json:"id" gorm:"primaryKey;autoIncrement"`
+	ThreatID  uint64    `json:"threatId" gorm:"not null;index"`
+	Field     string    `json:"field" gorm:"not null;type:varchar(32)"`
+	OldValue  *string   `json:"oldValue" gorm:"type:text"`
+	NewValue  *string   `json:"newValue" gorm:"type:text"`
+	ChangedBy string    `json:"changedBy" gorm:"not null;type:varchar(255)"`
+	CreatedAt time.Time `json:"createdAt" gorm:"autoCreateTime"`
+}
+
+func (ThreatHistory) TableName() string {
+	return "threat_history"
+}
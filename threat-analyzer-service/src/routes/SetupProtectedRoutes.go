@@ -2,15 +2,51 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
+	authcontroller "github.com/yatender-pareek/identity/src/controllers/auth-controller"
+	mfacontroller "github.com/yatender-pareek/identity/src/controllers/mfa-controller"
+	tokenservice "github.com/yatender-pareek/identity/src/services/token-service"
+	"github.com/yatender-pareek/identity/src/tenancy"
+	incidentcontroller "github.com/yatender-pareek/threat-analyzer-service/src/controllers/incident-controller"
+	rulecontroller "github.com/yatender-pareek/threat-analyzer-service/src/controllers/rule-controller"
 	threatcontroller "github.com/yatender-pareek/threat-analyzer-service/src/controllers/threat-controller"
 )
 
 func SetupProtectedRoutes(r *gin.RouterGroup) *gin.RouterGroup {
-	r.POST("/threats/analyze", threatcontroller.AnalyzeThreats)
-	r.GET("/threats", threatcontroller.GetAllThreats)
-	r.GET("/threats/search", threatcontroller.SearchThreats)
-	r.GET("/threats/:threatId", threatcontroller.GetThreatByID)
-	r.DELETE("/threats/:threatId", threatcontroller.DeletethreatByID)
+	r.POST("/logout", authcontroller.Logout)
+	// MFA is part of logging in, so it is managed on the token issuer
+	if tokenservice.CanIssue() {
+		r.GET("/mfa", mfacontroller.GetMFAStatus)
+		r.POST("/mfa/totp", mfacontroller.EnrollTOTP)
+		r.POST("/mfa/totp/confirm", mfacontroller.ConfirmTOTP)
+		r.DELETE("/mfa/totp", mfacontroller.DisableTOTP)
+		r.POST("/mfa/recovery-codes", mfacontroller.RegenerateRecoveryCodes)
+		r.GET("/mfa/policy", tenancy.Require(tenancy.PermManageUsers), mfacontroller.GetMFAPolicy)
+		r.PUT("/mfa/policy", tenancy.Require(tenancy.PermManageUsers), mfacontroller.SetMFAPolicy)
+	}
+
+	read := r.Group("", tenancy.Require(tenancy.PermRead))
+	read.GET("/threats", threatcontroller.GetAllThreats)
+	read.GET("/threats/search", threatcontroller.SearchThreats)
+	read.GET("/threats/:threatId", threatcontroller.GetThreatByID)
+	read.GET("/incidents", incidentcontroller.GetAllIncidents)
+	read.GET("/incidents/search", incidentcontroller.SearchIncidents)
+	read.GET("/incidents/:incidentId", incidentcontroller.GetIncidentByID)
+	read.GET("/rules", rulecontroller.GetRules)
+
+	analyze := r.Group("", tenancy.Require(tenancy.PermAnalyze))
+	analyze.POST("/threats/analyze", threatcontroller.AnalyzeThreats)
+	analyze.PATCH("/threats/:threatId", threatcontroller.UpdateThreat)
+	analyze.POST("/incidents", incidentcontroller.CreateIncident)
+	analyze.PATCH("/incidents/:incidentId", incidentcontroller.UpdateIncident)
+
+	remove := r.Group("", tenancy.Require(tenancy.PermDelete))
+	remove.DELETE("/threats/:threatId", threatcontroller.DeletethreatByID)
+	remove.DELETE("/incidents/:incidentId", incidentcontroller.DeleteIncidentByID)
+
+	admin := r.Group("", tenancy.RequirePlatformAdmin())
+	admin.GET("/threats/checkpoints", threatcontroller.GetCheckpoints)
+	admin.POST("/threats/checkpoints/reset", threatcontroller.ResetCheckpoints)
+	admin.POST("/rules/reload", rulecontroller.ReloadRules)
 
 	return r
 }
@@ -0,0 +1,472 @@
+package ruleengine
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	logDataentity "github.com/yatender-pareek/threat-analyzer-service/src/models/log-data-model"
+)
+
+// attributePrefix introduces fields read from a log's attributes, e.g. attr.user_agent
+const attributePrefix = "attr."
+
+// fieldValue resolves a rule field name against a log entry; missing values
+// behave like SQL NULL and fail every condition except not_exists
+func fieldValue(entry *logDataentity.LogData, field string) (string, bool) {
+	if key, ok := strings.CutPrefix(field, attributePrefix); ok {
+		value, found := entry.Attributes[key]
+		return value, found
+	}
+	switch field {
+	case "user_id":
+		return entry.UserID, true
+	case "ip_address":
+		return entry.IPAddress, true
+	case "action":
+		return entry.Action, true
+	case "file_name":
+		if entry.FileName == nil {
+			return "", false
+		}
+		return *entry.FileName, true
+	case "database_query":
+		if entry.DatabaseQuery == nil {
+			return "", false
+		}
+		return *entry.DatabaseQuery, true
+	case "hour":
+		// Read in a fixed zone, so rules do not depend on the zone the service runs in
+		return strconv.Itoa(entry.Timestamp.In(hourLocation).Hour()), true
+	case "source":
+		return entry.Source, entry.Source != ""
+	case "tenant":
+		return entry.EffectiveTenant(), true
+	}
+	return "", false
+}
+
+func isKnownField(field string) bool {
+	if key, ok := strings.CutPrefix(field, attributePrefix); ok {
+		return key != ""
+	}
+	switch field {
+	case "user_id", "ip_address", "action", "file_name", "database_query", "hour", "source", "tenant":
+		return true
+	}
+	return false
+}
+
+func (c *Condition) matches(entry *logDataentity.LogData) bool {
+	value, ok := fieldValue(entry, c.Field)
+	switch c.Op {
+	case "exists":
+		return ok
+	case "not_exists":
+		return !ok
+	}
+	if !ok {
+		return false
+	}
+	value = strings.ToLower(value)
+
+	switch c.Op {
+	case "eq", "in":
+		for _, operand := range c.operands() {
+			if value == operand {
+				return true
+			}
+		}
+		return false
+	case "neq", "not_in":
+		for _, operand := range c.operands() {
+			if value == operand {
+				return false
+			}
+		}
+		return true
+	case "contains":
+		for _, operand := range c.operands() {
+			if strings.Contains(value, operand) {
+				return true
+			}
+		}
+		return false
+	case "prefix":
+		for _, operand := range c.operands() {
+			if strings.HasPrefix(value, operand) {
+				return true
+			}
+		}
+		return false
+	case "between":
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return false
+		}
+		low, _ := strconv.ParseFloat(c.Values[0], 64)
+		high, _ := strconv.ParseFloat(c.Values[1], 64)
+		return number >= low && number <= high
+	}
+	return false
+}
+
+func matchesAll(conditions []Condition, entry *logDataentity.LogData) bool {
+	for i := range conditions {
+		if !conditions[i].matches(entry) {
+			return false
+		}
+	}
+	return true
+}
+
+func filterEvents(events []*logDataentity.LogData, conditions ...[]Condition) []*logDataentity.LogData {
+	var result []*logDataentity.LogData
+	for _, event := range events {
+		ok := true
+		for _, set := range conditions {
+			if !matchesAll(set, event) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			result = append(result, event)
+		}
+	}
+	return result
+}
+
+// Detection is a log entry flagged by a rule together with the logs that made the rule fire
+type Detection struct {
+	Log      logDataentity.LogData
+	Evidence []logDataentity.LogData
+}
+
+// firing is one occurrence of a rule: the events that satisfied it and the events it flags
+type firing struct {
+	evidence []*logDataentity.LogData
+	flagged  []*logDataentity.LogData
+}
+
+// Evaluate runs a rule over the given logs and returns the entries it flags,
+// ordered by user and timestamp with identical rows collapsed like SELECT DISTINCT
+func Evaluate(rule *Rule, logs []logDataentity.LogData) []Detection {
+	var firings []firing
+	for _, events := range groupEvents(rule.GroupBy, logs) {
+		switch rule.Type {
+		case RuleTypeMatch:
+			for _, event := range filterEvents(events, rule.Conditions) {
+				single := []*logDataentity.LogData{event}
+				firings = append(firings, newFiring(rule, events, single, single, single))
+			}
+		case RuleTypeThreshold:
+			firings = append(firings, evaluateThreshold(rule, events)...)
+		case RuleTypeSequence:
+			firings = append(firings, evaluateSequence(rule, events)...)
+		}
+	}
+	return collectDetections(firings)
+}
+
+// GroupKey returns the entry's tenant and the value of the rule's group_by fields, so events
+// of different tenants are never correlated. Entries missing one of the fields belong to no
+// group and are never evaluated.
+func (r *Rule) GroupKey(entry *logDataentity.LogData) (string, bool) {
+	return groupKey(r.GroupBy, entry)
+}
+
+func groupKey(groupBy []string, entry *logDataentity.LogData) (string, bool) {
+	parts := make([]string, 0, len(groupBy)+1)
+	parts = append(parts, entry.EffectiveTenant())
+	for _, field := range groupBy {
+		value, ok := fieldValue(entry, field)
+		if !ok {
+			return "", false
+		}
+		parts = append(parts, strings.ToLower(value))
+	}
+	return strings.Join(parts, "\x00"), true
+}
+
+// groupEvents partitions logs by tenant and the rule's group_by fields, each group sorted by time
+func groupEvents(groupBy []string, logs []logDataentity.LogData) map[string][]*logDataentity.LogData {
+	groups := make(map[string][]*logDataentity.LogData)
+	for i := range logs {
+		entry := &logs[i]
+		key, ok := groupKey(groupBy, entry)
+		if !ok {
+			continue
+		}
+		groups[key] = append(groups[key], entry)
+	}
+	for _, events := range groups {
+		sort.SliceStable(events, func(i, j int) bool { return chronological(events[i], events[j]) })
+	}
+	return groups
+}
+
+// newFiring applies the rule's emit settings; anchors open the emit window in window mode
+func newFiring(rule *Rule, events, evidence, matched, anchors []*logDataentity.LogData) firing {
+	if rule.Emit.Mode == EmitWindow {
+		return firing{evidence: evidence, flagged: windowEvents(rule, events, anchors)}
+	}
+	return firing{evidence: evidence, flagged: matched}
+}
+
+// evaluateThreshold counts matching events per tumbling bucket aligned to the epoch
+func evaluateThreshold(rule *Rule, events []*logDataentity.LogData) []firing {
+	bucketSize := int64(rule.Threshold.Bucket)
+	buckets := make(map[int64][]*logDataentity.LogData)
+	var keys []int64
+	for _, event := range filterEvents(events, rule.Conditions) {
+		var key int64
+		if bucketSize > 0 {
+			nanos := event.Timestamp.UnixNano()
+			key = nanos / bucketSize
+			if nanos%bucketSize < 0 {
+				key--
+			}
+		}
+		if _, exists := buckets[key]; !exists {
+			keys = append(keys, key)
+		}
+		buckets[key] = append(buckets[key], event)
+	}
+
+	var firings []firing
+	for _, key := range keys {
+		bucket := buckets[key]
+		count := len(bucket)
+		if field := rule.Threshold.DistinctField; field != "" {
+			seen := map[string]bool{}
+			for _, event := range bucket {
+				if value, ok := fieldValue(event, field); ok {
+					seen[strings.ToLower(value)] = true
+				}
+			}
+			count = len(seen)
+		}
+		if count >= rule.Threshold.MinCount {
+			firings = append(firings, newFiring(rule, events, bucket, bucket, bucket[:1]))
+		}
+	}
+	return firings
+}
+
+type sequenceResult struct {
+	hits [][]*logDataentity.LogData
+	ok   bool
+}
+
+type sequenceKey struct {
+	step  int
+	event *logDataentity.LogData
+}
+
+// evaluateSequence finds ordered chains of step events. Each step's events must
+// occur at or before the event matched by the following step, and the whole chain
+// must fit inside rule.Within when it is set. Every last-step event completing a
+// chain is one firing.
+func evaluateSequence(rule *Rule, events []*logDataentity.LogData) []firing {
+	last := len(rule.Steps) - 1
+	candidates := make([][]*logDataentity.LogData, len(rule.Steps))
+	for i := range rule.Steps {
+		candidates[i] = filterEvents(events, rule.Conditions, rule.Steps[i].Conditions)
+	}
+	emitSteps := make(map[string]bool)
+	for _, name := range rule.Emit.Steps {
+		emitSteps[name] = true
+	}
+
+	var firings []firing
+	within := time.Duration(rule.Within)
+	for _, tail := range candidates[last] {
+		windowStart := tail.Timestamp.Add(-within)
+		memo := make(map[sequenceKey]sequenceResult)
+
+		var resolve func(k int, next *logDataentity.LogData) sequenceResult
+		resolve = func(k int, next *logDataentity.LogData) sequenceResult {
+			key := sequenceKey{step: k, event: next}
+			if cached, ok := memo[key]; ok {
+				return cached
+			}
+			step := &rule.Steps[k]
+			hits := make([][]*logDataentity.LogData, k+1)
+			var qualifying []*logDataentity.LogData
+			for _, candidate := range candidates[k] {
+				if candidate.Timestamp.After(next.Timestamp) {
+					break
+				}
+				if within > 0 && candidate.Timestamp.Before(windowStart) {
+					continue
+				}
+				if !relatedToNext(step, candidate, next) {
+					continue
+				}
+				if k > 0 {
+					sub := resolve(k-1, candidate)
+					if !sub.ok {
+						continue
+					}
+					for i := range sub.hits {
+						hits[i] = append(hits[i], sub.hits[i]...)
+					}
+				}
+				qualifying = append(qualifying, candidate)
+			}
+			hits[k] = qualifying
+			result := sequenceResult{hits: hits, ok: len(qualifying) >= step.MinCount}
+			memo[key] = result
+			return result
+		}
+
+		result := resolve(last-1, tail)
+		if !result.ok {
+			continue
+		}
+		stepHits := append(result.hits, []*logDataentity.LogData{tail})
+		var evidence, matched []*logDataentity.LogData
+		for i, hits := range stepHits {
+			evidence = append(evidence, hits...)
+			if len(emitSteps) == 0 || emitSteps[rule.Steps[i].Name] {
+				matched = append(matched, hits...)
+			}
+		}
+		firings = append(firings, newFiring(rule, events, evidence, matched, stepHits[0]))
+	}
+	return firings
+}
+
+func relatedToNext(step *Step, candidate, next *logDataentity.LogData) bool {
+	for _, field := range step.SameAsNext {
+		a, okA := fieldValue(candidate, field)
+		b, okB := fieldValue(next, field)
+		if !okA || !okB || !strings.EqualFold(a, b) {
+			return false
+		}
+	}
+	for _, field := range step.DiffersFromNext {
+		a, okA := fieldValue(candidate, field)
+		b, okB := fieldValue(next, field)
+		if !okA || !okB || strings.EqualFold(a, b) {
+			return false
+		}
+	}
+	return true
+}
+
+// windowEvents returns group events inside the emit window opened by each anchor
+func windowEvents(rule *Rule, events, anchors []*logDataentity.LogData) []*logDataentity.LogData {
+	window := time.Duration(rule.Emit.Window)
+	var result []*logDataentity.LogData
+	for _, anchor := range anchors {
+		end := anchor.Timestamp.Add(window)
+		for _, event := range events {
+			if event.Timestamp.Before(anchor.Timestamp) || event.Timestamp.After(end) {
+				continue
+			}
+			if matchesAll(rule.Emit.Conditions, event) {
+				result = append(result, event)
+			}
+		}
+	}
+	return result
+}
+
+// collectDetections collapses flagged rows with identical content and merges the
+// evidence of every firing that flagged them, always including the row itself
+func collectDetections(firings []firing) []Detection {
+	var flagged []*logDataentity.LogData
+	evidenceByRow := make(map[string]map[*logDataentity.LogData]bool)
+	for _, f := range firings {
+		for _, event := range f.flagged {
+			key := rowKey(event)
+			if evidenceByRow[key] == nil {
+				evidenceByRow[key] = make(map[*logDataentity.LogData]bool)
+			}
+			evidenceByRow[key][event] = true
+			for _, related := range f.evidence {
+				evidenceByRow[key][related] = true
+			}
+			flagged = append(flagged, event)
+		}
+	}
+
+	sort.SliceStable(flagged, func(i, j int) bool {
+		if flagged[i].UserID != flagged[j].UserID {
+			return flagged[i].UserID < flagged[j].UserID
+		}
+		return chronological(flagged[i], flagged[j])
+	})
+
+	seen := make(map[string]bool)
+	detections := make([]Detection, 0, len(flagged))
+	for _, event := range flagged {
+		key := rowKey(event)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		related := make([]*logDataentity.LogData, 0, len(evidenceByRow[key]))
+		for entry := range evidenceByRow[key] {
+			related = append(related, entry)
+		}
+		sort.Slice(related, func(i, j int) bool { return chronological(related[i], related[j]) })
+		evidence := make([]logDataentity.LogData, len(related))
+		for i, entry := range related {
+			evidence[i] = *entry
+		}
+		detections = append(detections, Detection{Log: *event, Evidence: evidence})
+	}
+	return detections
+}
+
+func chronological(a, b *logDataentity.LogData) bool {
+	if !a.Timestamp.Equal(b.Timestamp) {
+		return a.Timestamp.Before(b.Timestamp)
+	}
+	return a.ID < b.ID
+}
+
+func rowKey(entry *logDataentity.LogData) string {
+	fileName, _ := fieldValue(entry, "file_name")
+	databaseQuery, _ := fieldValue(entry, "database_query")
+	parts := []string{
+		strconv.FormatInt(entry.Timestamp.UnixNano(), 10),
+		entry.UserID,
+		entry.IPAddress,
+		entry.Action,
+		strconv.FormatBool(entry.FileName != nil) + fileName,
+		strconv.FormatBool(entry.DatabaseQuery != nil) + databaseQuery,
+	}
+	// Only other tenants extend the key, so fingerprints of threats stored before
+	// tenants existed stay the same
+	if tenant := entry.EffectiveTenant(); tenant != logDataentity.DefaultTenant {
+		parts = append(parts, tenant)
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// Fingerprint identifies a detection across analysis runs: the rule, the user, the flagged log
+// and the sorted IDs of its evidence. Firings over different evidence get different fingerprints,
+// and the same evidence gets the same one whenever it is found again.
+func Fingerprint(rule *Rule, detection *Detection) string {
+	ids := make([]uint64, len(detection.Evidence))
+	for i, entry := range detection.Evidence {
+		ids[i] = entry.ID
+	}
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+	parts := []string{rule.ID, detection.Log.UserID, strconv.FormatUint(detection.Log.ID, 10)}
+	for _, id := range ids {
+		parts = append(parts, strconv.FormatUint(id, 10))
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,429 @@
+package ruleengine
+
+import (
+	"testing"
+	"time"
+
+	logDataentity "github.com/yatender-pareek/threat-analyzer-service/src/models/log-data-model"
+)
+
+// base is a bucket-aligned instant, so threshold buckets start at base
+var base = time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+
+func builtinRule(t *testing.T, id string) *Rule {
+	t.Helper()
+	rules, err := loadRules("")
+	if err != nil {
+		t.Fatalf("loading built-in rules: %v", err)
+	}
+	for i := range rules {
+		if rules[i].ID == id {
+			return &rules[i]
+		}
+	}
+	t.Fatalf("no built-in rule %s", id)
+	return nil
+}
+
+type fixture struct {
+	id     uint64
+	at     time.Duration
+	user   string
+	ip     string
+	action string
+	file   string
+	query  string
+	tenant string
+	source string
+}
+
+func (f fixture) log() logDataentity.LogData {
+	entry := logDataentity.LogData{
+		ID:        f.id,
+		Timestamp: base.Add(f.at),
+		UserID:    f.user,
+		IPAddress: f.ip,
+		Action:    f.action,
+		Tenant:    f.tenant,
+		Source:    f.source,
+	}
+	if f.file != "" {
+		entry.FileName = &f.file
+	}
+	if f.query != "" {
+		entry.DatabaseQuery = &f.query
+	}
+	return entry
+}
+
+func fixtureLogs(fixtures []fixture) []logDataentity.LogData {
+	logs := make([]logDataentity.LogData, len(fixtures))
+	for i, f := range fixtures {
+		logs[i] = f.log()
+	}
+	return logs
+}
+
+func detectedIDs(detections []Detection) []uint64 {
+	ids := make([]uint64, len(detections))
+	for i, detection := range detections {
+		ids[i] = detection.Log.ID
+	}
+	return ids
+}
+
+func equalIDs(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestBuiltinRules(t *testing.T) {
+	const payroll, dump, design, readme = "/secure/payroll.csv", "/db_dump.sql", "/confidential/design.pdf", "/public/readme.txt"
+	// audited is the source of the services' audit events; spoofed is what a client could set before it was reserved
+	const audited, spoofed = "audit:log-ingestor-service", "log-ingestor-service"
+	tests := []struct {
+		name string
+		rule string
+		logs []fixture
+		want []uint64
+	}{
+		{
+			name: "credential stuffing flags the failures before a success",
+			rule: "credential-stuffing",
+			logs: []fixture{
+				{id: 1, at: 0, user: "alice", action: "login_failed", file: payroll},
+				{id: 2, at: time.Minute, user: "alice", action: "login_failed", file: dump},
+				{id: 3, at: 2 * time.Minute, user: "alice", action: "login_failed", file: readme},
+				{id: 4, at: 3 * time.Minute, user: "alice", action: "login_success"},
+			},
+			want: []uint64{1, 2, 3},
+		},
+		{
+			name: "credential stuffing needs three failures",
+			rule: "credential-stuffing",
+			logs: []fixture{
+				{id: 1, at: 0, user: "alice", action: "login_failed", file: payroll},
+				{id: 2, at: time.Minute, user: "alice", action: "login_failed", file: dump},
+				{id: 3, at: 2 * time.Minute, user: "alice", action: "login_success"},
+			},
+		},
+		{
+			name: "credential stuffing needs the success after the failures",
+			rule: "credential-stuffing",
+			logs: []fixture{
+				{id: 1, at: 0, user: "alice", action: "login_success"},
+				{id: 2, at: time.Minute, user: "alice", action: "login_failed", file: payroll},
+				{id: 3, at: 2 * time.Minute, user: "alice", action: "login_failed", file: dump},
+				{id: 4, at: 3 * time.Minute, user: "alice", action: "login_failed", file: readme},
+			},
+		},
+		{
+			name: "credential stuffing ignores failures on unlisted files",
+			rule: "credential-stuffing",
+			logs: []fixture{
+				{id: 1, at: 0, user: "alice", action: "login_failed", file: "/tmp/a"},
+				{id: 2, at: time.Minute, user: "alice", action: "login_failed", file: "/tmp/b"},
+				{id: 3, at: 2 * time.Minute, user: "alice", action: "login_failed", file: "/tmp/c"},
+				{id: 4, at: 3 * time.Minute, user: "alice", action: "login_success"},
+			},
+		},
+		{
+			name: "credential stuffing does not combine users",
+			rule: "credential-stuffing",
+			logs: []fixture{
+				{id: 1, at: 0, user: "alice", action: "login_failed", file: payroll},
+				{id: 2, at: time.Minute, user: "bob", action: "login_failed", file: dump},
+				{id: 3, at: 2 * time.Minute, user: "alice", action: "login_failed", file: readme},
+				{id: 4, at: 3 * time.Minute, user: "bob", action: "login_success"},
+			},
+		},
+		{
+			name: "credential stuffing does not combine tenants",
+			rule: "credential-stuffing",
+			logs: []fixture{
+				{id: 1, at: 0, user: "alice", action: "login_failed", file: payroll, tenant: "acme"},
+				{id: 2, at: time.Minute, user: "alice", action: "login_failed", file: dump, tenant: "acme"},
+				{id: 3, at: 2 * time.Minute, user: "alice", action: "login_failed", file: readme, tenant: "acme"},
+				{id: 4, at: 3 * time.Minute, user: "alice", action: "login_success", tenant: "globex"},
+			},
+		},
+		{
+			name: "privilege escalation flags the failed login before a modification",
+			rule: "privilege-escalation",
+			logs: []fixture{
+				{id: 1, at: 0, user: "alice", action: "login_failed", query: "DELETE FROM users"},
+				{id: 2, at: 4 * time.Minute, user: "alice", action: "query", query: "INSERT INTO grants VALUES (1)"},
+			},
+			want: []uint64{1},
+		},
+		{
+			name: "privilege escalation ignores failed logins without a modifying query",
+			rule: "privilege-escalation",
+			logs: []fixture{
+				{id: 1, at: 0, user: "alice", action: "login_failed", query: "SELECT 1"},
+				{id: 2, at: time.Minute, user: "alice", action: "query", query: "DELETE FROM users"},
+			},
+			want: []uint64{},
+		},
+		{
+			name: "account takeover flags sensitive access within ten minutes of the first",
+			rule: "account-takeover",
+			logs: []fixture{
+				{id: 1, at: 0, user: "alice", ip: "10.0.0.1", action: "file_access", file: payroll},
+				{id: 2, at: 5 * time.Minute, user: "alice", ip: "10.0.0.2", action: "file_access", file: dump},
+				{id: 3, at: 8 * time.Minute, user: "alice", ip: "10.0.0.2", action: "file_access", file: design},
+				{id: 4, at: 9 * time.Minute, user: "alice", ip: "10.0.0.2", action: "file_access", file: readme},
+				{id: 5, at: 11 * time.Minute, user: "alice", ip: "10.0.0.2", action: "file_access", file: payroll},
+			},
+			want: []uint64{1, 2, 3},
+		},
+		{
+			name: "account takeover needs two addresses",
+			rule: "account-takeover",
+			logs: []fixture{
+				{id: 1, at: 0, user: "alice", ip: "10.0.0.1", action: "file_access", file: payroll},
+				{id: 2, at: 5 * time.Minute, user: "alice", ip: "10.0.0.1", action: "file_access", file: dump},
+			},
+		},
+		{
+			name: "account takeover needs both accesses within ten minutes",
+			rule: "account-takeover",
+			logs: []fixture{
+				{id: 1, at: 0, user: "alice", ip: "10.0.0.1", action: "file_access", file: payroll},
+				{id: 2, at: 11 * time.Minute, user: "alice", ip: "10.0.0.2", action: "file_access", file: dump},
+			},
+		},
+		{
+			name: "data exfiltration flags two distinct files in one bucket",
+			rule: "data-exfiltration",
+			logs: []fixture{
+				{id: 1, at: 0, user: "alice", action: "file_access", file: payroll},
+				{id: 2, at: 10 * time.Second, user: "alice", action: "file_access", file: dump},
+				{id: 3, at: 20 * time.Second, user: "alice", action: "file_access", file: readme},
+			},
+			want: []uint64{1, 2},
+		},
+		{
+			name: "data exfiltration counts distinct files",
+			rule: "data-exfiltration",
+			logs: []fixture{
+				{id: 1, at: 0, user: "alice", action: "file_access", file: payroll},
+				{id: 2, at: 10 * time.Second, user: "alice", action: "file_access", file: payroll},
+			},
+		},
+		{
+			name: "data exfiltration buckets are aligned, not sliding",
+			rule: "data-exfiltration",
+			logs: []fixture{
+				{id: 1, at: 25 * time.Second, user: "alice", action: "file_access", file: payroll},
+				{id: 2, at: 35 * time.Second, user: "alice", action: "file_access", file: dump},
+			},
+		},
+		{
+			name: "insider threat flags restricted access between 02:00 and 04:59",
+			rule: "insider-threat",
+			logs: []fixture{
+				{id: 1, at: -8*time.Hour - time.Minute, user: "alice", action: "file_access", file: payroll},
+				{id: 2, at: -8 * time.Hour, user: "alice", action: "file_access", file: payroll},
+				{id: 3, at: -5*time.Hour - time.Minute, user: "alice", action: "file_access", file: readme},
+				{id: 4, at: -5 * time.Hour, user: "alice", action: "file_access", file: payroll},
+				{id: 5, at: -7 * time.Hour, user: "alice", action: "login_success"},
+			},
+			want: []uint64{2, 3},
+		},
+		{
+			name: "identical rows are flagged once",
+			rule: "insider-threat",
+			logs: []fixture{
+				{id: 1, at: -7 * time.Hour, user: "alice", action: "file_access", file: payroll},
+				{id: 2, at: -7 * time.Hour, user: "alice", action: "file_access", file: payroll},
+			},
+			want: []uint64{1},
+		},
+		{
+			name: "platform credential stuffing flags audited failures before a success",
+			rule: "platform-credential-stuffing",
+			logs: []fixture{
+				{id: 1, at: 0, user: "alice", action: "login_failed", source: audited},
+				{id: 2, at: time.Minute, user: "alice", action: "mfa_failed", source: audited},
+				{id: 3, at: 2 * time.Minute, user: "alice", action: "login_failed", source: "audit:threat-analyzer-service"},
+				{id: 4, at: 3 * time.Minute, user: "alice", action: "login_success", source: audited},
+			},
+			want: []uint64{1, 2, 3},
+		},
+		{
+			name: "platform credential stuffing ignores events under a service name without the audit prefix",
+			rule: "platform-credential-stuffing",
+			logs: []fixture{
+				{id: 1, at: 0, user: "alice", action: "login_failed", source: spoofed},
+				{id: 2, at: time.Minute, user: "alice", action: "login_failed", source: spoofed},
+				{id: 3, at: 2 * time.Minute, user: "alice", action: "login_failed", source: spoofed},
+				{id: 4, at: 3 * time.Minute, user: "alice", action: "login_success", source: spoofed},
+			},
+		},
+		{
+			name: "platform password spraying flags five usernames from one address",
+			rule: "platform-password-spraying",
+			logs: []fixture{
+				{id: 1, at: 0, user: "alice", ip: "203.0.113.9", action: "login_failed", source: audited},
+				{id: 2, at: time.Minute, user: "bob", ip: "203.0.113.9", action: "login_failed", source: audited},
+				{id: 3, at: 2 * time.Minute, user: "carol", ip: "203.0.113.9", action: "login_failed", source: audited},
+				{id: 4, at: 3 * time.Minute, user: "dave", ip: "203.0.113.9", action: "login_failed", source: audited},
+				{id: 5, at: 4 * time.Minute, user: "erin", ip: "203.0.113.9", action: "login_failed", source: audited},
+				{id: 6, at: 5 * time.Minute, user: "frank", ip: "198.51.100.7", action: "login_failed", source: audited},
+			},
+			want: []uint64{1, 2, 3, 4, 5},
+		},
+		{
+			name: "platform password spraying counts distinct usernames",
+			rule: "platform-password-spraying",
+			logs: []fixture{
+				{id: 1, at: 0, user: "alice", ip: "203.0.113.9", action: "login_failed", source: audited},
+				{id: 2, at: time.Minute, user: "bob", ip: "203.0.113.9", action: "login_failed", source: audited},
+				{id: 3, at: 2 * time.Minute, user: "carol", ip: "203.0.113.9", action: "login_failed", source: audited},
+				{id: 4, at: 3 * time.Minute, user: "dave", ip: "203.0.113.9", action: "login_failed", source: audited},
+				{id: 5, at: 4 * time.Minute, user: "alice", ip: "203.0.113.9", action: "login_failed", source: audited},
+			},
+		},
+		{
+			name: "platform password spraying ignores events under a service name without the audit prefix",
+			rule: "platform-password-spraying",
+			logs: []fixture{
+				{id: 1, at: 0, user: "alice", ip: "203.0.113.9", action: "login_failed", source: spoofed},
+				{id: 2, at: time.Minute, user: "bob", ip: "203.0.113.9", action: "login_failed", source: spoofed},
+				{id: 3, at: 2 * time.Minute, user: "carol", ip: "203.0.113.9", action: "login_failed", source: spoofed},
+				{id: 4, at: 3 * time.Minute, user: "dave", ip: "203.0.113.9", action: "login_failed", source: spoofed},
+				{id: 5, at: 4 * time.Minute, user: "erin", ip: "203.0.113.9", action: "login_failed", source: spoofed},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := detectedIDs(Evaluate(builtinRule(t, tt.rule), fixtureLogs(tt.logs)))
+			if tt.want == nil {
+				tt.want = []uint64{}
+			}
+			if !equalIDs(got, tt.want) {
+				t.Fatalf("flagged %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateEvidence(t *testing.T) {
+	const payroll, dump = "/secure/payroll.csv", "/db_dump.sql"
+	logs := fixtureLogs([]fixture{
+		{id: 1, at: 0, user: "alice", ip: "10.0.0.1", action: "file_access", file: payroll},
+		{id: 2, at: 5 * time.Minute, user: "alice", ip: "10.0.0.2", action: "file_access", file: dump},
+	})
+	detections := Evaluate(builtinRule(t, "account-takeover"), logs)
+	if len(detections) != 2 {
+		t.Fatalf("expected 2 detections, got %d", len(detections))
+	}
+	for _, detection := range detections {
+		var got []uint64
+		for _, entry := range detection.Evidence {
+			got = append(got, entry.ID)
+		}
+		if !equalIDs(got, []uint64{1, 2}) {
+			t.Fatalf("detection %d carries evidence %v, want both accesses", detection.Log.ID, got)
+		}
+	}
+}
+
+func TestBuiltinRulesAreValid(t *testing.T) {
+	rules, err := loadRules("")
+	if err != nil {
+		t.Fatalf("loading built-in rules: %v", err)
+	}
+	seen := make(map[string]bool)
+	for i := range rules {
+		if err := rules[i].Validate(); err != nil {
+			t.Errorf("rule %s: %v", rules[i].ID, err)
+		}
+		if seen[rules[i].ID] {
+			t.Errorf("rule %s is defined twice", rules[i].ID)
+		}
+		seen[rules[i].ID] = true
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	detection := func(flagged uint64, shift time.Duration, evidence ...uint64) *Detection {
+		d := &Detection{Log: fixture{id: flagged, at: shift, user: "alice", action: "login_failed"}.log()}
+		for i, id := range evidence {
+			d.Evidence = append(d.Evidence, fixture{id: id, at: shift + time.Duration(i)*time.Minute, user: "alice", action: "login_failed"}.log())
+		}
+		return d
+	}
+	stuffing, spraying := &Rule{ID: "credential-stuffing"}, &Rule{ID: "password-spraying"}
+	reference := Fingerprint(stuffing, detection(3, 0, 1, 2, 3, 4))
+	tests := []struct {
+		name      string
+		rule      *Rule
+		detection *Detection
+		same      bool
+	}{
+		{name: "same evidence", rule: stuffing, detection: detection(3, 0, 1, 2, 3, 4), same: true},
+		{name: "same evidence in another order", rule: stuffing, detection: detection(3, 0, 4, 2, 3, 1), same: true},
+		{name: "same evidence reported at another second", rule: stuffing, detection: detection(3, 90*time.Second, 1, 2, 3, 4), same: true},
+		{name: "evidence window one log longer", rule: stuffing, detection: detection(3, 0, 1, 2, 3, 4, 5)},
+		{name: "evidence window shifted", rule: stuffing, detection: detection(3, 0, 2, 3, 4, 5)},
+		{name: "another log flagged by the same firing", rule: stuffing, detection: detection(2, 0, 1, 2, 3, 4)},
+		{name: "another rule", rule: spraying, detection: detection(3, 0, 1, 2, 3, 4)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Fingerprint(tt.rule, tt.detection); (got == reference) != tt.same {
+				t.Fatalf("fingerprint %s, reference %s, want same: %v", got, reference, tt.same)
+			}
+		})
+	}
+}
+
+func TestInsiderThreatHours(t *testing.T) {
+	kolkata := time.FixedZone("IST", 5*3600+1800)
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	utc := func(hour, minute, second int) time.Time {
+		return time.Date(2024, 3, 4, hour, minute, second, 0, time.UTC)
+	}
+	tests := []struct {
+		name     string
+		zone     *time.Location
+		at       time.Time
+		detected bool
+	}{
+		{name: "just before 02:00", at: utc(1, 59, 59)},
+		{name: "02:00", at: utc(2, 0, 0), detected: true},
+		{name: "04:59:59", at: utc(4, 59, 59), detected: true},
+		{name: "05:00", at: utc(5, 0, 0)},
+		{name: "stored with an offset, 02:00 in UTC", at: utc(2, 0, 0).In(kolkata), detected: true},
+		{name: "stored with an offset, 02:00 only locally", at: time.Date(2024, 3, 4, 2, 0, 0, 0, kolkata)},
+		{name: "configured zone, 02:00 there", zone: newYork, at: time.Date(2024, 3, 4, 2, 0, 0, 0, newYork), detected: true},
+		{name: "configured zone, 02:00 in UTC", zone: newYork, at: utc(2, 0, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.zone != nil {
+				hourLocation = tt.zone
+				defer func() { hourLocation = time.UTC }()
+			}
+			file := "/secure/payroll.csv"
+			entry := logDataentity.LogData{ID: 1, Timestamp: tt.at, UserID: "alice", Action: "file_access", FileName: &file}
+			detected := len(Evaluate(builtinRule(t, "insider-threat"), []logDataentity.LogData{entry})) == 1
+			if detected != tt.detected {
+				t.Fatalf("detected %v, want %v", detected, tt.detected)
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package ruleengine
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	// The hour zone is looked up by name, and the service image has no zone database
+	_ "time/tzdata"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed builtin/*.yaml
+var builtinRules embed.FS
+
+type Container struct {
+	rules       []Rule
+	fingerprint string
+	mu          sync.RWMutex
+}
+
+var container *Container
+var once sync.Once
+
+// hourLocation is the time zone the hour field is read in, from THREAT_RULES_TIMEZONE
+var hourLocation = time.UTC
+
+// ErrNotInitialized is returned when rules are used before Init
+var ErrNotInitialized = errors.New("rule engine not initialized; call ruleengine.Init() first")
+
+// Init loads the built-in rules plus any rule files found in THREAT_RULES_DIR
+// and starts polling that directory for changes when one is configured
+func Init() error {
+	var err error
+	once.Do(func() {
+		if name := os.Getenv("THREAT_RULES_TIMEZONE"); name != "" {
+			location, loadErr := time.LoadLocation(name)
+			if loadErr != nil {
+				err = fmt.Errorf("invalid THREAT_RULES_TIMEZONE: %v", loadErr)
+				return
+			}
+			hourLocation = location
+		}
+		container = &Container{}
+		if err = Reload(); err != nil {
+			return
+		}
+		if dir := os.Getenv("THREAT_RULES_DIR"); dir != "" {
+			interval := 30 * time.Second
+			if raw := os.Getenv("THREAT_RULES_RELOAD_INTERVAL"); raw != "" {
+				parsed, parseErr := time.ParseDuration(raw)
+				if parseErr != nil {
+					err = fmt.Errorf("invalid THREAT_RULES_RELOAD_INTERVAL: %v", parseErr)
+					return
+				}
+				if parsed <= 0 {
+					err = fmt.Errorf("invalid THREAT_RULES_RELOAD_INTERVAL %q: must be positive", raw)
+					return
+				}
+				interval = parsed
+			}
+			go watch(dir, interval)
+		}
+	})
+	return err
+}
+
+// GetRules returns the currently loaded rules in evaluation order
+func GetRules() ([]Rule, error) {
+	if container == nil {
+		return nil, ErrNotInitialized
+	}
+	container.mu.RLock()
+	defer container.mu.RUnlock()
+	rules := make([]Rule, len(container.rules))
+	copy(rules, container.rules)
+	return rules, nil
+}
+
+// Reload re-reads every rule file. On error the previously loaded rules stay active.
+func Reload() error {
+	if container == nil {
+		return ErrNotInitialized
+	}
+	dir := os.Getenv("THREAT_RULES_DIR")
+	rules, err := loadRules(dir)
+	if err != nil {
+		return err
+	}
+	fingerprint, _ := dirFingerprint(dir)
+
+	container.mu.Lock()
+	defer container.mu.Unlock()
+	container.rules = rules
+	container.fingerprint = fingerprint
+	log.Printf("Loaded %d detection rules", len(rules))
+	return nil
+}
+
+func loadRules(dir string) ([]Rule, error) {
+	var rules []Rule
+	index := make(map[string]int)
+	add := func(rule Rule) {
+		if i, exists := index[rule.ID]; exists {
+			rules[i] = rule
+			return
+		}
+		index[rule.ID] = len(rules)
+		rules = append(rules, rule)
+	}
+
+	builtinFiles, err := fs.Glob(builtinRules, "builtin/*.yaml")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list built-in rules: %v", err)
+	}
+	sort.Strings(builtinFiles)
+	for _, name := range builtinFiles {
+		data, err := builtinRules.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read built-in rule %s: %v", name, err)
+		}
+		rule, err := parseRule(name, data)
+		if err != nil {
+			return nil, err
+		}
+		rule.Source = "builtin:" + path.Base(name)
+		add(rule)
+	}
+
+	if dir == "" {
+		return rules, nil
+	}
+	files, err := ruleFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range files {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read rule file %s: %v", name, err)
+		}
+		rule, err := parseRule(name, data)
+		if err != nil {
+			return nil, err
+		}
+		rule.Source = name
+		add(rule)
+	}
+	return rules, nil
+}
+
+func parseRule(name string, data []byte) (Rule, error) {
+	var rule Rule
+	var err error
+	if strings.HasSuffix(name, ".json") {
+		decoder := json.NewDecoder(strings.NewReader(string(data)))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&rule)
+	} else {
+		decoder := yaml.NewDecoder(strings.NewReader(string(data)))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&rule)
+	}
+	if err != nil {
+		return Rule{}, fmt.Errorf("failed to parse rule file %s: %v", name, err)
+	}
+	if err := rule.Validate(); err != nil {
+		return Rule{}, fmt.Errorf("invalid rule file %s: %v", name, err)
+	}
+	return rule, nil
+}
+
+func ruleFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules directory %s: %v", dir, err)
+	}
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".yaml", ".yml", ".json":
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// dirFingerprint summarises names, sizes and modification times of the rule files
+func dirFingerprint(dir string) (string, error) {
+	if dir == "" {
+		return "", nil
+	}
+	files, err := ruleFiles(dir)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, name := range files {
+		info, err := os.Stat(name)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "%s:%d:%d;", name, info.Size(), info.ModTime().UnixNano())
+	}
+	return b.String(), nil
+}
+
+func watch(dir string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		fingerprint, err := dirFingerprint(dir)
+		if err != nil {
+			log.Printf("Rule directory check failed: %v", err)
+			continue
+		}
+		container.mu.RLock()
+		changed := fingerprint != container.fingerprint
+		container.mu.RUnlock()
+		if !changed {
+			continue
+		}
+		if err := Reload(); err != nil {
+			log.Printf("Rule reload failed, keeping previous rules: %v", err)
+			container.mu.Lock()
+			container.fingerprint = fingerprint
+			container.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,266 @@
+// Package ruleengine evaluates declarative detection rules against log data
+package ruleengine
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	RuleTypeMatch     = "match"
+	RuleTypeThreshold = "threshold"
+	RuleTypeSequence  = "sequence"
+
+	EmitMatched = "matched"
+	EmitWindow  = "window"
+)
+
+// Rule describes a single detection loaded from a YAML or JSON document
+type Rule struct {
+	ID          string      `yaml:"id" json:"id"`
+	Name        string      `yaml:"name" json:"name"`
+	Description string      `yaml:"description" json:"description,omitempty"`
+	Enabled     *bool       `yaml:"enabled" json:"enabled,omitempty"`
+	Type        string      `yaml:"type" json:"type"`
+	ThreatType  string      `yaml:"threat_type" json:"threatType"`
+	Severity    string      `yaml:"severity" json:"severity"`
+	GroupBy     []string    `yaml:"group_by" json:"groupBy,omitempty"`
+	Conditions  []Condition `yaml:"conditions" json:"conditions,omitempty"`
+	Threshold   *Threshold  `yaml:"threshold" json:"threshold,omitempty"`
+	Steps       []Step      `yaml:"steps" json:"steps,omitempty"`
+	Within      Duration    `yaml:"within" json:"within,omitempty" swaggertype:"string"`
+	Lookback    Duration    `yaml:"lookback" json:"lookback,omitempty" swaggertype:"string"`
+	Emit        Emit        `yaml:"emit" json:"emit"`
+	Source      string      `yaml:"-" json:"source"`
+}
+
+// Condition matches a single log field against one or more values.
+// String comparisons are case-insensitive, mirroring the MySQL collation
+// the original SQL detectors relied on.
+type Condition struct {
+	Field  string   `yaml:"field" json:"field"`
+	Op     string   `yaml:"op" json:"op"`
+	Value  string   `yaml:"value" json:"value,omitempty"`
+	Values []string `yaml:"values" json:"values,omitempty"`
+}
+
+// Threshold fires when a group accumulates enough matching events in a bucket
+type Threshold struct {
+	Bucket        Duration `yaml:"bucket" json:"bucket,omitempty" swaggertype:"string"`
+	MinCount      int      `yaml:"min_count" json:"minCount"`
+	DistinctField string   `yaml:"distinct_field" json:"distinctField,omitempty"`
+}
+
+// Step is one stage of a sequence rule. SameAsNext and DiffersFromNext
+// compare fields against the event matched by the following step.
+type Step struct {
+	Name            string      `yaml:"name" json:"name"`
+	Conditions      []Condition `yaml:"conditions" json:"conditions"`
+	MinCount        int         `yaml:"min_count" json:"minCount,omitempty"`
+	SameAsNext      []string    `yaml:"same_as_next" json:"sameAsNext,omitempty"`
+	DiffersFromNext []string    `yaml:"differs_from_next" json:"differsFromNext,omitempty"`
+}
+
+// Emit selects which log entries become threats once a rule fires
+type Emit struct {
+	Mode       string      `yaml:"mode" json:"mode"`
+	Steps      []string    `yaml:"steps" json:"steps,omitempty"`
+	Window     Duration    `yaml:"window" json:"window,omitempty" swaggertype:"string"`
+	Conditions []Condition `yaml:"conditions" json:"conditions,omitempty"`
+}
+
+// Duration accepts Go duration strings such as "30s" or "10m" in rule files
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string: %v", err)
+	}
+	return d.parse(s)
+}
+
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	return d.parse(node.Value)
+}
+
+func (d *Duration) parse(s string) error {
+	if s == "" {
+		*d = 0
+		return nil
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %v", s, err)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// IsEnabled reports whether the rule should run; rules are enabled unless disabled explicitly
+func (r *Rule) IsEnabled() bool {
+	return r.Enabled == nil || *r.Enabled
+}
+
+// RequiredLookback is how much history before an analysis window the rule needs.
+// An explicit lookback wins; otherwise the widest of within, bucket and emit window is used.
+func (r *Rule) RequiredLookback() time.Duration {
+	if r.Lookback > 0 {
+		return time.Duration(r.Lookback)
+	}
+	lookback := time.Duration(r.Within)
+	if r.Threshold != nil && time.Duration(r.Threshold.Bucket) > lookback {
+		lookback = time.Duration(r.Threshold.Bucket)
+	}
+	if time.Duration(r.Emit.Window) > lookback {
+		lookback = time.Duration(r.Emit.Window)
+	}
+	return lookback
+}
+
+var supportedOps = map[string]bool{
+	"eq": true, "neq": true, "in": true, "not_in": true, "contains": true,
+	"prefix": true, "exists": true, "not_exists": true, "between": true,
+}
+
+// Validate checks the rule for structural errors and fills in defaults
+func (r *Rule) Validate() error {
+	if r.ID == "" {
+		return fmt.Errorf("rule id is required")
+	}
+	if r.ThreatType == "" {
+		return fmt.Errorf("rule %s: threat_type is required", r.ID)
+	}
+	if r.Name == "" {
+		r.Name = r.ThreatType
+	}
+	if r.Severity == "" {
+		return fmt.Errorf("rule %s: severity is required", r.ID)
+	}
+	if r.Within < 0 || r.Lookback < 0 {
+		return fmt.Errorf("rule %s: within and lookback cannot be negative", r.ID)
+	}
+	for _, field := range r.GroupBy {
+		if !isKnownField(field) {
+			return fmt.Errorf("rule %s: unknown group_by field %q", r.ID, field)
+		}
+	}
+	if err := validateConditions(r.Conditions); err != nil {
+		return fmt.Errorf("rule %s: %v", r.ID, err)
+	}
+
+	switch r.Type {
+	case RuleTypeMatch:
+		if len(r.Conditions) == 0 {
+			return fmt.Errorf("rule %s: match rules need at least one condition", r.ID)
+		}
+	case RuleTypeThreshold:
+		if r.Threshold == nil || r.Threshold.MinCount < 1 {
+			return fmt.Errorf("rule %s: threshold.min_count must be at least 1", r.ID)
+		}
+		if r.Threshold.DistinctField != "" && !isKnownField(r.Threshold.DistinctField) {
+			return fmt.Errorf("rule %s: unknown distinct_field %q", r.ID, r.Threshold.DistinctField)
+		}
+	case RuleTypeSequence:
+		if len(r.Steps) < 2 {
+			return fmt.Errorf("rule %s: sequence rules need at least two steps", r.ID)
+		}
+		names := map[string]bool{}
+		for i := range r.Steps {
+			step := &r.Steps[i]
+			if step.Name == "" {
+				step.Name = strconv.Itoa(i)
+			}
+			if names[step.Name] {
+				return fmt.Errorf("rule %s: duplicate step name %q", r.ID, step.Name)
+			}
+			names[step.Name] = true
+			if step.MinCount < 1 {
+				step.MinCount = 1
+			}
+			if i == len(r.Steps)-1 && (step.MinCount > 1 || len(step.SameAsNext) > 0 || len(step.DiffersFromNext) > 0) {
+				return fmt.Errorf("rule %s: the last step cannot use min_count, same_as_next or differs_from_next", r.ID)
+			}
+			if err := validateConditions(step.Conditions); err != nil {
+				return fmt.Errorf("rule %s step %s: %v", r.ID, step.Name, err)
+			}
+			for _, field := range append(append([]string{}, step.SameAsNext...), step.DiffersFromNext...) {
+				if !isKnownField(field) {
+					return fmt.Errorf("rule %s step %s: unknown field %q", r.ID, step.Name, field)
+				}
+			}
+		}
+		for _, name := range r.Emit.Steps {
+			if !names[name] {
+				return fmt.Errorf("rule %s: emit references unknown step %q", r.ID, name)
+			}
+		}
+	default:
+		return fmt.Errorf("rule %s: unsupported type %q", r.ID, r.Type)
+	}
+
+	switch r.Emit.Mode {
+	case "":
+		r.Emit.Mode = EmitMatched
+	case EmitMatched:
+	case EmitWindow:
+		if r.Emit.Window <= 0 {
+			return fmt.Errorf("rule %s: emit.window must be positive in window mode", r.ID)
+		}
+	default:
+		return fmt.Errorf("rule %s: unsupported emit mode %q", r.ID, r.Emit.Mode)
+	}
+	if err := validateConditions(r.Emit.Conditions); err != nil {
+		return fmt.Errorf("rule %s emit: %v", r.ID, err)
+	}
+	return nil
+}
+
+func validateConditions(conditions []Condition) error {
+	for _, cond := range conditions {
+		if !isKnownField(cond.Field) {
+			return fmt.Errorf("unknown field %q", cond.Field)
+		}
+		if !supportedOps[cond.Op] {
+			return fmt.Errorf("unsupported op %q on field %s", cond.Op, cond.Field)
+		}
+		switch cond.Op {
+		case "between":
+			if len(cond.Values) != 2 {
+				return fmt.Errorf("between on field %s needs exactly two values", cond.Field)
+			}
+			for _, v := range cond.Values {
+				if _, err := strconv.ParseFloat(v, 64); err != nil {
+					return fmt.Errorf("between on field %s needs numeric values", cond.Field)
+				}
+			}
+		case "exists", "not_exists":
+		default:
+			if cond.Value == "" && len(cond.Values) == 0 {
+				return fmt.Errorf("op %s on field %s needs a value", cond.Op, cond.Field)
+			}
+		}
+	}
+	return nil
+}
+
+// operands returns every value a condition compares against, lower-cased
+func (c *Condition) operands() []string {
+	values := make([]string, 0, len(c.Values)+1)
+	if c.Value != "" {
+		values = append(values, strings.ToLower(c.Value))
+	}
+	for _, v := range c.Values {
+		values = append(values, strings.ToLower(v))
+	}
+	return values
+}
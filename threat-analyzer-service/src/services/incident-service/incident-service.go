@@ -0,0 +1,231 @@
+package incidentservice
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/yatender-pareek/identity/src/tenancy"
+	mysqlconfig "github.com/yatender-pareek/threat-analyzer-service/src/config/my-sql-config"
+	incidententity "github.com/yatender-pareek/threat-analyzer-service/src/models/incident-model"
+	threatentity "github.com/yatender-pareek/threat-analyzer-service/src/models/threat-model"
+	triageentity "github.com/yatender-pareek/threat-analyzer-service/src/models/triage-model"
+	"github.com/yatender-pareek/threat-analyzer-service/src/utility"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUnknownThreat = errors.New("one or more threats do not exist")
+	ErrMixedTenants  = errors.New("an incident only holds threats of one tenant")
+)
+
+// IncidentDetails is an incident together with the threats grouped into it
+type IncidentDetails struct {
+	incidententity.Incident
+	Threats []threatentity.Threat `json:"threats"`
+}
+
+type IncidentService struct {
+	tenant string
+}
+
+func NewIncidentService() *IncidentService {
+	return &IncidentService{}
+}
+
+// ForTenant returns a service limited to the incidents and threats of tenant; an empty tenant covers all tenants
+func (s *IncidentService) ForTenant(tenant string) *IncidentService {
+	return &IncidentService{tenant: tenant}
+}
+
+func (s *IncidentService) db() *gorm.DB {
+	return tenancy.Scoped(mysqlconfig.GetDB(), s.tenant)
+}
+
+func (s *IncidentService) GetAllIncidents() ([]incidententity.Incident, error) {
+	var incidents []incidententity.Incident
+	if err := s.db().Order("last_seen DESC").Find(&incidents).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve incidents: %v", err)
+	}
+	return incidents, nil
+}
+
+func (s *IncidentService) GetIncidentByID(id uint64) (IncidentDetails, error) {
+	db := s.db()
+	var details IncidentDetails
+	if err := db.First(&details.Incident, id).Error; err != nil {
+		return IncidentDetails{}, err
+	}
+	if err := db.Where("incident_id = ?", id).Order("timestamp, id").Find(&details.Threats).Error; err != nil {
+		return IncidentDetails{}, fmt.Errorf("failed to retrieve incident threats: %v", err)
+	}
+	return details, nil
+}
+
+// CreateIncident opens a manual incident and moves the given threats into it
+func (s *IncidentService) CreateIncident(title string, threatIDs []uint64) (IncidentDetails, error) {
+	var incidentID uint64
+	err := s.db().Transaction(func(tx *gorm.DB) error {
+		threats, err := loadThreats(tx, threatIDs)
+		if err != nil {
+			return err
+		}
+		incident := incidententity.Incident{
+			Title:      title,
+			RuleID:     threats[0].RuleID,
+			ThreatType: threats[0].ThreatType,
+			UserID:     threats[0].UserID,
+			Tenant:     threats[0].Tenant,
+			IPAddress:  threats[0].IPAddress,
+			FirstSeen:  threats[0].Timestamp,
+			LastSeen:   threats[0].Timestamp,
+		}
+		for _, threat := range threats[1:] {
+			if threat.Tenant != incident.Tenant {
+				return ErrMixedTenants
+			}
+			if threat.RuleID != incident.RuleID {
+				incident.RuleID = ""
+			}
+			if threat.ThreatType != incident.ThreatType {
+				incident.ThreatType = ""
+			}
+			if threat.UserID != incident.UserID {
+				incident.UserID = ""
+			}
+		}
+		if err := tx.Create(&incident).Error; err != nil {
+			return fmt.Errorf("failed to create incident: %v", err)
+		}
+		incidentID = incident.ID
+		return moveThreats(tx, threats, incident.ID)
+	})
+	if err != nil {
+		return IncidentDetails{}, err
+	}
+	return s.GetIncidentByID(incidentID)
+}
+
+// UpdateIncident renames an incident and/or moves more threats into it
+func (s *IncidentService) UpdateIncident(id uint64, title *string, threatIDs []uint64) (IncidentDetails, error) {
+	err := s.db().Transaction(func(tx *gorm.DB) error {
+		var incident incidententity.Incident
+		if err := tx.First(&incident, id).Error; err != nil {
+			return err
+		}
+		if title != nil {
+			if err := tx.Model(&incident).Update("title", *title).Error; err != nil {
+				return fmt.Errorf("failed to update incident: %v", err)
+			}
+		}
+		if len(threatIDs) == 0 {
+			return nil
+		}
+		threats, err := loadThreats(tx, threatIDs)
+		if err != nil {
+			return err
+		}
+		for _, threat := range threats {
+			if threat.Tenant != incident.Tenant {
+				return ErrMixedTenants
+			}
+		}
+		return moveThreats(tx, threats, id)
+	})
+	if err != nil {
+		return IncidentDetails{}, err
+	}
+	return s.GetIncidentByID(id)
+}
+
+// DeleteIncidentByID removes the incident and deletes its threats like DeleteThreatByID,
+// keeping their evidence, comments and history
+func (s *IncidentService) DeleteIncidentByID(id uint64, actor string) error {
+	return s.db().Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&incidententity.Incident{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		var threatIDs []uint64
+		if err := tx.Model(&threatentity.Threat{}).Where("incident_id = ?", id).Pluck("id", &threatIDs).Error; err != nil {
+			return err
+		}
+		if len(threatIDs) == 0 {
+			return nil
+		}
+		history := make([]triageentity.ThreatHistory, len(threatIDs))
+		for i, threatID := range threatIDs {
+			history[i] = triageentity.ThreatHistory{ThreatID: threatID, Field: "deleted", ChangedBy: actor}
+		}
+		if err := tx.Create(&history).Error; err != nil {
+			return err
+		}
+		return tx.Where("id IN ?", threatIDs).Delete(&threatentity.Threat{}).Error
+	})
+}
+
+func (s *IncidentService) SearchIncidents(ruleID, userID, tenant, severity, startTime, endTime string) ([]incidententity.Incident, error) {
+	query := s.db().Model(&incidententity.Incident{})
+	if tenant != "" {
+		query = query.Where("tenant = ?", tenant)
+	}
+	if ruleID != "" {
+		query = query.Where("rule_id = ?", ruleID)
+	}
+	if userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	if severity != "" {
+		query = query.Where("severity = ?", severity)
+	}
+	if startTime != "" {
+		query = query.Where("last_seen >= ?", startTime)
+	}
+	if endTime != "" {
+		query = query.Where("first_seen <= ?", endTime)
+	}
+
+	var incidents []incidententity.Incident
+	if err := query.Order("last_seen DESC").Find(&incidents).Error; err != nil {
+		return nil, err
+	}
+	return incidents, nil
+}
+
+func loadThreats(tx *gorm.DB, threatIDs []uint64) ([]threatentity.Threat, error) {
+	var threats []threatentity.Threat
+	if err := tx.Where("id IN ?", threatIDs).Order("timestamp, id").Find(&threats).Error; err != nil {
+		return nil, fmt.Errorf("failed to load threats: %v", err)
+	}
+	unique := make(map[uint64]bool)
+	for _, id := range threatIDs {
+		unique[id] = true
+	}
+	if len(threats) != len(unique) {
+		return nil, ErrUnknownThreat
+	}
+	return threats, nil
+}
+
+// moveThreats links threats to an incident and refreshes every incident involved
+func moveThreats(tx *gorm.DB, threats []threatentity.Threat, incidentID uint64) error {
+	affected := map[uint64]bool{incidentID: true}
+	ids := make([]uint64, len(threats))
+	for i, threat := range threats {
+		ids[i] = threat.ID
+		if threat.IncidentID != nil {
+			affected[*threat.IncidentID] = true
+		}
+	}
+	if err := tx.Model(&threatentity.Threat{}).Where("id IN ?", ids).Update("incident_id", incidentID).Error; err != nil {
+		return fmt.Errorf("failed to move threats: %v", err)
+	}
+	for id := range affected {
+		if err := utility.RefreshIncident(tx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	mysqlconfig "github.com/yatender-pareek/threat-analyzer-service/src/config/my-sql-config"
+	checkpointentity "github.com/yatender-pareek/threat-analyzer-service/src/models/checkpoint-model"
+	logDataentity "github.com/yatender-pareek/threat-analyzer-service/src/models/log-data-model"
+	ruleengine "github.com/yatender-pareek/threat-analyzer-service/src/rule-engine"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrUnknownRule = errors.New("unknown rule")
+
+func (s *ThreatService) GetCheckpoints() ([]checkpointentity.AnalysisCheckpoint, error) {
+	var checkpoints []checkpointentity.AnalysisCheckpoint
+	if err := mysqlconfig.GetDB().Order("rule_id").Find(&checkpoints).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve checkpoints: %v", err)
+	}
+	return checkpoints, nil
+}
+
+// ResetCheckpoints rewinds the checkpoints of one rule, or of every rule when ruleID is nil.
+// Without logID or timestamp the checkpoints are removed and the next run re-analyzes all history.
+func (s *ThreatService) ResetCheckpoints(ruleID *string, logID *uint64, timestamp *time.Time) ([]checkpointentity.AnalysisCheckpoint, error) {
+	rules, err := ruleengine.GetRules()
+	if err != nil {
+		return nil, err
+	}
+	var ruleIDs []string
+	for _, rule := range rules {
+		if ruleID == nil || rule.ID == *ruleID {
+			ruleIDs = append(ruleIDs, rule.ID)
+		}
+	}
+	if ruleID != nil && len(ruleIDs) == 0 {
+		return nil, ErrUnknownRule
+	}
+
+	db := mysqlconfig.GetDB()
+	if logID == nil && timestamp == nil {
+		query := db.Session(&gorm.Session{AllowGlobalUpdate: true})
+		if ruleID != nil {
+			query = query.Where("rule_id = ?", *ruleID)
+		}
+		if err := query.Delete(&checkpointentity.AnalysisCheckpoint{}).Error; err != nil {
+			return nil, fmt.Errorf("failed to reset checkpoints: %v", err)
+		}
+		return s.GetCheckpoints()
+	}
+
+	var lastLogID uint64
+	var lastTimestamp *time.Time
+	if logID != nil {
+		lastLogID = *logID
+		var entry logDataentity.LogData
+		if err := db.Select("timestamp").First(&entry, lastLogID).Error; err == nil {
+			lastTimestamp = &entry.Timestamp
+		}
+	} else {
+		// Everything stored from the first log at or after timestamp is analyzed again
+		var firstID *uint64
+		if err := db.Model(&logDataentity.LogData{}).Select("MIN(id)").
+			Where("timestamp >= ?", *timestamp).Scan(&firstID).Error; err != nil {
+			return nil, fmt.Errorf("failed to locate logs after %s: %v", timestamp.Format(time.RFC3339), err)
+		}
+		if firstID != nil {
+			lastLogID = *firstID - 1
+		} else if err := db.Model(&logDataentity.LogData{}).Select("COALESCE(MAX(id), 0)").Scan(&lastLogID).Error; err != nil {
+			return nil, fmt.Errorf("failed to read latest log id: %v", err)
+		}
+		lastTimestamp = timestamp
+	}
+
+	checkpoints := make([]checkpointentity.AnalysisCheckpoint, 0, len(ruleIDs))
+	for _, id := range ruleIDs {
+		checkpoints = append(checkpoints, checkpointentity.AnalysisCheckpoint{
+			RuleID:        id,
+			LastLogID:     lastLogID,
+			LastTimestamp: lastTimestamp,
+		})
+	}
+	if err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "rule_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"last_log_id", "last_timestamp", "updated_at"}),
+	}).Create(&checkpoints).Error; err != nil {
+		return nil, fmt.Errorf("failed to rewind checkpoints: %v", err)
+	}
+	return s.GetCheckpoints()
+}
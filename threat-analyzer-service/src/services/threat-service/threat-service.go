@@ -5,19 +5,34 @@ import (
 	"log"
 	"time"
 
+	"github.com/yatender-pareek/identity/src/tenancy"
 	mysqlconfig "github.com/yatender-pareek/threat-analyzer-service/src/config/my-sql-config"
+	logDataentity "github.com/yatender-pareek/threat-analyzer-service/src/models/log-data-model"
 	threatentity "github.com/yatender-pareek/threat-analyzer-service/src/models/threat-model"
+	triageentity "github.com/yatender-pareek/threat-analyzer-service/src/models/triage-model"
+	ruleengine "github.com/yatender-pareek/threat-analyzer-service/src/rule-engine"
 	"github.com/yatender-pareek/threat-analyzer-service/src/utility"
 	"gorm.io/gorm"
 )
 
 type ThreatService struct {
+	tenant string
 }
 
 func NewThreatService() *ThreatService {
 	return &ThreatService{}
 }
-func (s *ThreatService) AnalyzeThreats(start *time.Time, end *time.Time) (int, error) {
+
+// ForTenant returns a service limited to the threats, incidents and logs of tenant.
+// An empty tenant, as for platform admins and background analysis, covers all tenants.
+func (s *ThreatService) ForTenant(tenant string) *ThreatService {
+	return &ThreatService{tenant: tenant}
+}
+
+func (s *ThreatService) db() *gorm.DB {
+	return tenancy.Scoped(mysqlconfig.GetDB(), s.tenant)
+}
+func (s *ThreatService) AnalyzeThreats(start time.Time, end time.Time) (utility.AnalysisResult, error) {
 	db := mysqlconfig.GetDB()
 	if db == nil {
 		log.Fatal("DB connection is nil!")
@@ -30,45 +45,109 @@ func (s *ThreatService) AnalyzeThreats(start *time.Time, end *time.Time) (int, e
 		log.Fatalf("DB ping failed: %v", err)
 	}
 
-	affectedRows, err := utility.ProcessLogs(mysqlconfig.GeSqltDB(), mysqlconfig.GetDB())
+	rules, err := ruleengine.GetRules()
+	if err != nil {
+		return utility.AnalysisResult{}, err
+	}
+	return utility.ProcessLogs(s.db(), rules, start, end)
+}
 
-	return affectedRows, err
+// AnalyzeNewLogs analyzes only logs stored since each rule's last checkpoint
+func (s *ThreatService) AnalyzeNewLogs() (utility.AnalysisResult, error) {
+	rules, err := ruleengine.GetRules()
+	if err != nil {
+		return utility.AnalysisResult{}, err
+	}
+	return utility.ProcessNewLogs(s.db(), rules)
 }
 
 func (s *ThreatService) GetAllThreats() ([]threatentity.Threat, error) {
 	var threats []threatentity.Threat
-	if err := mysqlconfig.GetDB().Find(&threats).Error; err != nil {
+	if err := s.db().Find(&threats).Error; err != nil {
 		return nil, fmt.Errorf("failed to retrieve: %v", err)
 	}
 	return threats, nil
 }
 
-func (s *ThreatService) GetThreatByID(id uint64) (threatentity.Threat, error) {
+// GetThreatByID returns the threat with its evidence, comments and history in chronological
+// order, optionally with each evidence log row inlined
+func (s *ThreatService) GetThreatByID(id uint64, expandLogs bool) (threatentity.Threat, error) {
+	db := s.db()
 	var threat threatentity.Threat
-	if err := mysqlconfig.GetDB().First(&threat, id).Error; err != nil {
+	if err := db.First(&threat, id).Error; err != nil {
 		return threatentity.Threat{}, err
 	}
-	return threat, nil
-}
-func (s *ThreatService) DeleteThreatByID(id uint64) error {
-	result := mysqlconfig.GetDB().Where("id = ?", id).Delete(&threatentity.Threat{})
-	if result.Error != nil {
-		return result.Error
+
+	if err := db.Where("threat_id = ?", id).Order("log_timestamp, log_id").Find(&threat.Evidence).Error; err != nil {
+		return threatentity.Threat{}, fmt.Errorf("failed to retrieve evidence: %v", err)
+	}
+	if err := db.Where("threat_id = ?", id).Order("created_at, id").Find(&threat.Comments).Error; err != nil {
+		return threatentity.Threat{}, fmt.Errorf("failed to retrieve comments: %v", err)
 	}
-	if result.RowsAffected == 0 {
-		return gorm.ErrRecordNotFound
+	if err := db.Where("threat_id = ?", id).Order("created_at, id").Find(&threat.History).Error; err != nil {
+		return threatentity.Threat{}, fmt.Errorf("failed to retrieve history: %v", err)
 	}
-	return nil
+	if !expandLogs || len(threat.Evidence) == 0 {
+		return threat, nil
+	}
+
+	logIDs := make([]uint64, len(threat.Evidence))
+	for i, evidence := range threat.Evidence {
+		logIDs[i] = evidence.LogID
+	}
+	var logs []logDataentity.LogData
+	if err := db.Where("id IN ?", logIDs).Find(&logs).Error; err != nil {
+		return threatentity.Threat{}, fmt.Errorf("failed to retrieve evidence logs: %v", err)
+	}
+	byID := make(map[uint64]*logDataentity.LogData, len(logs))
+	for i := range logs {
+		byID[logs[i].ID] = &logs[i]
+	}
+	for i := range threat.Evidence {
+		threat.Evidence[i].Log = byID[threat.Evidence[i].LogID]
+	}
+	return threat, nil
 }
 
-func (s *ThreatService) SearchThreats(threatType, userID, startTime, endTime string) ([]threatentity.Threat, error) {
-	query := mysqlconfig.GetDB().Model(&threatentity.Threat{})
+func (s *ThreatService) DeleteThreatByID(id uint64, actor string) error {
+	return s.db().Transaction(func(tx *gorm.DB) error {
+		var threat threatentity.Threat
+		if err := tx.Select("id", "incident_id").First(&threat, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&threatentity.Threat{}, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&triageentity.ThreatHistory{ThreatID: id, Field: "deleted", ChangedBy: actor}).Error; err != nil {
+			return err
+		}
+		if threat.IncidentID != nil {
+			return utility.RefreshIncident(tx, *threat.IncidentID)
+		}
+		return nil
+	})
+}
+
+func (s *ThreatService) SearchThreats(threatType, userID, source, tenant, status, assigneeID, startTime, endTime string) ([]threatentity.Threat, error) {
+	query := s.db().Model(&threatentity.Threat{})
+	if source != "" {
+		query = query.Where("source = ?", source)
+	}
+	if tenant != "" {
+		query = query.Where("tenant = ?", tenant)
+	}
 	if threatType != "" {
 		query = query.Where("threat_type = ?", threatType)
 	}
 	if userID != "" {
 		query = query.Where("user_id = ?", userID)
 	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if assigneeID != "" {
+		query = query.Where("assignee_id = ?", assigneeID)
+	}
 	if startTime != "" {
 		query = query.Where("timestamp >= ?", startTime)
 	}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	userentity "github.com/yatender-pareek/identity/src/models/user-model"
+	threatanalyzerresquest "github.com/yatender-pareek/threat-analyzer-service/src/dto/threat-analyzer-resquest"
+	threatentity "github.com/yatender-pareek/threat-analyzer-service/src/models/threat-model"
+	triageentity "github.com/yatender-pareek/threat-analyzer-service/src/models/triage-model"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUnknownAssignee    = errors.New("assignee does not exist")
+	ErrResolutionRequired = errors.New("a resolution is required to resolve a threat or mark it as a false positive")
+)
+
+// UpdateThreat applies a triage change made by actor and records every changed field in the threat's history
+func (s *ThreatService) UpdateThreat(id uint64, req threatanalyzerresquest.UpdateThreatRequest, actor string) (threatentity.Threat, error) {
+	err := s.db().Transaction(func(tx *gorm.DB) error {
+		var threat threatentity.Threat
+		if err := tx.First(&threat, id).Error; err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{}
+		var history []triageentity.ThreatHistory
+		record := func(field string, oldValue, newValue *string) {
+			history = append(history, triageentity.ThreatHistory{
+				ThreatID:  id,
+				Field:     field,
+				OldValue:  oldValue,
+				NewValue:  newValue,
+				ChangedBy: actor,
+			})
+		}
+
+		status := threat.Status
+		if req.Status != nil && *req.Status != threat.Status {
+			status = *req.Status
+			updates["status"] = status
+			record("status", &threat.Status, req.Status)
+		}
+
+		resolution := threat.Resolution
+		if req.Resolution != nil {
+			trimmed := strings.TrimSpace(*req.Resolution)
+			resolution = &trimmed
+			if trimmed == "" {
+				resolution = nil
+			}
+		}
+		if threatentity.IsClosedStatus(status) {
+			if resolution == nil {
+				return ErrResolutionRequired
+			}
+			if !threatentity.IsClosedStatus(threat.Status) {
+				updates["resolved_at"] = time.Now()
+			}
+		} else {
+			// Only closed threats carry a resolution; reopening one discards it
+			resolution = nil
+			if threat.ResolvedAt != nil {
+				updates["resolved_at"] = nil
+			}
+		}
+		if !equalStrings(resolution, threat.Resolution) {
+			updates["resolution"] = resolution
+			record("resolution", threat.Resolution, resolution)
+		}
+
+		if req.AssigneeID != nil {
+			var assignee *uint
+			if *req.AssigneeID != 0 {
+				// Only members of the threat's tenant can be assigned
+				if err := tx.Select("id").Where("tenant = ?", threat.Tenant).First(&userentity.User{}, *req.AssigneeID).Error; err != nil {
+					if err == gorm.ErrRecordNotFound {
+						return ErrUnknownAssignee
+					}
+					return fmt.Errorf("failed to look up assignee: %v", err)
+				}
+				assignee = req.AssigneeID
+			}
+			if !equalIDs(assignee, threat.AssigneeID) {
+				updates["assignee_id"] = assignee
+				record("assignee", idString(threat.AssigneeID), idString(assignee))
+			}
+		}
+
+		if len(updates) > 0 {
+			if err := tx.Model(&threat).Updates(updates).Error; err != nil {
+				return fmt.Errorf("failed to update threat: %v", err)
+			}
+		}
+		if len(history) > 0 {
+			if err := tx.Create(&history).Error; err != nil {
+				return fmt.Errorf("failed to record threat history: %v", err)
+			}
+		}
+
+		if req.Comment != nil && strings.TrimSpace(*req.Comment) != "" {
+			comment := triageentity.ThreatComment{ThreatID: id, Author: actor, Body: strings.TrimSpace(*req.Comment)}
+			if err := tx.Create(&comment).Error; err != nil {
+				return fmt.Errorf("failed to add comment: %v", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return threatentity.Threat{}, err
+	}
+	return s.GetThreatByID(id, false)
+}
+
+func equalStrings(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func equalIDs(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func idString(id *uint) *string {
+	if id == nil {
+		return nil
+	}
+	s := strconv.FormatUint(uint64(*id), 10)
+	return &s
+}
@@ -0,0 +1,244 @@
+// Package streamdetector evaluates detection rules over sliding windows as logs
+// are ingested, so threats are stored within seconds instead of on the next analysis run
+package streamdetector
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	eventbus "github.com/yatender-pareek/threat-analyzer-service/src/event-bus"
+	logDataentity "github.com/yatender-pareek/threat-analyzer-service/src/models/log-data-model"
+	outboxentity "github.com/yatender-pareek/threat-analyzer-service/src/models/outbox-model"
+	ruleengine "github.com/yatender-pareek/threat-analyzer-service/src/rule-engine"
+	"github.com/yatender-pareek/threat-analyzer-service/src/utility"
+	"gorm.io/gorm"
+)
+
+// ConsumerName identifies the detector's offset in outbox_consumer_offsets
+const ConsumerName = "stream-detector"
+
+// Config bounds the detector's memory and controls how quickly it picks up new logs
+type Config struct {
+	PollInterval    time.Duration
+	GapTimeout      time.Duration
+	BatchSize       int
+	MaxEventsPerKey int
+	MaxKeysPerRule  int
+	RecoveryLimit   int
+}
+
+// Detector keeps per-rule windows of recent events keyed by each rule's group_by fields
+type Detector struct {
+	db     *gorm.DB
+	config Config
+	states map[string]*ruleState
+}
+
+func NewDetector(db *gorm.DB, config Config) *Detector {
+	return &Detector{db: db, config: config, states: make(map[string]*ruleState)}
+}
+
+// Start recovers the windows from recent log_data and consumes "log ingested" events
+// from the outbox in the background. STREAM_DETECTION_ENABLED=false turns streaming detection off.
+func Start(db *gorm.DB) error {
+	if os.Getenv("STREAM_DETECTION_ENABLED") == "false" {
+		log.Println("Streaming detection disabled")
+		return nil
+	}
+	config, err := ConfigFromEnv()
+	if err != nil {
+		return err
+	}
+	detector := NewDetector(db, config)
+	if err := detector.Recover(); err != nil {
+		return err
+	}
+	consumer := eventbus.NewConsumer(db, ConsumerName, []string{outboxentity.EventLogIngested}, detector.HandleEvents)
+	consumer.BatchSize = config.BatchSize
+	consumer.PollInterval = config.PollInterval
+	consumer.GapTimeout = config.GapTimeout
+	consumer.Start()
+	return nil
+}
+
+// ConfigFromEnv reads the STREAM_* settings, falling back to defaults for unset ones
+func ConfigFromEnv() (Config, error) {
+	config := Config{
+		PollInterval:    time.Second,
+		GapTimeout:      30 * time.Second,
+		BatchSize:       1000,
+		MaxEventsPerKey: 1000,
+		MaxKeysPerRule:  50000,
+		RecoveryLimit:   100000,
+	}
+	for name, target := range map[string]*time.Duration{
+		"STREAM_POLL_INTERVAL": &config.PollInterval,
+		"STREAM_GAP_TIMEOUT":   &config.GapTimeout,
+	} {
+		raw := os.Getenv(name)
+		if raw == "" {
+			continue
+		}
+		value, err := time.ParseDuration(raw)
+		if err != nil || value <= 0 {
+			return Config{}, fmt.Errorf("invalid %s %q", name, raw)
+		}
+		*target = value
+	}
+	for name, target := range map[string]*int{
+		"STREAM_BATCH_SIZE":         &config.BatchSize,
+		"STREAM_MAX_EVENTS_PER_KEY": &config.MaxEventsPerKey,
+		"STREAM_MAX_KEYS_PER_RULE":  &config.MaxKeysPerRule,
+		"STREAM_RECOVERY_LIMIT":     &config.RecoveryLimit,
+	} {
+		raw := os.Getenv(name)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.Atoi(raw)
+		if err != nil || value <= 0 {
+			return Config{}, fmt.Errorf("invalid %s %q", name, raw)
+		}
+		*target = value
+	}
+	return config, nil
+}
+
+// Recover rebuilds the windows from the logs stored within the longest rule lookback
+// and re-evaluates them, so detections missed while the service was down are stored.
+// Threats are keyed by fingerprint, so re-detecting known ones is harmless.
+func (d *Detector) Recover() error {
+	rules, err := ruleengine.GetRules()
+	if err != nil {
+		return err
+	}
+	var retention time.Duration
+	for _, rule := range rules {
+		if rule.IsEnabled() && rule.RequiredLookback() > retention {
+			retention = rule.RequiredLookback()
+		}
+	}
+	if retention == 0 {
+		return nil
+	}
+
+	var logs []logDataentity.LogData
+	if err := d.db.Where("timestamp >= ?", time.Now().Add(-retention)).
+		Order("id DESC").Limit(d.config.RecoveryLimit).Find(&logs).Error; err != nil {
+		return fmt.Errorf("failed to load recent logs: %w", err)
+	}
+	for i, j := 0, len(logs)-1; i < j; i, j = i+1, j-1 {
+		logs[i], logs[j] = logs[j], logs[i]
+	}
+	result, err := d.Process(logs)
+	if err != nil {
+		return err
+	}
+	log.Printf("Streaming detection recovered %d recent logs, %d new threats", len(logs), result.NewThreats)
+	return nil
+}
+
+// HandleEvents decodes the logs carried by "log ingested" events and processes them.
+// Events whose payload can never be decoded are skipped instead of blocking the consumer.
+func (d *Detector) HandleEvents(events []outboxentity.OutboxEvent) error {
+	logs := make([]logDataentity.LogData, 0, len(events))
+	for _, event := range events {
+		var entry logDataentity.LogData
+		if err := json.Unmarshal([]byte(event.Payload), &entry); err != nil {
+			log.Printf("Skipping outbox event %d with malformed payload: %v", event.ID, err)
+			continue
+		}
+		logs = append(logs, entry)
+	}
+	_, err := d.Process(logs)
+	return err
+}
+
+// Process adds logs to the windows of every enabled rule, evaluates the windows
+// they touched and stores the detections that involve at least one of the logs
+func (d *Detector) Process(logs []logDataentity.LogData) (utility.AnalysisResult, error) {
+	if len(logs) == 0 {
+		return utility.AnalysisResult{}, nil
+	}
+	rules, err := ruleengine.GetRules()
+	if err != nil {
+		return utility.AnalysisResult{}, err
+	}
+	return utility.StoreDetections(d.db, d.detect(rules, logs))
+}
+
+func (d *Detector) detect(rules []ruleengine.Rule, logs []logDataentity.LogData) []utility.RuleDetection {
+	incoming := make(map[uint64]bool, len(logs))
+	for _, entry := range logs {
+		incoming[entry.ID] = true
+	}
+
+	active := make(map[string]bool, len(rules))
+	var detections []utility.RuleDetection
+	for i := range rules {
+		rule := &rules[i]
+		if !rule.IsEnabled() {
+			continue
+		}
+		active[rule.ID] = true
+		state, ok := d.states[rule.ID]
+		if !ok {
+			state = newRuleState()
+			d.states[rule.ID] = state
+		}
+
+		touched := make(map[string]*keyState)
+		for _, entry := range logs {
+			key, ok := rule.GroupKey(&entry)
+			if !ok {
+				continue
+			}
+			window, exists := state.keys[key]
+			if !exists {
+				window = &keyState{}
+				state.keys[key] = window
+			}
+			// Logs retried after a failed store are already in the window but are evaluated again
+			window.add(entry, d.config.MaxEventsPerKey)
+			touched[key] = window
+			if entry.Timestamp.After(state.watermark) {
+				state.watermark = entry.Timestamp
+			}
+		}
+
+		retention := rule.RequiredLookback()
+		for _, window := range touched {
+			for _, detection := range ruleengine.Evaluate(rule, window.events) {
+				if involves(detection, incoming) {
+					detections = append(detections, utility.RuleDetection{Rule: rule, Detection: detection})
+				}
+			}
+			window.trim(retention)
+		}
+		state.evict(retention, d.config.MaxKeysPerRule)
+	}
+
+	// State of rules that were removed or disabled is dropped
+	for id := range d.states {
+		if !active[id] {
+			delete(d.states, id)
+		}
+	}
+	return detections
+}
+
+func involves(detection ruleengine.Detection, ids map[uint64]bool) bool {
+	if ids[detection.Log.ID] {
+		return true
+	}
+	for _, entry := range detection.Evidence {
+		if ids[entry.ID] {
+			return true
+		}
+	}
+	return false
+}
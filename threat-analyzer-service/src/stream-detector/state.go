@@ -0,0 +1,80 @@
+package streamdetector
+
+import (
+	"sort"
+	"time"
+
+	logDataentity "github.com/yatender-pareek/threat-analyzer-service/src/models/log-data-model"
+)
+
+// keyState is the sliding window of recent events for one group_by value of a rule
+type keyState struct {
+	events []logDataentity.LogData
+	latest time.Time
+}
+
+// ruleState holds the windows of every group of one rule. The watermark is the
+// newest event time seen by the rule and drives eviction of idle groups.
+type ruleState struct {
+	keys      map[string]*keyState
+	watermark time.Time
+}
+
+func newRuleState() *ruleState {
+	return &ruleState{keys: make(map[string]*keyState)}
+}
+
+// add inserts an event in time order, ignoring events the window already holds,
+// and drops the oldest events once the window exceeds maxEvents
+func (k *keyState) add(entry logDataentity.LogData, maxEvents int) {
+	i := sort.Search(len(k.events), func(i int) bool {
+		return !k.events[i].Timestamp.Before(entry.Timestamp)
+	})
+	for j := i; j < len(k.events) && k.events[j].Timestamp.Equal(entry.Timestamp); j++ {
+		if k.events[j].ID == entry.ID {
+			return
+		}
+	}
+	k.events = append(k.events, logDataentity.LogData{})
+	copy(k.events[i+1:], k.events[i:])
+	k.events[i] = entry
+	if len(k.events) > maxEvents {
+		k.events = k.events[len(k.events)-maxEvents:]
+	}
+	if entry.Timestamp.After(k.latest) {
+		k.latest = entry.Timestamp
+	}
+}
+
+// trim drops events that can no longer take part in a detection with newer events
+func (k *keyState) trim(retention time.Duration) {
+	cutoff := k.latest.Add(-retention)
+	i := sort.Search(len(k.events), func(i int) bool {
+		return !k.events[i].Timestamp.Before(cutoff)
+	})
+	if i > 0 {
+		k.events = append([]logDataentity.LogData(nil), k.events[i:]...)
+	}
+}
+
+// evict removes groups that have been idle longer than the retention and, if the
+// rule still tracks more than maxKeys groups, the least recently active ones
+func (r *ruleState) evict(retention time.Duration, maxKeys int) {
+	cutoff := r.watermark.Add(-retention)
+	for key, state := range r.keys {
+		if state.latest.Before(cutoff) {
+			delete(r.keys, key)
+		}
+	}
+	if len(r.keys) <= maxKeys {
+		return
+	}
+	keys := make([]string, 0, len(r.keys))
+	for key := range r.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return r.keys[keys[i]].latest.Before(r.keys[keys[j]].latest) })
+	for _, key := range keys[:len(keys)-maxKeys] {
+		delete(r.keys, key)
+	}
+}
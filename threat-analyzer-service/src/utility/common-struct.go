@@ -1,7 +1,21 @@
 package utility
 
+import ruleengine "github.com/yatender-pareek/threat-analyzer-service/src/rule-engine"
+
 type CommonConfigStruct struct {
 	SensitiveFilePrefixes []string
 	BusinessHourStart     int
 	BusinessHourEnd       int
 }
+
+// AnalysisResult separates threats first seen in a run from ones already stored
+type AnalysisResult struct {
+	NewThreats   int
+	KnownThreats int
+}
+
+// RuleDetection is a detection together with the rule that produced it
+type RuleDetection struct {
+	Rule      *ruleengine.Rule
+	Detection ruleengine.Detection
+}
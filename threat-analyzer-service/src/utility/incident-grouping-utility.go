@@ -0,0 +1,279 @@
+package utility
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	incidententity "github.com/yatender-pareek/threat-analyzer-service/src/models/incident-model"
+	threatentity "github.com/yatender-pareek/threat-analyzer-service/src/models/threat-model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var severityRank = map[string]int{"low": 1, "medium": 2, "high": 3, "critical": 4}
+
+// HigherSeverity returns the more severe of two severity labels
+func HigherSeverity(a, b string) string {
+	if severityRank[strings.ToLower(b)] > severityRank[strings.ToLower(a)] {
+		return b
+	}
+	return a
+}
+
+// IncidentGroupingWindow is the largest gap between threats that still belong to one incident
+func IncidentGroupingWindow() time.Duration {
+	if raw := os.Getenv("INCIDENT_GROUPING_WINDOW"); raw != "" {
+		if window, err := time.ParseDuration(raw); err == nil {
+			return window
+		}
+		log.Printf("Invalid INCIDENT_GROUPING_WINDOW %q, using 30m", raw)
+	}
+	return 30 * time.Minute
+}
+
+// groupKey partitions incidents: threats of different rules or tenants never share one
+type groupKey struct {
+	rule   string
+	tenant string
+}
+
+// ruleKey falls back to the threat type for threats stored before rules had IDs
+func ruleKey(ruleID, threatType string) string {
+	if ruleID != "" {
+		return ruleID
+	}
+	return threatType
+}
+
+// incidentGroup is an incident with the users and IP addresses of its threats
+type incidentGroup struct {
+	incident *incidententity.Incident
+	users    map[string]bool
+	ips      map[string]bool
+	threats  []uint64
+	// mergedInto is set once the group has been folded into another one
+	mergedInto *incidentGroup
+}
+
+func newIncidentGroup(incident *incidententity.Incident) *incidentGroup {
+	return &incidentGroup{incident: incident, users: make(map[string]bool), ips: make(map[string]bool)}
+}
+
+func (g *incidentGroup) root() *incidentGroup {
+	for g.mergedInto != nil {
+		g = g.mergedInto
+	}
+	return g
+}
+
+func (g *incidentGroup) addMember(userID, ip string) {
+	if userID != "" {
+		g.users[userID] = true
+	}
+	if ip != "" {
+		g.ips[ip] = true
+	}
+}
+
+// matches reports whether a threat of user and ip at ts belongs to the group
+func (g *incidentGroup) matches(userID, ip string, ts time.Time, window time.Duration) bool {
+	if ts.Before(g.incident.FirstSeen.Add(-window)) || ts.After(g.incident.LastSeen.Add(window)) {
+		return false
+	}
+	return (userID != "" && g.users[userID]) || (ip != "" && g.ips[ip])
+}
+
+// absorb folds other into g
+func (g *incidentGroup) absorb(other *incidentGroup) {
+	if other.incident.FirstSeen.Before(g.incident.FirstSeen) {
+		g.incident.FirstSeen = other.incident.FirstSeen
+	}
+	if other.incident.LastSeen.After(g.incident.LastSeen) {
+		g.incident.LastSeen = other.incident.LastSeen
+	}
+	g.incident.Severity = HigherSeverity(g.incident.Severity, other.incident.Severity)
+	g.incident.ThreatCount += other.incident.ThreatCount
+	for userID := range other.users {
+		g.users[userID] = true
+	}
+	for ip := range other.ips {
+		g.ips[ip] = true
+	}
+	g.threats = append(g.threats, other.threats...)
+	other.threats = nil
+	other.mergedInto = g
+}
+
+// AssignIncidents attaches every threat without an incident to an incident of the same rule
+// and tenant that has a threat of the same user or IP address within the grouping window,
+// opening new ones as needed. A threat linking several incidents merges them into the oldest.
+//
+// Threats are stored by the streaming detector and by analysis runs concurrently, so the
+// ungrouped threats and candidate incidents are read with FOR UPDATE: a second run waits for
+// the first to commit and then sees the incidents it opened.
+func AssignIncidents(tx *gorm.DB) error {
+	var threats []threatentity.Threat
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("incident_id IS NULL").
+		Order("timestamp, id").Find(&threats).Error; err != nil {
+		return fmt.Errorf("failed to load ungrouped threats: %w", err)
+	}
+	if len(threats) == 0 {
+		return nil
+	}
+
+	window := IncidentGroupingWindow()
+	tenantSet := make(map[string]bool)
+	var tenants []string
+	for _, threat := range threats {
+		if !tenantSet[threat.Tenant] {
+			tenantSet[threat.Tenant] = true
+			tenants = append(tenants, threat.Tenant)
+		}
+	}
+	var existing []incidententity.Incident
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("tenant IN ? AND last_seen >= ?", tenants, threats[0].Timestamp.Add(-window)).
+		Order("id").Find(&existing).Error; err != nil {
+		return fmt.Errorf("failed to load recent incidents: %w", err)
+	}
+
+	candidates := make(map[groupKey][]*incidentGroup)
+	byID := make(map[uint64]*incidentGroup, len(existing))
+	for i := range existing {
+		group := newIncidentGroup(&existing[i])
+		byID[existing[i].ID] = group
+		key := groupKey{rule: ruleKey(existing[i].RuleID, existing[i].ThreatType), tenant: existing[i].Tenant}
+		candidates[key] = append(candidates[key], group)
+	}
+	if len(byID) > 0 {
+		ids := make([]uint64, 0, len(byID))
+		for id := range byID {
+			ids = append(ids, id)
+		}
+		var members []struct {
+			IncidentID uint64
+			UserID     string
+			IPAddress  string
+		}
+		if err := tx.Model(&threatentity.Threat{}).Distinct("incident_id", "user_id", "ip_address").
+			Where("incident_id IN ?", ids).Scan(&members).Error; err != nil {
+			return fmt.Errorf("failed to load members of recent incidents: %w", err)
+		}
+		for _, member := range members {
+			byID[member.IncidentID].addMember(member.UserID, member.IPAddress)
+		}
+	}
+
+	var touched []*incidentGroup
+	for _, threat := range threats {
+		key := groupKey{rule: ruleKey(threat.RuleID, threat.ThreatType), tenant: threat.Tenant}
+		var target *incidentGroup
+		kept := candidates[key][:0]
+		for _, group := range candidates[key] {
+			switch {
+			case !group.matches(threat.UserID, threat.IPAddress, threat.Timestamp, window):
+				kept = append(kept, group)
+			case target == nil:
+				target = group
+				kept = append(kept, group)
+			default:
+				target.absorb(group)
+			}
+		}
+		candidates[key] = kept
+		if target == nil {
+			target = newIncidentGroup(&incidententity.Incident{
+				Title:      fmt.Sprintf("%s on %s", threat.ThreatType, threat.UserID),
+				RuleID:     threat.RuleID,
+				ThreatType: threat.ThreatType,
+				UserID:     threat.UserID,
+				Tenant:     threat.Tenant,
+				IPAddress:  threat.IPAddress,
+				Severity:   threat.Severity,
+				FirstSeen:  threat.Timestamp,
+				LastSeen:   threat.Timestamp,
+			})
+			candidates[key] = append(candidates[key], target)
+		}
+
+		incident := target.incident
+		if threat.Timestamp.Before(incident.FirstSeen) {
+			incident.FirstSeen = threat.Timestamp
+		}
+		if threat.Timestamp.After(incident.LastSeen) {
+			incident.LastSeen = threat.Timestamp
+		}
+		incident.Severity = HigherSeverity(incident.Severity, threat.Severity)
+		incident.ThreatCount++
+		target.addMember(threat.UserID, threat.IPAddress)
+		target.threats = append(target.threats, threat.ID)
+		touched = append(touched, target)
+	}
+
+	saved := make(map[*incidentGroup]bool)
+	for _, group := range touched {
+		root := group.root()
+		if saved[root] {
+			continue
+		}
+		saved[root] = true
+		if err := tx.Save(root.incident).Error; err != nil {
+			return fmt.Errorf("failed to save incident: %w", err)
+		}
+		if len(root.threats) == 0 {
+			continue
+		}
+		if err := tx.Model(&threatentity.Threat{}).Where("id IN ?", root.threats).
+			Update("incident_id", root.incident.ID).Error; err != nil {
+			return fmt.Errorf("failed to link threats to incident %d: %w", root.incident.ID, err)
+		}
+	}
+	// Merged incidents hand their threats, deleted ones included, to the incident they were folded into
+	for _, incident := range existing {
+		group := byID[incident.ID]
+		if group.mergedInto == nil {
+			continue
+		}
+		root := group.root()
+		if err := tx.Unscoped().Model(&threatentity.Threat{}).Where("incident_id = ?", incident.ID).
+			Update("incident_id", root.incident.ID).Error; err != nil {
+			return fmt.Errorf("failed to merge incident %d into %d: %w", incident.ID, root.incident.ID, err)
+		}
+		if err := tx.Delete(&incidententity.Incident{}, incident.ID).Error; err != nil {
+			return fmt.Errorf("failed to remove merged incident %d: %w", incident.ID, err)
+		}
+	}
+	return nil
+}
+
+// RefreshIncident recomputes an incident's counters from its threats and removes it once empty
+func RefreshIncident(tx *gorm.DB, incidentID uint64) error {
+	var threats []threatentity.Threat
+	if err := tx.Select("timestamp", "severity").Where("incident_id = ?", incidentID).Find(&threats).Error; err != nil {
+		return fmt.Errorf("failed to load threats of incident %d: %w", incidentID, err)
+	}
+	if len(threats) == 0 {
+		return tx.Delete(&incidententity.Incident{}, incidentID).Error
+	}
+
+	firstSeen, lastSeen, severity := threats[0].Timestamp, threats[0].Timestamp, threats[0].Severity
+	for _, threat := range threats[1:] {
+		if threat.Timestamp.Before(firstSeen) {
+			firstSeen = threat.Timestamp
+		}
+		if threat.Timestamp.After(lastSeen) {
+			lastSeen = threat.Timestamp
+		}
+		severity = HigherSeverity(severity, threat.Severity)
+	}
+	updates := map[string]interface{}{
+		"threat_count": len(threats),
+		"first_seen":   firstSeen,
+		"last_seen":    lastSeen,
+		"severity":     severity,
+	}
+	return tx.Model(&incidententity.Incident{}).Where("id = ?", incidentID).Updates(updates).Error
+}
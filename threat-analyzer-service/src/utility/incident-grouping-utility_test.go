@@ -0,0 +1,65 @@
+package utility
+
+import (
+	"testing"
+	"time"
+
+	incidententity "github.com/yatender-pareek/threat-analyzer-service/src/models/incident-model"
+)
+
+func TestIncidentGroupMatches(t *testing.T) {
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	group := newIncidentGroup(&incidententity.Incident{FirstSeen: base, LastSeen: base.Add(10 * time.Minute)})
+	group.addMember("alice", "10.0.0.1")
+
+	tests := []struct {
+		name string
+		user string
+		ip   string
+		ts   time.Time
+		want bool
+	}{
+		{"same user", "alice", "10.9.9.9", base.Add(5 * time.Minute), true},
+		{"same ip", "bob", "10.0.0.1", base.Add(5 * time.Minute), true},
+		{"neither", "bob", "10.9.9.9", base.Add(5 * time.Minute), false},
+		{"empty values never link", "", "", base.Add(5 * time.Minute), false},
+		{"within window after", "alice", "", base.Add(40 * time.Minute), true},
+		{"beyond window after", "alice", "", base.Add(41 * time.Minute), false},
+		{"beyond window before", "alice", "", base.Add(-31 * time.Minute), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := group.matches(tt.user, tt.ip, tt.ts, 30*time.Minute); got != tt.want {
+				t.Fatalf("matches(%q, %q) = %v, want %v", tt.user, tt.ip, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIncidentGroupAbsorb(t *testing.T) {
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	first := newIncidentGroup(&incidententity.Incident{ID: 1, FirstSeen: base, LastSeen: base, Severity: "low", ThreatCount: 2})
+	first.addMember("alice", "10.0.0.1")
+	first.threats = []uint64{10}
+	second := newIncidentGroup(&incidententity.Incident{ID: 2, FirstSeen: base.Add(-time.Minute), LastSeen: base.Add(time.Hour), Severity: "high", ThreatCount: 3})
+	second.addMember("bob", "10.0.0.2")
+	second.threats = []uint64{11, 12}
+	third := newIncidentGroup(&incidententity.Incident{FirstSeen: base, LastSeen: base})
+
+	first.absorb(second)
+	third.absorb(first)
+
+	if second.root() != third || first.root() != third {
+		t.Fatal("merged groups should resolve to the group that absorbed them")
+	}
+	incident := third.incident
+	if !incident.FirstSeen.Equal(base.Add(-time.Minute)) || !incident.LastSeen.Equal(base.Add(time.Hour)) {
+		t.Fatalf("unexpected span %s - %s", incident.FirstSeen, incident.LastSeen)
+	}
+	if incident.Severity != "high" || incident.ThreatCount != 5 {
+		t.Fatalf("unexpected severity %q and count %d", incident.Severity, incident.ThreatCount)
+	}
+	if !third.users["alice"] || !third.users["bob"] || !third.ips["10.0.0.2"] || len(third.threats) != 3 {
+		t.Fatalf("members or threats were not carried over: %v %v %v", third.users, third.ips, third.threats)
+	}
+}
@@ -1,249 +1,272 @@
 package utility
 
 import (
-	"database/sql"
 	"fmt"
-	"strings"
+	"log"
+	"os"
+	"sort"
 	"time"
 
+	"github.com/yatender-pareek/identity/src/tenancy"
+	checkpointentity "github.com/yatender-pareek/threat-analyzer-service/src/models/checkpoint-model"
+	evidenceentity "github.com/yatender-pareek/threat-analyzer-service/src/models/evidence-model"
+	logDataentity "github.com/yatender-pareek/threat-analyzer-service/src/models/log-data-model"
 	threatentity "github.com/yatender-pareek/threat-analyzer-service/src/models/threat-model"
+	ruleengine "github.com/yatender-pareek/threat-analyzer-service/src/rule-engine"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-func ProcessLogs(sqlDB *sql.DB, gormDB *gorm.DB) (int, error) {
-	threats := []threatentity.Threat{}
-	scanThreat := func(rows *sql.Rows, threatType, severity string) ([]threatentity.Threat, error) {
-		var result []threatentity.Threat
-		for rows.Next() {
-			var (
-				timestamp     time.Time
-				userID        string
-				ipAddress     string
-				action        string
-				fileName      *string
-				databaseQuery *string
-				threatType    string
-			)
-			if err := rows.Scan(&timestamp, &userID, &ipAddress, &action, &fileName, &databaseQuery, &threatType); err != nil {
-				return nil, fmt.Errorf("failed to scan row: %w", err)
-			}
-			threat := threatentity.Threat{
-				Timestamp:     timestamp,
-				UserID:        userID,
-				IPAddress:     ipAddress,
-				Action:        action,
-				FileName:      fileName,
-				ThreatType:    threatType,
-				Severity:      severity,
-				DatabaseQuery: databaseQuery,
+// ruleWindow is the slice of time a rule flags threats in during one run
+type ruleWindow struct {
+	rule  *ruleengine.Rule
+	start time.Time
+	end   time.Time
+}
+
+// ProcessLogs evaluates the rules against logs in [start, end]. Each rule also sees
+// the history it needs before start, but only logs inside the window become threats.
+// Threats are keyed by fingerprint, so re-running an analysis never duplicates them.
+func ProcessLogs(gormDB *gorm.DB, rules []ruleengine.Rule, start, end time.Time) (AnalysisResult, error) {
+	var windows []ruleWindow
+	for i := range rules {
+		if rules[i].IsEnabled() {
+			windows = append(windows, ruleWindow{rule: &rules[i], start: start, end: end})
+		}
+	}
+	return analyze(gormDB, windows, nil)
+}
+
+// ProcessNewLogs analyzes, per rule, the logs stored since that rule's checkpoint and
+// advances the checkpoints in the same transaction that stores the threats.
+// Checkpoints are shared by all tenants, so a run limited to one tenant leaves them in place.
+//
+// Log IDs are assigned before the inserting transaction commits, so logs stored within the
+// last ANALYSIS_COMMIT_LAG are left to the next run: a lower ID committing late would
+// otherwise fall behind a checkpoint. A rule analyzes at most ANALYSIS_MAX_WINDOW of new
+// logs per run, ending at the newest; older ones are left out and logged.
+func ProcessNewLogs(gormDB *gorm.DB, rules []ruleengine.Rule) (AnalysisResult, error) {
+	commitLag := durationEnv("ANALYSIS_COMMIT_LAG", time.Minute)
+	maxWindow := durationEnv("ANALYSIS_MAX_WINDOW", 24*time.Hour)
+
+	var maxID uint64
+	if err := gormDB.Model(&logDataentity.LogData{}).Select("COALESCE(MAX(id), 0)").
+		Where("created_at <= ?", time.Now().Add(-commitLag)).Scan(&maxID).Error; err != nil {
+		return AnalysisResult{}, fmt.Errorf("failed to read latest log id: %w", err)
+	}
+
+	var stored []checkpointentity.AnalysisCheckpoint
+	if err := gormDB.Find(&stored).Error; err != nil {
+		return AnalysisResult{}, fmt.Errorf("failed to load checkpoints: %w", err)
+	}
+	lastIDs := make(map[string]uint64, len(stored))
+	for _, checkpoint := range stored {
+		lastIDs[checkpoint.RuleID] = checkpoint.LastLogID
+	}
+
+	type span struct {
+		FirstSeen *time.Time
+		LastSeen  *time.Time
+	}
+	spans := make(map[uint64]span)
+	var windows []ruleWindow
+	var checkpoints []checkpointentity.AnalysisCheckpoint
+	for i := range rules {
+		rule := &rules[i]
+		lastID := lastIDs[rule.ID]
+		if !rule.IsEnabled() || lastID >= maxID {
+			continue
+		}
+		newLogs, ok := spans[lastID]
+		if !ok {
+			if err := gormDB.Model(&logDataentity.LogData{}).
+				Select("MIN(timestamp) AS first_seen, MAX(timestamp) AS last_seen").
+				Where("id > ? AND id <= ?", lastID, maxID).Scan(&newLogs).Error; err != nil {
+				return AnalysisResult{}, fmt.Errorf("failed to read new logs for rule %s: %w", rule.ID, err)
 			}
-			result = append(result, threat)
+			spans[lastID] = newLogs
 		}
-		if err := rows.Err(); err != nil {
-			return nil, fmt.Errorf("row iteration error: %w", err)
+		if newLogs.FirstSeen == nil || newLogs.LastSeen == nil {
+			continue
 		}
-		return result, nil
-	}
-
-	// Restricted files list
-	restrictedFiles := []string{
-		"/secure/payroll.csv",
-		"/confidential/design.pdf",
-		"/db_dump.sql",
-		"/public/readme.txt",
-		"/logs/system.log",
-	}
-	fileList := "'" + strings.Join(restrictedFiles, "', '") + "'"
-
-	// 1. Credential Stuffing
-	credStuffQuery := fmt.Sprintf(`
-		SELECT DISTINCT 
-			l3.timestamp,
-			l3.user_id,
-			l3.ip_address,
-			l3.action,
-			l3.file_name,
-			l3.database_query,
-			'Credential Stuffing' AS threat_type
-		FROM log_data l3
-		JOIN (
-			SELECT l2.user_id, l2.timestamp AS success_time
-			FROM log_data l2
-			WHERE l2.action = 'login_success'
-			AND EXISTS (
-				SELECT 1
-				FROM log_data l1
-				WHERE l1.user_id = l2.user_id
-				AND l1.action = 'login_failed'
-				AND l1.timestamp <= l2.timestamp
-				AND l1.file_name IN (%s)
-				GROUP BY l1.user_id
-				HAVING COUNT(*) >= 3
-			)
-		) successful_logins
-			ON l3.user_id = successful_logins.user_id
-			AND l3.timestamp <= successful_logins.success_time
-			AND l3.action = 'login_failed'
-			AND l3.file_name IN (%s)
-		ORDER BY l3.user_id, l3.timestamp;
-	`, fileList, fileList)
-
-	credRows, err := sqlDB.Query(credStuffQuery)
-	if err != nil {
-		return 0, fmt.Errorf("credential stuffing query failed: %w", err)
+		start := *newLogs.FirstSeen
+		if cutoff := newLogs.LastSeen.Add(-maxWindow); start.Before(cutoff) {
+			log.Printf("Rule %s: new logs span %s to %s, analyzing only the last %s; analyze %s to %s explicitly to cover the rest",
+				rule.ID, start.Format(time.RFC3339), newLogs.LastSeen.Format(time.RFC3339), maxWindow,
+				start.Format(time.RFC3339), cutoff.Format(time.RFC3339))
+			start = cutoff
+		}
+		windows = append(windows, ruleWindow{rule: rule, start: start, end: *newLogs.LastSeen})
+		checkpoints = append(checkpoints, checkpointentity.AnalysisCheckpoint{
+			RuleID:        rule.ID,
+			LastLogID:     maxID,
+			LastTimestamp: newLogs.LastSeen,
+		})
 	}
-	defer credRows.Close()
+	if _, scoped := tenancy.TenantOf(gormDB.Statement.Context); scoped {
+		checkpoints = nil
+	}
+	return analyze(gormDB, windows, checkpoints)
+}
 
-	credThreats, err := scanThreat(credRows, "Credential Stuffing", "High")
-	if err != nil {
-		return 0, fmt.Errorf("scanning credential stuffing threats failed: %w", err)
-	}
-
-	threats = append(threats, credThreats...)
-
-	// 2. Privilege Escalation
-	privEscQuery := `SELECT DISTINCT 
-        lf.timestamp,
-        lf.user_id,
-        lf.ip_address,
-        lf.action,
-        lf.file_name,
-        lf.database_query,
-        'Privilege Escalation' AS threat_type
-    FROM log_data lf
-    JOIN log_data dbm
-        ON dbm.user_id = lf.user_id
-        AND dbm.database_query IS NOT NULL
-        AND (dbm.database_query LIKE '%DELETE%' OR dbm.database_query LIKE '%INSERT%')
-        AND dbm.timestamp BETWEEN lf.timestamp AND DATE_ADD(lf.timestamp, INTERVAL 5 MINUTE)
-    WHERE lf.action = 'login_failed'
-        AND lf.database_query IS NOT NULL
-        AND (lf.database_query LIKE '%DELETE%' OR lf.database_query LIKE '%INSERT%')
-    ORDER BY lf.timestamp;`
-
-	privRows, err := sqlDB.Query(privEscQuery)
-	if err != nil {
-		return 0, fmt.Errorf("privilege escalation query failed: %w", err)
+func durationEnv(name string, fallback time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(name)); err == nil && value > 0 {
+		return value
 	}
-	defer privRows.Close()
+	return fallback
+}
 
-	privThreats, err := scanThreat(privRows, "Privilege Escalation", "High")
-	if err != nil {
-		return 0, fmt.Errorf("scanning privilege escalation threats failed: %w", err)
-	}
-	threats = append(threats, privThreats...)
-
-	// 3. Account Takeover
-	accTakeoverQuery := `SELECT DISTINCT 
-			l3.timestamp,
-			l3.user_id,
-			l3.ip_address,
-			l3.action,
-			l3.file_name,
-			l3.database_query,
-			'Account Takeover' AS threat_type
-		FROM log_data l3
-		JOIN (
-			SELECT 
-				l1.user_id,
-				l1.timestamp AS first_access_time
-			FROM log_data l1
-			JOIN log_data l2
-				ON l1.user_id = l2.user_id
-				AND l1.ip_address != l2.ip_address
-				AND l2.timestamp BETWEEN l1.timestamp AND DATE_ADD(l1.timestamp, INTERVAL 10 MINUTE)
-				AND l2.file_name IN ('/secure/payroll.csv','/db_dump.sql')
-			WHERE l1.file_name IN ('/secure/payroll.csv','/db_dump.sql')
-		) suspicious_access
-			ON l3.user_id = suspicious_access.user_id
-			AND l3.timestamp BETWEEN suspicious_access.first_access_time AND DATE_ADD(suspicious_access.first_access_time, INTERVAL 10 MINUTE)
-			AND l3.file_name IN ('/confidential/design.pdf','/secure/payroll.csv','/db_dump.sql','/logs/system.log')
-		ORDER BY l3.user_id, l3.timestamp;`
-
-	accRows, err := sqlDB.Query(accTakeoverQuery)
-	if err != nil {
-		return 0, fmt.Errorf("account takeover query failed: %w", err)
+func analyze(gormDB *gorm.DB, windows []ruleWindow, checkpoints []checkpointentity.AnalysisCheckpoint) (AnalysisResult, error) {
+	if len(windows) == 0 {
+		return AnalysisResult{}, nil
+	}
+	from, to := windows[0].start.Add(-windows[0].rule.RequiredLookback()), windows[0].end
+	for _, window := range windows[1:] {
+		if start := window.start.Add(-window.rule.RequiredLookback()); start.Before(from) {
+			from = start
+		}
+		if window.end.After(to) {
+			to = window.end
+		}
 	}
-	defer accRows.Close()
 
-	accThreats, err := scanThreat(accRows, "Account Takeover", "Medium")
-	if err != nil {
-		return 0, fmt.Errorf("scanning account takeover threats failed: %w", err)
-	}
-	threats = append(threats, accThreats...)
-
-	// 4. Data Exfiltration
-	dataExfilQuery := `SELECT DISTINCT 
-			l3.timestamp,
-			l3.user_id,
-			l3.ip_address,
-			l3.action,
-			l3.file_name,
-			l3.database_query,
-			'Data Exfiltration' AS threat_type
-		FROM log_data l3
-		JOIN (
-			SELECT 
-				user_id, 
-				MIN(timestamp) AS start_time
-			FROM log_data
-			WHERE action = 'file_access'
-				AND file_name IN ('/confidential/design.pdf','/secure/payroll.csv','/db_dump.sql','/logs/system.log')
-			GROUP BY user_id, FLOOR(UNIX_TIMESTAMP(timestamp) / 30)
-			HAVING COUNT(DISTINCT file_name) >= 2
-		) suspicious_access
-			ON l3.user_id = suspicious_access.user_id
-			AND l3.timestamp BETWEEN suspicious_access.start_time AND DATE_ADD(suspicious_access.start_time, INTERVAL 30 SECOND)
-			AND l3.action = 'file_access'
-			AND l3.file_name IN ('/confidential/design.pdf','/secure/payroll.csv','/db_dump.sql','/logs/system.log')
-		ORDER BY l3.user_id, l3.timestamp;`
-
-	exfilRows, err := sqlDB.Query(dataExfilQuery)
-	if err != nil {
-		return 0, fmt.Errorf("data exfiltration query failed: %w", err)
+	var logs []logDataentity.LogData
+	if err := gormDB.Where("timestamp BETWEEN ? AND ?", from, to).
+		Order("timestamp, id").Find(&logs).Error; err != nil {
+		return AnalysisResult{}, fmt.Errorf("failed to load logs: %w", err)
 	}
-	defer exfilRows.Close()
 
-	exfilThreats, err := scanThreat(exfilRows, "Data Exfiltration", "High")
-	if err != nil {
-		return 0, fmt.Errorf("scanning data exfiltration threats failed: %w", err)
-	}
-	threats = append(threats, exfilThreats...)
-
-	// 5. Insider Threat
-	insiderThreatQuery := fmt.Sprintf(`
-		SELECT DISTINCT 
-			timestamp,
-			user_id,
-			ip_address,
-			action,
-			file_name,
-			database_query,
-			'Insider Threat' AS threat_type
-		FROM log_data
-		WHERE action = 'file_access'
-			AND HOUR(timestamp) BETWEEN 2 AND 4
-			AND file_name IN (%s)
-		ORDER BY timestamp;
-	`, fileList)
-
-	insiderRows, err := sqlDB.Query(insiderThreatQuery)
-	if err != nil {
-		return 0, fmt.Errorf("insider threat query failed: %w", err)
+	var detections []RuleDetection
+	for _, window := range windows {
+		rule := window.rule
+		for _, detection := range ruleengine.Evaluate(rule, logsBetween(logs, window.start.Add(-rule.RequiredLookback()), window.end)) {
+			if detection.Log.Timestamp.Before(window.start) {
+				continue
+			}
+			detections = append(detections, RuleDetection{Rule: rule, Detection: detection})
+		}
+	}
+	return storeDetections(gormDB, detections, checkpoints)
+}
+
+// StoreDetections upserts threats for detections made outside an analysis run,
+// such as by the streaming detector
+func StoreDetections(gormDB *gorm.DB, detections []RuleDetection) (AnalysisResult, error) {
+	return storeDetections(gormDB, detections, nil)
+}
+
+func storeDetections(gormDB *gorm.DB, detections []RuleDetection, checkpoints []checkpointentity.AnalysisCheckpoint) (AnalysisResult, error) {
+	threats := []threatentity.Threat{}
+	evidence := make(map[string][]logDataentity.LogData)
+	for _, found := range detections {
+		rule, entry := found.Rule, found.Detection.Log
+		fingerprint := ruleengine.Fingerprint(rule, &found.Detection)
+		if _, seen := evidence[fingerprint]; seen {
+			continue
+		}
+		evidence[fingerprint] = found.Detection.Evidence
+		threats = append(threats, threatentity.Threat{
+			Timestamp:     entry.Timestamp,
+			UserID:        entry.UserID,
+			IPAddress:     entry.IPAddress,
+			Action:        entry.Action,
+			FileName:      entry.FileName,
+			DatabaseQuery: entry.DatabaseQuery,
+			Source:        entry.Source,
+			Tenant:        entry.EffectiveTenant(),
+			ThreatType:    rule.ThreatType,
+			Severity:      rule.Severity,
+			RuleID:        rule.ID,
+			Fingerprint:   fingerprint,
+			Status:        threatentity.StatusOpen,
+		})
 	}
-	defer insiderRows.Close()
+	if len(threats) == 0 && len(checkpoints) == 0 {
+		return AnalysisResult{}, nil
+	}
+
+	var result AnalysisResult
+	err := gormDB.Transaction(func(tx *gorm.DB) error {
+		if len(threats) > 0 {
+			fingerprints := make([]string, 0, len(threats))
+			for _, threat := range threats {
+				fingerprints = append(fingerprints, threat.Fingerprint)
+			}
+			// Deleted threats count as known: the upsert only refreshes them and they stay deleted
+			var known []string
+			if err := tx.Unscoped().Model(&threatentity.Threat{}).Where("fingerprint IN ?", fingerprints).
+				Pluck("fingerprint", &known).Error; err != nil {
+				return fmt.Errorf("failed to look up known threats: %w", err)
+			}
+
+			// Known fingerprints only refresh what a rule edit may have changed
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "fingerprint"}},
+				DoUpdates: clause.AssignmentColumns([]string{"threat_type", "severity", "updated_at"}),
+			}).CreateInBatches(&threats, 500).Error; err != nil {
+				return fmt.Errorf("failed to upsert threats: %w", err)
+			}
+			result = AnalysisResult{NewThreats: len(threats) - len(known), KnownThreats: len(known)}
 
-	insiderThreats, err := scanThreat(insiderRows, "Insider Threat", "Medium")
+			if err := saveEvidence(tx, fingerprints, evidence); err != nil {
+				return err
+			}
+			if err := AssignIncidents(tx); err != nil {
+				return err
+			}
+		}
+
+		if len(checkpoints) > 0 {
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "rule_id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"last_log_id", "last_timestamp", "updated_at"}),
+			}).Create(&checkpoints).Error; err != nil {
+				return fmt.Errorf("failed to save checkpoints: %w", err)
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		return 0, fmt.Errorf("scanning insider threat threats failed: %w", err)
+		return AnalysisResult{}, err
 	}
-	threats = append(threats, insiderThreats...)
-	for _, threat := range threats {
-		if err := gormDB.Create(&threat).Error; err != nil {
-			return 0, fmt.Errorf("failed to insert threat: %w", err)
+
+	fmt.Printf("Inserted %d new threat records into threats table, %d already known\n", result.NewThreats, result.KnownThreats)
+	return result, nil
+}
+
+// saveEvidence links every threat, new or already known, to its evidence logs.
+// Upserted rows don't reliably report their IDs, so they are looked up by fingerprint.
+func saveEvidence(tx *gorm.DB, fingerprints []string, evidence map[string][]logDataentity.LogData) error {
+	var stored []threatentity.Threat
+	if err := tx.Select("id", "fingerprint").Where("fingerprint IN ?", fingerprints).Find(&stored).Error; err != nil {
+		return fmt.Errorf("failed to look up threat ids: %w", err)
+	}
+
+	var links []evidenceentity.ThreatEvidence
+	for _, threat := range stored {
+		for _, entry := range evidence[threat.Fingerprint] {
+			links = append(links, evidenceentity.ThreatEvidence{
+				ThreatID:     threat.ID,
+				LogID:        entry.ID,
+				LogTimestamp: entry.Timestamp,
+			})
 		}
 	}
-	fmt.Printf("Inserted %d threat records into threats table\n", len(threats))
-	return len(threats), nil
+	if len(links) == 0 {
+		return nil
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&links, 500).Error; err != nil {
+		return fmt.Errorf("failed to save threat evidence: %w", err)
+	}
+	return nil
+}
 
+// logsBetween returns the time-ordered logs within [from, to]
+func logsBetween(logs []logDataentity.LogData, from, to time.Time) []logDataentity.LogData {
+	lo := sort.Search(len(logs), func(i int) bool { return !logs[i].Timestamp.Before(from) })
+	hi := sort.Search(len(logs), func(i int) bool { return logs[i].Timestamp.After(to) })
+	if lo >= hi {
+		return nil
+	}
+	return logs[lo:hi]
 }